@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+// runConvert implements the `convert` subcommand: it converts the standard ZSTD input
+// into the seekable stream of fixed size frames, optionally at a different quality level.
+func runConvert(args []string) error {
+	var (
+		inputFlag, outputFlag string
+		chunkSizeFlag         int
+		qualityFlag           int
+	)
+
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s convert -f file -o output [-c chunk size] [-q quality]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&inputFlag, "f", "", "input filename")
+	fs.StringVar(&outputFlag, "o", "", "output filename")
+	fs.IntVar(&chunkSizeFlag, "c", 1024, "frame size (in kb)")
+	fs.IntVar(&qualityFlag, "q", 1, "compression quality (lower == faster)")
+	_ = fs.Parse(args)
+
+	if inputFlag == "" || outputFlag == "" {
+		fs.Usage()
+		return fmt.Errorf("both input and output files need to be defined")
+	}
+
+	input, err := os.Open(inputFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open input: %w", err)
+	}
+	defer input.Close()
+
+	output, err := os.OpenFile(outputFlag, os.O_TRUNC|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open output: %w", err)
+	}
+	defer output.Close()
+
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create zstd decompressor: %w", err)
+	}
+	defer dec.Close()
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(qualityFlag)))
+	if err != nil {
+		return fmt.Errorf("failed to create zstd compressor: %w", err)
+	}
+
+	if err = seekable.Convert(output, input, dec, enc, chunkSizeFlag*1024); err != nil {
+		return fmt.Errorf("failed to convert: %w", err)
+	}
+	return output.Close()
+}
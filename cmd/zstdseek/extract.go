@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/atomic"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// countingEnv counts frames fetched from the underlying environment.
+type countingEnv struct {
+	env.REnvironment
+	frames atomic.Int64
+}
+
+func (c *countingEnv) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	c.frames.Inc()
+	return c.REnvironment.GetFrameByIndex(index)
+}
+
+// runExtract implements the `extract` subcommand: it writes the [start, end) range
+// of the decompressed stream to the output, only decompressing frames overlapping it.
+func runExtract(args []string) error {
+	var (
+		inputFlag, outputFlag string
+		startFlag, endFlag    int64
+		statsFlag             bool
+	)
+
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s extract -f file [-s start] [-e end] [-o output]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&inputFlag, "f", "", "input filename")
+	fs.StringVar(&outputFlag, "o", "-", "output filename")
+	fs.Int64Var(&startFlag, "s", 0, "start offset within the decompressed stream")
+	fs.Int64Var(&endFlag, "e", -1, "end offset within the decompressed stream (exclusive), -1 means EOF")
+	fs.BoolVar(&statsFlag, "stats", false, "print the number of fetched frames to stderr")
+	_ = fs.Parse(args)
+
+	if inputFlag == "" {
+		fs.Usage()
+		return fmt.Errorf("input file needs to be defined")
+	}
+	if startFlag < 0 || (endFlag >= 0 && endFlag < startFlag) {
+		return fmt.Errorf("invalid range: [%d, %d)", startFlag, endFlag)
+	}
+
+	input, err := os.Open(inputFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open input: %w", err)
+	}
+	defer input.Close()
+
+	output := os.Stdout
+	if outputFlag != "-" {
+		output, err = os.OpenFile(outputFlag, os.O_TRUNC|os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open output: %w", err)
+		}
+		defer output.Close()
+	}
+
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create zstd decompressor: %w", err)
+	}
+	defer dec.Close()
+
+	e := &countingEnv{REnvironment: seekable.NewReadSeekerEnv(input)}
+	r, err := seekable.NewReader(nil, dec, seekable.WithREnvironment(e))
+	if err != nil {
+		return fmt.Errorf("failed to create new seekable reader: %w", err)
+	}
+	defer r.Close()
+
+	if _, err = r.Seek(startFlag, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to %d: %w", startFlag, err)
+	}
+	if endFlag < 0 {
+		_, err = io.Copy(output, r)
+	} else {
+		// Range past the end of the stream is truncated.
+		_, err = io.CopyN(output, r, endFlag-startFlag)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract data: %w", err)
+	}
+
+	if statsFlag {
+		fmt.Fprintf(os.Stderr, "frames_fetched\t%d\n", e.frames.Load())
+	}
+	return nil
+}
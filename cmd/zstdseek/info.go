@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+// runInfo implements the `info` subcommand: it prints the seek table of a seekable file
+// along with summary statistics.  Frames are not decompressed.
+func runInfo(args []string) error {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s info [-json] file\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	jsonFlag := fs.Bool("json", false, "print the seek table as JSON")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("exactly one file is expected")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open input: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat input: %w", err)
+	}
+
+	st, err := seekable.ExtractSeekTable(f)
+	if err != nil {
+		return fmt.Errorf("failed to parse seek table: %w", err)
+	}
+
+	if *jsonFlag {
+		return json.NewEncoder(os.Stdout).Encode(st)
+	}
+	return writeInfo(os.Stdout, st, fi.Size())
+}
+
+// writeInfo prints summary as "name\tvalue" lines followed by the tab-separated table of frames.
+func writeInfo(w io.Writer, st *seekable.SeekTable, fileSize int64) error {
+	var decompSize uint64
+	var maxCompSize, maxDecompSize uint32
+	for _, e := range st.Entries {
+		decompSize += uint64(e.DecompSize)
+		maxCompSize = max(maxCompSize, e.CompSize)
+		maxDecompSize = max(maxDecompSize, e.DecompSize)
+	}
+
+	var ratio, avgCompSize, avgDecompSize float64
+	if fileSize > 0 {
+		ratio = float64(decompSize) / float64(fileSize)
+	}
+	if n := len(st.Entries); n > 0 {
+		var compSize uint64
+		for _, e := range st.Entries {
+			compSize += uint64(e.CompSize)
+		}
+		avgCompSize = float64(compSize) / float64(n)
+		avgDecompSize = float64(decompSize) / float64(n)
+	}
+
+	_, err := fmt.Fprintf(w,
+		"compressed_bytes\t%d\n"+
+			"decompressed_bytes\t%d\n"+
+			"compression_ratio\t%.3f\n"+
+			"frames\t%d\n"+
+			"checksums\t%t\n"+
+			"avg_compressed_frame_size\t%.1f\n"+
+			"max_compressed_frame_size\t%d\n"+
+			"avg_frame_size\t%.1f\n"+
+			"max_frame_size\t%d\n",
+		fileSize, decompSize, ratio, len(st.Entries), st.ChecksumFlag,
+		avgCompSize, maxCompSize, avgDecompSize, maxDecompSize)
+	if err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintf(w, "\nid\tcomp_offset\tcomp_size\tdecomp_offset\tdecomp_size\tchecksum\n"); err != nil {
+		return err
+	}
+	for i, e := range st.Entries {
+		checksum := "-"
+		if st.ChecksumFlag {
+			checksum = fmt.Sprintf("%08x", e.Checksum)
+		}
+		_, err = fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%s\n",
+			i, e.CompOffset, e.CompSize, e.DecompOffset, e.DecompSize, checksum)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/SaveTheRbtz/fastcdc-go"
 	"github.com/klauspost/compress/zstd"
@@ -27,12 +28,30 @@ type readCloser struct {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		subcommands := map[string]func([]string) error{
+			"info":    runInfo,
+			"extract": runExtract,
+			"verify":  runVerify,
+			"reindex": runReindex,
+			"convert": runConvert,
+		}
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
 	ctx := context.Background()
+	start := time.Now()
 
 	var (
 		inputFlag, chunkingFlag, outputFlag string
 		qualityFlag                         int
 		verifyFlag, verboseFlag             bool
+		statsFlag, statsJSONFlag            bool
 	)
 
 	flag.StringVar(&inputFlag, "f", "", "input filename")
@@ -41,6 +60,8 @@ func main() {
 	flag.BoolVar(&verifyFlag, "t", false, "test reading after the write")
 	flag.IntVar(&qualityFlag, "q", 1, "compression quality (lower == faster)")
 	flag.BoolVar(&verboseFlag, "v", false, "be verbose")
+	flag.BoolVar(&statsFlag, "s", false, "print compression statistics to stderr")
+	flag.BoolVar(&statsJSONFlag, "sj", false, "print compression statistics to stderr as JSON")
 
 	flag.Parse()
 
@@ -139,7 +160,8 @@ func main() {
 		logger.Fatal("failed to create zstd encoder", zap.Error(err))
 	}
 
-	w, err := seekable.NewWriter(output, enc, seekable.WithWLogger(logger))
+	counter := &countingWriter{w: output}
+	w, err := seekable.NewWriter(counter, enc, seekable.WithWLogger(logger))
 	if err != nil {
 		logger.Fatal("failed to create compressed writer", zap.Error(err))
 	}
@@ -171,8 +193,10 @@ func main() {
 		return bytes.Clone(chunk.Data), nil
 	}
 
-	err = w.WriteMany(ctx, frameSource, seekable.WithWriteCallback(func(size uint32) {
+	var st stats
+	err = w.WriteMany(ctx, frameSource, seekable.WithWriteCallback(func(size, _ uint32) {
 		_ = bar.Add(int(size))
+		st.addFrame(size)
 	}))
 	if err != nil {
 		logger.Fatal("failed to write data", zap.Error(err))
@@ -180,7 +204,16 @@ func main() {
 
 	_ = bar.Finish()
 	input.Close()
-	w.Close()
+	if err = w.Close(); err != nil {
+		logger.Fatal("failed to write seek table", zap.Error(err))
+	}
+
+	if statsFlag || statsJSONFlag {
+		st.finish(counter.n, time.Since(start))
+		if err = st.write(os.Stderr, statsJSONFlag); err != nil {
+			logger.Fatal("failed to print statistics", zap.Error(err))
+		}
+	}
 
 	if verifyFlag {
 		logger.Info("verifying checksum")
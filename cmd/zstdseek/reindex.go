@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/klauspost/compress/zstd"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+const (
+	zstdFrameMagic      = 0xFD2FB528
+	skippableFrameMagic = 0x184D2A50
+	// skippableFrameHeaderSize is the size of the `Magic_Number` and `Frame_Size` fields.
+	skippableFrameHeaderSize = 8
+)
+
+// runReindex implements the `reindex` subcommand: it recovers ZSTD frames of the input
+// and writes them to the output followed by a rebuilt seek table.
+// Skippable frames, including the old seek table, are dropped.
+func runReindex(args []string) error {
+	var inputFlag, outputFlag string
+
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s reindex -f file -o output\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&inputFlag, "f", "", "input filename")
+	fs.StringVar(&outputFlag, "o", "", "output filename")
+	_ = fs.Parse(args)
+
+	if inputFlag == "" || outputFlag == "" {
+		fs.Usage()
+		return fmt.Errorf("both input and output files need to be defined")
+	}
+
+	// Frame boundaries are found by decompression, so the whole input is needed anyway.
+	data, err := os.ReadFile(inputFlag)
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	output, err := os.OpenFile(outputFlag, os.O_TRUNC|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open output: %w", err)
+	}
+	defer output.Close()
+
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create zstd decompressor: %w", err)
+	}
+	defer dec.Close()
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create zstd compressor: %w", err)
+	}
+
+	w, err := seekable.NewWriter(output, enc)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed writer: %w", err)
+	}
+
+	frames, err := reindex(data, dec, func(frame, decompressed []byte) error {
+		return w.WriteRaw(frame, uint32(len(decompressed)), uint32((xxhash.Sum64(decompressed)<<32)>>32))
+	})
+	if err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("failed to write seek table: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "frames\t%d\n", frames)
+	return output.Close()
+}
+
+// reindex scans data for ZSTD frames and calls fn with each of them along with its decompressed data.
+// Frame boundaries are the offsets of the next ZSTD or skippable frame magic that decompress successfully,
+// so that magic-like bytes within the compressed data are not mistaken for a boundary.
+// Scanning stops at the first frame that can not be decompressed, e.g. a truncated one.
+func reindex(data []byte, dec *zstd.Decoder, fn func(frame, decompressed []byte) error) (int, error) {
+	frames := 0
+	for off := 0; off+4 <= len(data); {
+		magic := binary.LittleEndian.Uint32(data[off:])
+		if magic&0xFFFFFFF0 == skippableFrameMagic {
+			if off+skippableFrameHeaderSize > len(data) {
+				break
+			}
+			off += skippableFrameHeaderSize + int(binary.LittleEndian.Uint32(data[off+4:]))
+			continue
+		}
+		if magic != zstdFrameMagic {
+			return frames, fmt.Errorf("unexpected magic at offset %d: 0x%08X", off, magic)
+		}
+
+		var decompressed []byte
+		end := off
+		for {
+			end = nextMagic(data, end+1)
+			var err error
+			if decompressed, err = dec.DecodeAll(data[off:end], decompressed[:0]); err == nil {
+				break
+			}
+			if end == len(data) {
+				// Truncated frame.
+				return frames, nil
+			}
+		}
+
+		if err := fn(data[off:end], decompressed); err != nil {
+			return frames, fmt.Errorf("failed to write frame at offset %d: %w", off, err)
+		}
+		frames++
+		off = end
+	}
+	return frames, nil
+}
+
+// nextMagic returns the offset of the next ZSTD or skippable frame magic at or after off,
+// or len(data) if there is none.
+func nextMagic(data []byte, off int) int {
+	for ; off+4 <= len(data); off++ {
+		magic := binary.LittleEndian.Uint32(data[off:])
+		if magic == zstdFrameMagic || magic&0xFFFFFFF0 == skippableFrameMagic {
+			return off
+		}
+	}
+	return len(data)
+}
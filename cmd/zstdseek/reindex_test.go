@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+func TestReindex(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	var b bytes.Buffer
+	// Metadata is written in a skippable frame before the seek table.
+	w, err := seekable.NewWriter(&b, enc, seekable.WithFrameMetadata("key", []byte("value")))
+	require.NoError(t, err)
+	var expected []byte
+	for i := 0; i < 5; i++ {
+		frame := bytes.Repeat([]byte{byte('a' + i)}, 1000*(i+1))
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		expected = append(expected, frame...)
+	}
+	require.NoError(t, w.Close())
+
+	dir := t.TempDir()
+	for name, input := range map[string][]byte{
+		"truncated": b.Bytes()[:b.Len()-5],
+		// Seek table of 5 frames and the metadata frame.
+		"no_table": b.Bytes()[:b.Len()-9-6*12-8],
+		"valid":    b.Bytes(),
+	} {
+		path := filepath.Join(dir, name+".zst")
+		require.NoError(t, os.WriteFile(path, input, 0o600))
+
+		_, err = seekable.NewReader(bytes.NewReader(input), nil, seekable.WithDefaultDecoder())
+		if name != "valid" {
+			require.Error(t, err, name)
+		}
+
+		output := filepath.Join(dir, name+".out.zst")
+		out, code := runZstdseek(t, "reindex", "-f", path, "-o", output)
+		require.Equal(t, 0, code, out)
+		assert.Contains(t, out, "frames\t5\n", name)
+
+		f, err := os.Open(output)
+		require.NoError(t, err)
+		r, err := seekable.NewReader(f, nil, seekable.WithDefaultDecoder(), seekable.WithSizeValidation())
+		require.NoError(t, err, name)
+		actual, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual, name)
+		require.NoError(t, r.Close())
+		require.NoError(t, f.Close())
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// countingWriter counts bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// stats is the compression statistics summary.
+type stats struct {
+	InputBytes       int64   `json:"input_bytes"`
+	OutputBytes      int64   `json:"output_bytes"`
+	CompressionRatio float64 `json:"compression_ratio"`
+	Frames           int64   `json:"frames"`
+	AvgFrameSize     float64 `json:"avg_frame_size"`
+	MinFrameSize     uint32  `json:"min_frame_size"`
+	MaxFrameSize     uint32  `json:"max_frame_size"`
+	WallTimeSeconds  float64 `json:"wall_time_seconds"`
+}
+
+// addFrame records a frame of the given decompressed size.
+func (s *stats) addFrame(size uint32) {
+	if s.Frames == 0 || size < s.MinFrameSize {
+		s.MinFrameSize = size
+	}
+	if size > s.MaxFrameSize {
+		s.MaxFrameSize = size
+	}
+	s.Frames++
+	s.InputBytes += int64(size)
+}
+
+// finish computes derived values once all frames and the seek table are written.
+func (s *stats) finish(outputBytes int64, elapsed time.Duration) {
+	s.OutputBytes = outputBytes
+	if outputBytes > 0 {
+		s.CompressionRatio = float64(s.InputBytes) / float64(outputBytes)
+	}
+	if s.Frames > 0 {
+		s.AvgFrameSize = float64(s.InputBytes) / float64(s.Frames)
+	}
+	s.WallTimeSeconds = elapsed.Seconds()
+}
+
+// write prints stats either as "name\tvalue" lines or as a JSON object.
+func (s *stats) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(s)
+	}
+
+	_, err := fmt.Fprintf(w,
+		"input_bytes\t%d\n"+
+			"output_bytes\t%d\n"+
+			"compression_ratio\t%.3f\n"+
+			"frames\t%d\n"+
+			"avg_frame_size\t%.1f\n"+
+			"min_frame_size\t%d\n"+
+			"max_frame_size\t%d\n"+
+			"wall_time_seconds\t%.3f\n",
+		s.InputBytes, s.OutputBytes, s.CompressionRatio, s.Frames,
+		s.AvgFrameSize, s.MinFrameSize, s.MaxFrameSize, s.WallTimeSeconds)
+	return err
+}
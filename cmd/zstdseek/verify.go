@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+// errVerificationFailed is returned by runVerify if the file has problems.
+var errVerificationFailed = fmt.Errorf("verification failed")
+
+// runVerify implements the `verify` subcommand: it checks the seek table and, unless -fast is set,
+// decompresses every frame to verify its size and checksum.
+func runVerify(args []string) error {
+	var (
+		inputFlag         string
+		fastFlag, verbose bool
+	)
+
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s verify -f file [-fast] [-v]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&inputFlag, "f", "", "input filename")
+	fs.BoolVar(&fastFlag, "fast", false, "only verify structural consistency, without decompressing frames")
+	fs.BoolVar(&verbose, "v", false, "be verbose: print the status of every frame, not only the failed ones")
+	_ = fs.Parse(args)
+
+	if inputFlag == "" {
+		fs.Usage()
+		return fmt.Errorf("input file needs to be defined")
+	}
+
+	input, err := os.Open(inputFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open input: %w", err)
+	}
+	defer input.Close()
+
+	var dec seekable.ZSTDDecoder
+	if !fastFlag {
+		zd, err := zstd.NewReader(nil)
+		if err != nil {
+			return fmt.Errorf("failed to create zstd decompressor: %w", err)
+		}
+		defer zd.Close()
+		dec = zd
+	}
+
+	problems, err := seekable.Validate(input, dec)
+	if err != nil {
+		return fmt.Errorf("failed to validate: %w", err)
+	}
+
+	// Seek table is not available if the stream can not be parsed at all.
+	var frames int
+	if st, err := seekable.ExtractSeekTable(input); err == nil {
+		frames = len(st.Entries)
+	}
+	return writeVerify(os.Stdout, frames, problems, verbose)
+}
+
+// writeVerify prints the tab-separated table of frames with their status followed by the summary.
+// Problems not specific to a frame are printed with "-" id.
+func writeVerify(w io.Writer, frames int, problems []seekable.ValidationError, verbose bool) error {
+	byFrame := make(map[int64][]seekable.ValidationError)
+	for _, p := range problems {
+		byFrame[p.FrameID] = append(byFrame[p.FrameID], p)
+	}
+
+	if _, err := fmt.Fprintf(w, "id\tstatus\terror\n"); err != nil {
+		return err
+	}
+	for _, p := range byFrame[-1] {
+		if _, err := fmt.Fprintf(w, "-\tfail\t%v\n", p); err != nil {
+			return err
+		}
+	}
+	failed := 0
+	for id := int64(0); id < int64(frames); id++ {
+		if len(byFrame[id]) == 0 {
+			if verbose {
+				if _, err := fmt.Fprintf(w, "%d\tok\t-\n", id); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		failed++
+		for _, p := range byFrame[id] {
+			if _, err := fmt.Fprintf(w, "%d\tfail\t%v\n", id, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\nframes\t%d\nfailed_frames\t%d\nproblems\t%d\n", frames, failed, len(problems))
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return errVerificationFailed
+	}
+	return nil
+}
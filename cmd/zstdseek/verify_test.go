@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+// TestMain runs main instead of tests when the test binary is re-executed by runZstdseek.
+func TestMain(m *testing.M) {
+	if os.Getenv("ZSTDSEEK_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runZstdseek runs the tool with args and returns its output and exit code.
+func runZstdseek(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "ZSTDSEEK_TEST_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	}
+	require.NoError(t, err)
+	return string(out), 0
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc)
+	require.NoError(t, err)
+	const numFrames = 3
+	for i := 0; i < numFrames; i++ {
+		_, err = w.Write(bytes.Repeat([]byte{byte('a' + i)}, 1000))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "valid.zst")
+	require.NoError(t, os.WriteFile(path, b.Bytes(), 0o600))
+
+	out, code := runZstdseek(t, "verify", "-v", "-f", path)
+	assert.Equal(t, 0, code, out)
+	assert.Contains(t, out, "1\tok\t-\n")
+	assert.Contains(t, out, "frames\t3\nfailed_frames\t0\n")
+
+	// Corrupt the checksum of the second frame:
+	// |Frame_0|...|Frame_N|Seek_Table_Entries|Seek_Table_Footer|, entries are 12 bytes with checksums last.
+	corrupted := bytes.Clone(b.Bytes())
+	checksumOff := len(corrupted) - 9 - (numFrames-1)*12 + 8
+	binary.LittleEndian.PutUint32(corrupted[checksumOff:], binary.LittleEndian.Uint32(corrupted[checksumOff:])+1)
+	path = filepath.Join(dir, "corrupted.zst")
+	require.NoError(t, os.WriteFile(path, corrupted, 0o600))
+
+	out, code = runZstdseek(t, "verify", "-f", path)
+	assert.NotEqual(t, 0, code, out)
+	assert.Contains(t, out, "1\tfail\tchecksum: frame 1: checksum mismatch")
+	assert.NotContains(t, out, "0\tok")
+	assert.Contains(t, out, "failed_frames\t1\n")
+
+	// Checksums are not verified in the fast mode.
+	out, code = runZstdseek(t, "verify", "-fast", "-f", path)
+	assert.Equal(t, 0, code, out)
+
+	// Truncated files are structural errors.
+	path = filepath.Join(dir, "truncated.zst")
+	require.NoError(t, os.WriteFile(path, b.Bytes()[:len(b.Bytes())-1], 0o600))
+	out, code = runZstdseek(t, "verify", "-fast", "-f", path)
+	assert.NotEqual(t, 0, code, out)
+	assert.Contains(t, out, "-\tfail\tstructural")
+}
@@ -0,0 +1,181 @@
+package seekable
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"go.uber.org/multierr"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// FrameGap describes compressed data missing between two consecutive frames.
+type FrameGap struct {
+	// AfterFrameID is the ID of the frame preceding the gap, -1 for a gap before the first frame.
+	AfterFrameID int64
+	// GapBytes is the size of the gap.  Negative values mean that frames overlap.
+	GapBytes int64
+}
+
+// FindGaps returns discontinuities in the compressed stream described by the decoder's index:
+// before the first frame and between consecutive frames.
+//
+// This is a purely metadata analysis and does not do any I/O.  Since the seek table does not
+// record the size of the compressed stream, data missing after the last frame can not be detected.
+func FindGaps(d Decoder) []FrameGap {
+	var gaps []FrameGap
+
+	var expected uint64
+	prevID := int64(-1)
+	forEachFrame(d, func(index *env.FrameOffsetEntry) bool {
+		if index.CompOffset != expected {
+			gaps = append(gaps, FrameGap{
+				AfterFrameID: prevID,
+				GapBytes:     int64(index.CompOffset - expected),
+			})
+		}
+		expected = index.CompOffset + uint64(index.CompSize)
+		prevID = index.ID
+		return true
+	})
+	return gaps
+}
+
+// ChecksumCollision describes two frames that share the same checksum.
+type ChecksumCollision struct {
+	FrameID1 int64
+	FrameID2 int64
+	Checksum uint32
+}
+
+// FindChecksumCollisions returns pairs of frames that have equal 32-bit checksums.
+// Frames sharing a checksum are reported as consecutive pairs ordered by frame ID,
+// e.g. three frames 1, 5, 7 with the same checksum produce (1, 5) and (5, 7).
+//
+// Equal checksums are expected for frames with equal content (e.g. in deduplicated archives),
+// so callers may want to decompress both frames to confirm that they actually differ.
+// Streams without checksums yield no collisions.
+//
+// This is a purely metadata analysis and does not do any I/O.  It runs in O(N log N) of frames.
+func FindChecksumCollisions(d Decoder) []ChecksumCollision {
+	if r, ok := d.(*readerImpl); ok && !r.checksums {
+		return nil
+	}
+
+	var entries []*env.FrameOffsetEntry
+	forEachFrame(d, func(index *env.FrameOffsetEntry) bool {
+		entries = append(entries, index)
+		return true
+	})
+	slices.SortFunc(entries, func(a, b *env.FrameOffsetEntry) int {
+		if c := cmp.Compare(a.Checksum, b.Checksum); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	var collisions []ChecksumCollision
+	for i := 1; i < len(entries); i++ {
+		if entries[i-1].Checksum == entries[i].Checksum {
+			collisions = append(collisions, ChecksumCollision{
+				FrameID1: entries[i-1].ID,
+				FrameID2: entries[i].ID,
+				Checksum: entries[i].Checksum,
+			})
+		}
+	}
+	return collisions
+}
+
+// VerifyIndex checks semantic consistency of parsed seek table entries:
+// IDs are sequential starting from 0, the first frame starts at decompressed offset 0,
+// each frame starts where the previous one ends (both compressed and decompressed offsets),
+// and frames with decompressed data are not empty in the compressed stream.
+//
+// Frames with no decompressed data share the decompressed offset with the next frame,
+// so decompressed offsets are only strictly increasing for non-empty frames.
+//
+// This is a purely metadata analysis and does not do any I/O.
+func VerifyIndex(entries []env.FrameOffsetEntry) error {
+	for i, e := range entries {
+		if e.ID != int64(i) {
+			return fmt.Errorf("frame %d: unexpected ID: %d", i, e.ID)
+		}
+		if e.CompSize == 0 && e.DecompSize != 0 {
+			return fmt.Errorf("frame %d: compressed size is 0 for decompressed size %d", i, e.DecompSize)
+		}
+
+		if i == 0 {
+			if e.DecompOffset != 0 {
+				return fmt.Errorf("frame %d: decompressed offset is not 0: %d", i, e.DecompOffset)
+			}
+			continue
+		}
+
+		prev := entries[i-1]
+		if e.CompOffset != prev.CompOffset+uint64(prev.CompSize) {
+			return fmt.Errorf("frame %d: compressed offset mismatch: expected: %d, actual: %d",
+				i, prev.CompOffset+uint64(prev.CompSize), e.CompOffset)
+		}
+		if e.DecompOffset != prev.DecompOffset+uint64(prev.DecompSize) {
+			return fmt.Errorf("frame %d: decompressed offset mismatch: expected: %d, actual: %d",
+				i, prev.DecompOffset+uint64(prev.DecompSize), e.DecompOffset)
+		}
+	}
+	return nil
+}
+
+// Validate checks that IDs of the index are sequential starting from 0 and that each frame starts
+// where the previous one ends, both in the compressed and the decompressed stream.
+// Sizes are stored as uint32, so they can not overflow it, but compressed sizes are also checked
+// against the limit set by WithMaxFrameSize, since such frames can not be read.
+// Unlike VerifyIndex, all violations are collected and returned combined with multierr.
+//
+// For the hierarchical index, spans are validated instead of frames.
+func (r *readerImpl) Validate() error {
+	var errs []error
+	var prev *env.FrameOffsetEntry
+	var id int64
+	r.index.Ascend(func(e *env.FrameOffsetEntry) bool {
+		if e.ID != id {
+			errs = append(errs, fmt.Errorf("frame %d: unexpected ID: %d", id, e.ID))
+		}
+		if !r.hierarchical && int64(e.CompSize) > r.maxFrameSize {
+			errs = append(errs, fmt.Errorf("frame %d: compressed size is too big: %d > %d",
+				id, e.CompSize, r.maxFrameSize))
+		}
+		if prev != nil {
+			if e.CompOffset != prev.CompOffset+uint64(prev.CompSize) {
+				errs = append(errs, fmt.Errorf("frame %d: compressed offset mismatch: expected: %d, actual: %d",
+					id, prev.CompOffset+uint64(prev.CompSize), e.CompOffset))
+			}
+			if e.DecompOffset != prev.DecompOffset+uint64(prev.DecompSize) {
+				errs = append(errs, fmt.Errorf("frame %d: decompressed offset mismatch: expected: %d, actual: %d",
+					id, prev.DecompOffset+uint64(prev.DecompSize), e.DecompOffset))
+			}
+		}
+		prev = e
+		id++
+		return true
+	})
+	return multierr.Combine(errs...)
+}
+
+// forEachFrame calls fn for each frame of the decoder in order until fn returns false.
+func forEachFrame(d Decoder, fn func(index *env.FrameOffsetEntry) bool) {
+	if r, ok := d.(*readerImpl); ok {
+		r.index.Ascend(fn)
+		return
+	}
+
+	for id := int64(0); id < d.NumFrames(); id++ {
+		index := d.GetIndexByID(id)
+		if index == nil {
+			continue
+		}
+		if !fn(index) {
+			return
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package seekable
+
+import (
+	"testing"
+
+	"github.com/google/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// newTestDecoder returns a Decoder backed by the given (possibly inconsistent) entries.
+func newTestDecoder(entries []env.FrameOffsetEntry) *readerImpl {
+	r := &readerImpl{index: btree.NewG(8, env.Less)}
+	for i := range entries {
+		e := entries[i]
+		r.index.ReplaceOrInsert(&e)
+		r.numFrames = e.ID + 1
+		r.endOffset = int64(e.DecompOffset) + int64(e.DecompSize)
+	}
+	return r
+}
+
+func TestFindGaps(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDecoder(checksum[17+18:], nil)
+	require.NoError(t, err)
+	assert.Empty(t, FindGaps(d))
+
+	d = newTestDecoder([]env.FrameOffsetEntry{
+		{ID: 0, CompOffset: 10, CompSize: 10, DecompOffset: 0, DecompSize: 5},
+		{ID: 1, CompOffset: 20, CompSize: 10, DecompOffset: 5, DecompSize: 5},
+		{ID: 2, CompOffset: 35, CompSize: 10, DecompOffset: 10, DecompSize: 5},
+		{ID: 3, CompOffset: 40, CompSize: 10, DecompOffset: 15, DecompSize: 5},
+	})
+	assert.Equal(t, []FrameGap{
+		{AfterFrameID: -1, GapBytes: 10},
+		{AfterFrameID: 1, GapBytes: 5},
+		{AfterFrameID: 2, GapBytes: -5},
+	}, FindGaps(d))
+}
+
+func TestFindChecksumCollisions(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDecoder(checksum[17+18:], nil)
+	require.NoError(t, err)
+	assert.Empty(t, FindChecksumCollisions(d))
+
+	b := NewSeekTableBuilder(true)
+	b.AddFrame(10, 5, 0xdeadbeef)
+	b.AddFrame(10, 5, 0x12345678)
+	b.AddFrame(10, 5, 0xdeadbeef)
+	b.AddFrame(10, 5, 0x00000001)
+	b.AddFrame(10, 5, 0xdeadbeef)
+	b.AddFrame(10, 5, 0x00000001)
+	table, err := b.Bytes()
+	require.NoError(t, err)
+
+	d, err = NewDecoder(table, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []ChecksumCollision{
+		{FrameID1: 3, FrameID2: 5, Checksum: 0x00000001},
+		{FrameID1: 0, FrameID2: 2, Checksum: 0xdeadbeef},
+		{FrameID1: 2, FrameID2: 4, Checksum: 0xdeadbeef},
+	}, FindChecksumCollisions(d))
+
+	// All-zero checksums of a stream without checksums are not collisions.
+	b = NewSeekTableBuilder(false)
+	b.AddFrame(10, 5, 0)
+	b.AddFrame(10, 5, 0)
+	table, err = b.Bytes()
+	require.NoError(t, err)
+
+	d, err = NewDecoder(table, nil)
+	require.NoError(t, err)
+	assert.Empty(t, FindChecksumCollisions(d))
+}
+
+func TestVerifyIndex(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, VerifyIndex(nil))
+	require.NoError(t, VerifyIndex([]env.FrameOffsetEntry{
+		{ID: 0, CompOffset: 4, CompSize: 10, DecompOffset: 0, DecompSize: 5},
+		{ID: 1, CompOffset: 14, CompSize: 9, DecompOffset: 5, DecompSize: 0},
+		{ID: 2, CompOffset: 23, CompSize: 10, DecompOffset: 5, DecompSize: 5},
+	}))
+
+	for name, tc := range map[string]struct {
+		entries []env.FrameOffsetEntry
+		err     string
+	}{
+		"ID": {
+			entries: []env.FrameOffsetEntry{
+				{ID: 1, CompSize: 10, DecompSize: 5},
+			},
+			err: "frame 0: unexpected ID: 1",
+		},
+		"first decompressed offset": {
+			entries: []env.FrameOffsetEntry{
+				{ID: 0, CompSize: 10, DecompOffset: 1, DecompSize: 5},
+			},
+			err: "frame 0: decompressed offset is not 0",
+		},
+		"empty compressed frame": {
+			entries: []env.FrameOffsetEntry{
+				{ID: 0, CompSize: 10, DecompSize: 5},
+				{ID: 1, CompOffset: 10, CompSize: 0, DecompOffset: 5, DecompSize: 5},
+			},
+			err: "frame 1: compressed size is 0",
+		},
+		"compressed offset": {
+			entries: []env.FrameOffsetEntry{
+				{ID: 0, CompSize: 10, DecompSize: 5},
+				{ID: 1, CompOffset: 11, CompSize: 10, DecompOffset: 5, DecompSize: 5},
+			},
+			err: "frame 1: compressed offset mismatch: expected: 10, actual: 11",
+		},
+		"decompressed offset": {
+			entries: []env.FrameOffsetEntry{
+				{ID: 0, CompSize: 10, DecompSize: 5},
+				{ID: 1, CompOffset: 10, CompSize: 10, DecompOffset: 4, DecompSize: 5},
+			},
+			err: "frame 1: decompressed offset mismatch: expected: 5, actual: 4",
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorContains(t, VerifyIndex(tc.entries), tc.err)
+		})
+	}
+
+	// Seek tables are verified with WithSizeValidation.
+	b := NewSeekTableBuilder(true)
+	b.AddFrame(10, 5, 0)
+	b.AddFrame(0, 5, 0)
+	table, err := b.Bytes()
+	require.NoError(t, err)
+
+	_, err = NewDecoder(table, nil)
+	require.NoError(t, err)
+	_, err = NewDecoder(table, nil, WithSizeValidation())
+	require.ErrorContains(t, err, "failed to verify seek table: frame 1: compressed size is 0")
+}
+
+func TestDecoderValidate(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDecoder(checksum[17+18:], nil)
+	require.NoError(t, err)
+	require.NoError(t, d.Validate())
+
+	r := newTestDecoder([]env.FrameOffsetEntry{
+		{ID: 0, CompOffset: 0, CompSize: 10, DecompOffset: 0, DecompSize: 5},
+		// Gap in IDs and both offsets.
+		{ID: 2, CompOffset: 11, CompSize: 10, DecompOffset: 6, DecompSize: 5},
+		{ID: 3, CompOffset: 21, CompSize: 100, DecompOffset: 11, DecompSize: 5},
+		// Overlapping frames.
+		{ID: 4, CompOffset: 120, CompSize: 10, DecompOffset: 15, DecompSize: 5},
+	})
+	r.maxFrameSize = 50
+	err = r.Validate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"frame 1: unexpected ID: 2",
+		"frame 1: compressed offset mismatch: expected: 10, actual: 11",
+		"frame 1: decompressed offset mismatch: expected: 5, actual: 6",
+		"frame 2: unexpected ID: 3",
+		"frame 2: compressed size is too big: 100 > 50",
+		"frame 3: unexpected ID: 4",
+		"frame 3: compressed offset mismatch: expected: 121, actual: 120",
+		"frame 3: decompressed offset mismatch: expected: 16, actual: 15",
+	}, errorStrings(multierr.Errors(err)))
+}
+
+func errorStrings(errs []error) []string {
+	res := make([]string, 0, len(errs))
+	for _, err := range errs {
+		res = append(res, err.Error())
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+package seekable
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// EncodeBatch packs a batch of records into a single seekable stream, one frame per record.
+// This is useful for transports with binary message semantics, e.g. Kafka, NATS or gRPC.
+func EncodeBatch(frames [][]byte, enc ZSTDEncoder) ([]byte, error) {
+	e, err := NewEncoder(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []byte
+	for i, frame := range frames {
+		dst, err := e.Encode(frame)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode frame %d: %w", i, err)
+		}
+		res = append(res, dst...)
+	}
+
+	seekTable, err := e.EndStream()
+	if err != nil {
+		return nil, err
+	}
+	return append(res, seekTable...), nil
+}
+
+// DecodeBatch unpacks records of a seekable stream produced by EncodeBatch
+// (or any other seekable stream), one record per frame.
+// Checksums of frames are verified if present.
+func DecodeBatch(data []byte, dec ZSTDDecoder) ([][]byte, error) {
+	if dec == nil {
+		return nil, fmt.Errorf("decoder is required")
+	}
+
+	sr, err := NewReader(bytes.NewReader(data), dec)
+	if err != nil {
+		return nil, err
+	}
+	defer sr.Close()
+
+	r := sr.(*readerImpl)
+	if r.hierarchical {
+		return nil, fmt.Errorf("hierarchical index is not supported")
+	}
+
+	frames := make([][]byte, 0, r.NumFrames())
+	r.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		if index.DecompSize == 0 {
+			frames = append(frames, []byte{})
+			return true
+		}
+
+		var frame []byte
+		frame, err = r.decompressFrame(r.env, dec, index)
+		if err != nil {
+			return false
+		}
+		frames = append(frames, frame)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
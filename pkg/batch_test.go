@@ -0,0 +1,56 @@
+package seekable
+
+import (
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	for _, frames := range [][][]byte{
+		{},
+		{[]byte("test")},
+		{[]byte("test"), {}, makeTestFrame(t, 1), []byte("test2")},
+	} {
+		data, err := EncodeBatch(frames, enc)
+		require.NoError(t, err)
+
+		decoded, err := DecodeBatch(data, dec)
+		require.NoError(t, err)
+		assert.Equal(t, frames, decoded)
+
+		// Stream is valid ZSTD data.
+		concat, err := dec.DecodeAll(data, []byte{})
+		require.NoError(t, err)
+		var expected []byte
+		for _, frame := range frames {
+			expected = append(expected, frame...)
+		}
+		assert.Equal(t, len(expected), len(concat))
+	}
+
+	// Fixture.
+	decoded, err := DecodeBatch(checksum, dec)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("test"), []byte("test2")}, decoded)
+
+	_, err = DecodeBatch(checksum, nil)
+	require.ErrorContains(t, err, "decoder is required")
+
+	// Corruption.
+	_, err = DecodeBatch(checksum[1:], dec)
+	require.ErrorContains(t, err, "frame 0")
+	_, err = DecodeBatch(checksum[:17], dec)
+	require.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package seekable
+
+// SeekTableBuilder incrementally builds a seek table for frames that were
+// compressed and written by the caller.  This is useful when frames are produced
+// outside of the Writer, e.g. by multiple processes.
+//
+// SeekTableBuilder is not goroutine-safe.
+type SeekTableBuilder struct {
+	checksums bool
+	entries   []seekTableEntry
+}
+
+// NewSeekTableBuilder returns an empty seek table builder.
+// If checksums is false, checksums passed to AddFrame are ignored and
+// the resulting seek table has `Checksum_Flag` unset.
+func NewSeekTableBuilder(checksums bool) *SeekTableBuilder {
+	return &SeekTableBuilder{checksums: checksums}
+}
+
+// AddFrame appends a frame to the seek table.
+// checksum is the lower 32 bits of the XXH64 hash of the uncompressed data.
+func (b *SeekTableBuilder) AddFrame(compSize, decompSize uint32, checksum uint32) {
+	e := seekTableEntry{
+		CompressedSize:   compSize,
+		DecompressedSize: decompSize,
+	}
+	if b.checksums {
+		e.Checksum = checksum
+	}
+	b.entries = append(b.entries, e)
+}
+
+// Len returns the number of frames added so far.
+func (b *SeekTableBuilder) Len() int {
+	return len(b.entries)
+}
+
+// Bytes returns the seek table as a ZSTD's skippable frame.
+// For the same frames it produces the same bytes as Encoder's EndStream.
+func (b *SeekTableBuilder) Bytes() ([]byte, error) {
+	return marshalSeekTable(b.entries, b.checksums)
+}
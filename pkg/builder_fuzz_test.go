@@ -0,0 +1,76 @@
+//go:build go1.18
+// +build go1.18
+
+package seekable
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzSeekTableBuilder(f *testing.F) {
+	f.Add(int64(1), uint16(0), true)
+	f.Add(int64(2), uint16(1), false)
+	f.Add(int64(3), uint16(100), true)
+	f.Add(int64(4), uint16(1000), false)
+
+	// randomSize returns a random size biased towards edge cases.
+	randomSize := func(rng *rand.Rand) uint32 {
+		switch rng.Intn(4) {
+		case 0:
+			return 0
+		case 1:
+			return math.MaxUint32 - uint32(rng.Intn(2))
+		default:
+			return rng.Uint32()
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64, frames uint16, checksums bool) {
+		rng := rand.New(rand.NewSource(seed))
+
+		b := NewSeekTableBuilder(checksums)
+		expected := make([]seekTableEntry, frames)
+		for i := range expected {
+			expected[i] = seekTableEntry{
+				CompressedSize:   randomSize(rng),
+				DecompressedSize: randomSize(rng),
+				Checksum:         rng.Uint32(),
+			}
+			b.AddFrame(expected[i].CompressedSize, expected[i].DecompressedSize, expected[i].Checksum)
+		}
+		require.Equal(t, int(frames), b.Len())
+
+		seekTable, err := b.Bytes()
+		require.NoError(t, err)
+
+		d, err := NewDecoder(seekTable, nil)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, d.Close()) }()
+		require.Equal(t, int64(frames), d.NumFrames())
+
+		var compOffset, decompOffset uint64
+		for i, e := range expected {
+			index := d.GetIndexByID(int64(i))
+			require.NotNil(t, index)
+
+			assert.Equal(t, e.CompressedSize, index.CompSize)
+			assert.Equal(t, e.DecompressedSize, index.DecompSize)
+			assert.Equal(t, compOffset, index.CompOffset)
+			assert.Equal(t, decompOffset, index.DecompOffset)
+			if checksums {
+				assert.Equal(t, e.Checksum, index.Checksum)
+			} else {
+				assert.Zero(t, index.Checksum)
+			}
+
+			compOffset += uint64(e.CompressedSize)
+			decompOffset += uint64(e.DecompressedSize)
+		}
+		assert.Equal(t, int64(decompOffset), d.Size())
+	})
+}
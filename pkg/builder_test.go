@@ -0,0 +1,53 @@
+package seekable
+
+import (
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeekTableBuilder(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	e, err := NewEncoder(enc)
+	require.NoError(t, err)
+
+	b := NewSeekTableBuilder(true)
+	assert.Equal(t, 0, b.Len())
+
+	for _, src := range []string{sourceString[:4], sourceString[4:]} {
+		_, err := e.Encode([]byte(src))
+		require.NoError(t, err)
+
+		entry := e.(*writerImpl).frameEntries[b.Len()]
+		b.AddFrame(entry.CompressedSize, entry.DecompressedSize, entry.Checksum)
+	}
+	assert.Equal(t, 2, b.Len())
+
+	expected, err := e.EndStream()
+	require.NoError(t, err)
+	actual, err := b.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	// No checksums.
+	b = NewSeekTableBuilder(false)
+	b.AddFrame(0x11, 4, 0xdb678139)
+	b.AddFrame(0x12, 5, 0x7111eb87)
+	actual, err = b.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, noChecksum[17+18:], actual)
+
+	// Checksums.
+	b = NewSeekTableBuilder(true)
+	b.AddFrame(0x11, 4, 0xdb678139)
+	b.AddFrame(0x12, 5, 0x7111eb87)
+	actual, err = b.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, checksum[17+18:], actual)
+}
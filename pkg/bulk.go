@@ -0,0 +1,130 @@
+package seekable
+
+import (
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// ReadRange is a range of the decompressed stream requested by BulkReadAt.
+type ReadRange struct {
+	Offset int64
+	Length int
+}
+
+// fetchedFramesEnv serves frames fetched upfront by env.BulkFrameFetcher
+// and falls back to the underlying environment for the rest.
+type fetchedFramesEnv struct {
+	env.REnvironment
+	frames map[int64][]byte
+}
+
+func (e *fetchedFramesEnv) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	if frame, ok := e.frames[index.ID]; ok {
+		return frame, nil
+	}
+	return e.REnvironment.GetFrameByIndex(index)
+}
+
+// BulkReadAt reads multiple ranges of the decompressed stream.  Frames overlapping several ranges
+// are decompressed only once.  If the environment implements env.BulkFrameFetcher, frames
+// that are not cached are fetched with a single GetFramesByIndex call, otherwise up to
+// WithParallelReadAt frames are fetched and decompressed concurrently.
+//
+// All ranges have to be within the stream.
+func (r *readerImpl) BulkReadAt(ranges []ReadRange) ([][]byte, error) {
+	var indexes []*env.FrameOffsetEntry
+	seen := make(map[int64]bool)
+	for i, rng := range ranges {
+		if rng.Offset < 0 || rng.Length < 0 || rng.Offset+int64(rng.Length) > r.endOffset {
+			return nil, fmt.Errorf("range %d is out of bounds: [%d, %d) for stream of size %d",
+				i, rng.Offset, rng.Offset+int64(rng.Length), r.endOffset)
+		}
+		for off := rng.Offset; off < rng.Offset+int64(rng.Length); {
+			index, err := r.frameByDecompOffset(off)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[index.ID] {
+				seen[index.ID] = true
+				indexes = append(indexes, index)
+			}
+			off = int64(index.DecompOffset) + int64(index.DecompSize)
+		}
+	}
+
+	e, err := r.bulkFetch(indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make(map[int64][]byte, len(indexes))
+	decompressed := make([][]byte, len(indexes))
+	errs := make([]error, len(indexes))
+	var g errgroup.Group
+	g.SetLimit(max(r.parallelReadAt, 1))
+	for i, index := range indexes {
+		g.Go(func() error {
+			decompressed[i], errs[i] = r.frameFrom(e, index)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	for i, index := range indexes {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		frames[index.ID] = decompressed[i]
+	}
+
+	res := make([][]byte, len(ranges))
+	for i, rng := range ranges {
+		res[i] = make([]byte, rng.Length)
+		for n := 0; n < rng.Length; {
+			// Frames were found above, so the lookup can not fail.
+			index, _ := r.frameByDecompOffset(rng.Offset + int64(n))
+			n += copy(res[i][n:], frames[index.ID][uint64(rng.Offset+int64(n))-index.DecompOffset:])
+		}
+	}
+	return res, nil
+}
+
+// bulkFetch fetches frames that are not cached with a single call if the environment
+// implements env.BulkFrameFetcher, and returns the environment serving them.
+func (r *readerImpl) bulkFetch(indexes []*env.FrameOffsetEntry) (env.REnvironment, error) {
+	e := r.env
+	if s, ok := e.(*seekTableBytesEnv); ok {
+		e = s.REnvironment
+	}
+	fetcher, ok := e.(env.BulkFrameFetcher)
+	if !ok {
+		return r.env, nil
+	}
+
+	var missing []env.FrameOffsetEntry
+	for _, index := range indexes {
+		if !r.cache.has(index.ID) {
+			missing = append(missing, *index)
+		}
+	}
+	if len(missing) == 0 {
+		return r.env, nil
+	}
+
+	fetched, err := fetcher.GetFramesByIndex(missing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %d frames: %w", len(missing), err)
+	}
+	if len(fetched) != len(missing) {
+		return nil, fmt.Errorf("number of fetched frames does not match: expected: %d, actual: %d",
+			len(missing), len(fetched))
+	}
+
+	frames := make(map[int64][]byte, len(missing))
+	for i, index := range missing {
+		frames[index.ID] = fetched[i]
+	}
+	return &fetchedFramesEnv{REnvironment: r.env, frames: frames}, nil
+}
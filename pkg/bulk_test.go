@@ -0,0 +1,125 @@
+package seekable
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+type countingDecoder struct {
+	ZSTDDecoder
+	calls atomic.Int64
+}
+
+func (d *countingDecoder) DecodeAll(input, dst []byte) ([]byte, error) {
+	d.calls.Inc()
+	return d.ZSTDDecoder.DecodeAll(input, dst)
+}
+
+// bulkReadEnvironment implements env.BulkFrameFetcher and counts calls of both fetching methods.
+type bulkReadEnvironment struct {
+	countingReadEnvironment
+	bulkCalls atomic.Int64
+}
+
+func (e *bulkReadEnvironment) GetFramesByIndex(indexes []env.FrameOffsetEntry) ([][]byte, error) {
+	e.bulkCalls.Inc()
+	frames := make([][]byte, len(indexes))
+	for i, index := range indexes {
+		var err error
+		frames[i], err = e.REnvironment.GetFrameByIndex(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return frames, nil
+}
+
+func TestBulkReadAt(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	zdec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer zdec.Close()
+
+	frames := []string{strings.Repeat("a", 100), strings.Repeat("b", 100), strings.Repeat("c", 100)}
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	for _, f := range frames {
+		_, err = w.Write([]byte(f))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	original := []byte(strings.Join(frames, ""))
+
+	ranges := []ReadRange{
+		{Offset: 0, Length: 10},
+		{Offset: 50, Length: 100},
+		{Offset: 120, Length: 0},
+		{Offset: 95, Length: 200},
+		{Offset: 299, Length: 1},
+	}
+
+	t.Run("environment", func(t *testing.T) {
+		dec := &countingDecoder{ZSTDDecoder: zdec}
+		e := &countingReadEnvironment{REnvironment: NewReadSeekerEnv(bytes.NewReader(b.Bytes()))}
+		r, err := NewReader(nil, dec, WithREnvironment(e))
+		require.NoError(t, err)
+		defer func() { require.NoError(t, r.Close()) }()
+
+		res, err := r.BulkReadAt(ranges)
+		require.NoError(t, err)
+		require.Len(t, res, len(ranges))
+		for i, rng := range ranges {
+			assert.Equal(t, original[rng.Offset:rng.Offset+int64(rng.Length)], res[i], "range %d", i)
+		}
+		assert.LessOrEqual(t, dec.calls.Load(), int64(3))
+		assert.Equal(t, int64(3), e.calls.Load())
+	})
+
+	t.Run("bulk fetcher", func(t *testing.T) {
+		dec := &countingDecoder{ZSTDDecoder: zdec}
+		e := &bulkReadEnvironment{
+			countingReadEnvironment: countingReadEnvironment{REnvironment: NewReadSeekerEnv(bytes.NewReader(b.Bytes()))},
+		}
+		r, err := NewSyncReader(nil, dec, WithREnvironment(e), WithCacheSize(3))
+		require.NoError(t, err)
+		defer func() { require.NoError(t, r.Close()) }()
+
+		res, err := r.BulkReadAt(ranges)
+		require.NoError(t, err)
+		for i, rng := range ranges {
+			assert.Equal(t, original[rng.Offset:rng.Offset+int64(rng.Length)], res[i], "range %d", i)
+		}
+		assert.Equal(t, int64(3), dec.calls.Load())
+		assert.Equal(t, int64(1), e.bulkCalls.Load())
+		assert.Equal(t, int64(0), e.calls.Load())
+
+		// Cached frames are neither fetched nor decompressed again.
+		_, err = r.BulkReadAt(ranges)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), dec.calls.Load())
+		assert.Equal(t, int64(1), e.bulkCalls.Load())
+	})
+
+	t.Run("out of bounds", func(t *testing.T) {
+		r, err := NewReader(bytes.NewReader(b.Bytes()), zdec)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, r.Close()) }()
+
+		for _, rng := range []ReadRange{{Offset: -1, Length: 1}, {Offset: 0, Length: -1}, {Offset: 250, Length: 51}} {
+			_, err = r.BulkReadAt([]ReadRange{{Offset: 0, Length: 1}, rng})
+			require.ErrorContains(t, err, "range 1 is out of bounds")
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package seekable
+
+import (
+	"container/list"
+	"sync"
+)
+
+// frameCache is a goroutine-safe LRU cache of decompressed frames keyed by frame ID.
+// It is bounded both by the number of frames and, optionally, by their total size.
+// Prefetched frames are kept in addition to the capacity until they are read,
+// they are only evicted to fit the total size.
+type frameCache struct {
+	m sync.Mutex
+
+	capacity int
+	// prefetched is the number of prefetched frames that were not read yet.
+	prefetched int
+	// maxBytes is the limit on the total size of cached frames, 0 means unlimited.
+	maxBytes int64
+	bytes    int64
+
+	lru   *list.List
+	items map[int64]*list.Element
+}
+
+type cachedFrame struct {
+	id         int64
+	data       []byte
+	prefetched bool
+}
+
+func newFrameCache(capacity int, maxBytes int64) *frameCache {
+	return &frameCache{
+		capacity: capacity,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		items:    make(map[int64]*list.Element),
+	}
+}
+
+// get returns the frame data and marks it as recently used.
+// Prefetched frame counts towards the capacity once it is read.
+func (c *frameCache) get(id int64) ([]byte, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	e, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	frame := e.Value.(*cachedFrame)
+	if frame.prefetched {
+		frame.prefetched = false
+		c.prefetched--
+		c.evict()
+	}
+	return frame.data, true
+}
+
+// has reports whether the frame is cached without marking it as used.
+func (c *frameCache) has(id int64) bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	_, ok := c.items[id]
+	return ok
+}
+
+// put adds the frame to the cache evicting the least recently used frames if needed.
+// Frames larger than the byte capacity are not cached.
+func (c *frameCache) put(id int64, data []byte) {
+	c.add(id, data, false)
+}
+
+// putPrefetched is like put, but the frame is kept until it is read, see get.
+func (c *frameCache) putPrefetched(id int64, data []byte) {
+	c.add(id, data, true)
+}
+
+// pin makes the cached frame prefetched, it returns false if the frame is not cached.
+func (c *frameCache) pin(id int64) bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	e, ok := c.items[id]
+	if !ok {
+		return false
+	}
+	if frame := e.Value.(*cachedFrame); !frame.prefetched {
+		frame.prefetched = true
+		c.prefetched++
+	}
+	return true
+}
+
+func (c *frameCache) add(id int64, data []byte, prefetched bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if e, ok := c.items[id]; ok {
+		c.removeElement(e)
+	}
+	if c.maxBytes > 0 && int64(len(data)) > c.maxBytes {
+		return
+	}
+
+	c.items[id] = c.lru.PushFront(&cachedFrame{id: id, data: data, prefetched: prefetched})
+	c.bytes += int64(len(data))
+	if prefetched {
+		c.prefetched++
+	}
+	c.evict()
+}
+
+// evict removes the least recently used frames until the cache fits its bounds.
+// Prefetched frames are skipped unless the total size is exceeded.
+func (c *frameCache) evict() {
+	for e := c.lru.Back(); e != nil; {
+		prev := e.Prev()
+		overBytes := c.maxBytes > 0 && c.bytes > c.maxBytes
+		if !overBytes && c.lru.Len()-c.prefetched <= c.capacity {
+			return
+		}
+		if overBytes || !e.Value.(*cachedFrame).prefetched {
+			c.removeElement(e)
+		}
+		e = prev
+	}
+}
+
+// len returns the number of cached frames.
+func (c *frameCache) len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.lru.Len()
+}
+
+// size returns the total size of cached frames.
+func (c *frameCache) size() int64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.bytes
+}
+
+// clear drops all cached frames.
+func (c *frameCache) clear() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.lru.Init()
+	clear(c.items)
+	c.bytes = 0
+	c.prefetched = 0
+}
+
+func (c *frameCache) removeElement(e *list.Element) {
+	frame := c.lru.Remove(e).(*cachedFrame)
+	delete(c.items, frame.id)
+	c.bytes -= int64(len(frame.data))
+	if frame.prefetched {
+		c.prefetched--
+	}
+}
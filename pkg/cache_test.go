@@ -0,0 +1,116 @@
+package seekable
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameCache(t *testing.T) {
+	t.Parallel()
+
+	c := newFrameCache(2, 0)
+
+	_, ok := c.get(0)
+	assert.False(t, ok)
+
+	c.put(0, []byte("0"))
+	c.put(1, []byte("1"))
+	assert.Equal(t, 2, c.len())
+
+	// Touch 0, so that 1 becomes the least recently used.
+	data, ok := c.get(0)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("0"), data)
+
+	c.put(2, []byte("2"))
+	assert.Equal(t, 2, c.len())
+	_, ok = c.get(1)
+	assert.False(t, ok)
+	_, ok = c.get(0)
+	assert.True(t, ok)
+	_, ok = c.get(2)
+	assert.True(t, ok)
+
+	// Replace.
+	c.put(2, []byte("two"))
+	data, ok = c.get(2)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("two"), data)
+	assert.Equal(t, 2, c.len())
+
+	c.clear()
+	assert.Equal(t, 0, c.len())
+	_, ok = c.get(0)
+	assert.False(t, ok)
+}
+
+func TestFrameCachePrefetched(t *testing.T) {
+	t.Parallel()
+
+	c := newFrameCache(1, 0)
+
+	c.putPrefetched(0, []byte("0"))
+	c.putPrefetched(1, []byte("1"))
+	c.put(2, []byte("2"))
+	c.put(3, []byte("3"))
+	assert.Equal(t, 3, c.len())
+	assert.False(t, c.has(2))
+
+	// Pinning a cached frame keeps it until it is read.
+	assert.True(t, c.pin(3))
+	assert.False(t, c.pin(2))
+	c.put(4, []byte("4"))
+	assert.Equal(t, 4, c.len())
+	assert.True(t, c.has(3))
+
+	// Read frames count towards the capacity again.
+	for _, id := range []int64{0, 1, 3} {
+		_, ok := c.get(id)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 1, c.len())
+	_, ok := c.get(3)
+	assert.True(t, ok)
+
+	// Byte capacity applies to prefetched frames too.
+	c = newFrameCache(1, 2)
+	c.putPrefetched(0, []byte("0"))
+	c.putPrefetched(1, []byte("1"))
+	c.putPrefetched(2, []byte("2"))
+	assert.Equal(t, 2, c.len())
+	assert.False(t, c.has(0))
+	c.clear()
+	assert.Equal(t, 0, c.prefetched)
+}
+
+func TestFrameCacheByteCapacity(t *testing.T) {
+	t.Parallel()
+
+	c := newFrameCache(10, 8)
+
+	c.put(0, []byte("test"))
+	c.put(1, []byte("test"))
+	assert.Equal(t, 2, c.len())
+	assert.Equal(t, int64(8), c.size())
+
+	// Evicts both least recently used frames to fit.
+	c.put(2, []byte("test2"))
+	assert.Equal(t, 1, c.len())
+	assert.Equal(t, int64(5), c.size())
+	_, ok := c.get(2)
+	assert.True(t, ok)
+
+	// Replacing a frame updates its size.
+	c.put(2, []byte("t"))
+	assert.Equal(t, int64(1), c.size())
+
+	// Frames over the capacity are not cached.
+	c.put(3, []byte("too big!!"))
+	_, ok = c.get(3)
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.len())
+
+	c.clear()
+	assert.Equal(t, int64(0), c.size())
+}
@@ -0,0 +1,87 @@
+package seekable
+
+import (
+	"fmt"
+	"io"
+)
+
+// CDCPolicy selects content-defined chunk boundaries, e.g. using Rabin fingerprint or FastCDC.
+type CDCPolicy interface {
+	// Next returns the end of the next chunk that starts at the beginning of data,
+	// or a negative value if data does not contain a chunk boundary yet.
+	// Policy is responsible for bounding the chunk size.
+	Next(data []byte) (chunkEnd int)
+}
+
+// cdcWriter buffers input and writes each content-defined chunk as a separate frame.
+type cdcWriter struct {
+	w      Writer
+	policy CDCPolicy
+	buf    []byte
+	closed bool
+}
+
+var _ io.WriteCloser = (*cdcWriter)(nil)
+
+// NewCDCWriter wraps the passed io.Writer and Encoder into an indexed ZSTD stream
+// where frame boundaries are selected by the policy, so that frames of streams
+// with similar contents are similar too.
+//
+// Data remaining after the last chunk boundary is written as the final frame on Close.
+func NewCDCWriter(w io.Writer, enc ZSTDEncoder, policy CDCPolicy, opts ...wOption) (io.WriteCloser, error) {
+	if policy == nil {
+		return nil, fmt.Errorf("chunking policy is required")
+	}
+
+	sw, err := NewWriter(w, enc, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cdcWriter{
+		w:      sw,
+		policy: policy,
+	}, nil
+}
+
+func (c *cdcWriter) Write(p []byte) (int, error) {
+	if c.closed {
+		return 0, fmt.Errorf("write to closed writer")
+	}
+
+	c.buf = append(c.buf, p...)
+
+	start := 0
+	for start < len(c.buf) {
+		end := c.policy.Next(c.buf[start:])
+		if end < 0 {
+			break
+		}
+		if end == 0 || end > len(c.buf)-start {
+			return 0, fmt.Errorf("invalid chunk boundary: %d (buffered: %d)", end, len(c.buf)-start)
+		}
+
+		if _, err := c.w.Write(c.buf[start : start+end]); err != nil {
+			return 0, fmt.Errorf("failed to write chunk: %w", err)
+		}
+		start += end
+	}
+	c.buf = c.buf[:copy(c.buf, c.buf[start:])]
+
+	return len(p), nil
+}
+
+func (c *cdcWriter) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	if len(c.buf) > 0 {
+		if _, err := c.w.Write(c.buf); err != nil {
+			return fmt.Errorf("failed to write chunk: %w", err)
+		}
+		c.buf = nil
+	}
+	return c.w.Close()
+}
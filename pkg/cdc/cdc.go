@@ -0,0 +1,120 @@
+// Package cdc writes seekable streams with frame boundaries selected by content-defined chunking,
+// so that frames of streams with similar contents are similar too, e.g. for deduplication.
+// Boundaries are found with a Rabin fingerprint of a sliding window, as in restic/chunker.
+package cdc
+
+import (
+	"fmt"
+	"io"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+const (
+	defaultMinChunkSize = 512 << 10
+	defaultMaxChunkSize = 8 << 20
+	defaultAverageBits  = 20
+)
+
+type config struct {
+	minChunkSize int
+	maxChunkSize int
+	averageBits  int
+}
+
+// CDCOption configures chunking of NewCDCWriter.
+type CDCOption func(*config) error
+
+// WithMinChunkSize sets the minimum size of chunks, 512KiB by default.
+// Only the last chunk can be smaller.
+func WithMinChunkSize(n int) CDCOption {
+	return func(c *config) error {
+		if n < 1 {
+			return fmt.Errorf("min chunk size must be positive: %d", n)
+		}
+		c.minChunkSize = n
+		return nil
+	}
+}
+
+// WithMaxChunkSize sets the maximum size of chunks, 8MiB by default.
+// Chunks are cut at this size if no boundary was found.
+func WithMaxChunkSize(n int) CDCOption {
+	return func(c *config) error {
+		if n < 1 {
+			return fmt.Errorf("max chunk size must be positive: %d", n)
+		}
+		c.maxChunkSize = n
+		return nil
+	}
+}
+
+// WithAverageBits sets the number of fingerprint bits that must be zero at a chunk boundary,
+// so that chunks are 2^bits bytes on average (plus the min chunk size), 20 (1MiB) by default.
+func WithAverageBits(bits int) CDCOption {
+	return func(c *config) error {
+		if bits < 1 || bits > 32 {
+			return fmt.Errorf("average bits must be in [1, 32]: %d", bits)
+		}
+		c.averageBits = bits
+		return nil
+	}
+}
+
+// rabinPolicy is seekable.CDCPolicy that cuts chunks where the fingerprint of the window has
+// the lower averageBits bits set to zero.
+//
+// It is stateful: if Next does not find a boundary, it remembers the scanned part of data,
+// so the next call must pass the same data with more bytes appended, as seekable.NewCDCWriter does.
+type rabinPolicy struct {
+	config
+	mask uint64
+
+	roller  roller
+	scanned int
+}
+
+var _ seekable.CDCPolicy = (*rabinPolicy)(nil)
+
+func (p *rabinPolicy) Next(data []byte) int {
+	// Only the last window before the min chunk size affects the fingerprint there.
+	if skip := p.minChunkSize - rabinWindowSize; p.scanned < skip {
+		p.scanned = min(skip, len(data))
+	}
+
+	for ; p.scanned < len(data); p.scanned++ {
+		p.roller.roll(data[p.scanned])
+		size := p.scanned + 1
+		if size >= p.maxChunkSize || (size >= p.minChunkSize && p.roller.digest&p.mask == 0) {
+			p.roller.reset()
+			p.scanned = 0
+			return size
+		}
+	}
+	return -1
+}
+
+// NewCDCWriter wraps the passed io.Writer and Encoder into an indexed ZSTD stream where each
+// content-defined chunk of the input is written as a separate frame.
+// Data remaining after the last chunk boundary is written as the final frame on Close.
+func NewCDCWriter(w io.Writer, encoder seekable.ZSTDEncoder, opts ...CDCOption) (io.WriteCloser, error) {
+	c := config{
+		minChunkSize: defaultMinChunkSize,
+		maxChunkSize: defaultMaxChunkSize,
+		averageBits:  defaultAverageBits,
+	}
+	for _, o := range opts {
+		if err := o(&c); err != nil {
+			return nil, err
+		}
+	}
+	if c.minChunkSize > c.maxChunkSize {
+		return nil, fmt.Errorf("min chunk size is greater than max chunk size: %d > %d",
+			c.minChunkSize, c.maxChunkSize)
+	}
+
+	return seekable.NewCDCWriter(w, encoder, &rabinPolicy{
+		config: c,
+		mask:   1<<c.averageBits - 1,
+	})
+}
@@ -0,0 +1,130 @@
+package cdc
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+func TestRabinRoller(t *testing.T) {
+	t.Parallel()
+
+	// The fingerprint depends only on the window, not on the preceding data.
+	rng := rand.New(rand.NewSource(0))
+	data := make([]byte, 1000)
+	rng.Read(data)
+
+	var full, window roller
+	for _, b := range data {
+		full.roll(b)
+	}
+	for _, b := range data[len(data)-rabinWindowSize:] {
+		window.roll(b)
+	}
+	assert.Equal(t, window.digest, full.digest)
+	assert.NotZero(t, full.digest)
+	assert.Less(t, full.digest, uint64(1)<<rabinPolynomial.deg())
+}
+
+func TestCDCWriter(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	const minSize, maxSize = 4 << 10, 64 << 10
+	rng := rand.New(rand.NewSource(0))
+	src := make([]byte, 4<<20)
+	rng.Read(src)
+
+	write := func(src []byte, step int) []byte {
+		var b bytes.Buffer
+		w, err := NewCDCWriter(&b, enc, WithMinChunkSize(minSize), WithMaxChunkSize(maxSize), WithAverageBits(14))
+		require.NoError(t, err)
+		for off := 0; off < len(src); off += step {
+			_, err = w.Write(src[off:min(off+step, len(src))])
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		return b.Bytes()
+	}
+
+	frames := func(stream []byte) map[uint32]bool {
+		r, err := seekable.NewReader(bytes.NewReader(stream), dec)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, r.Close()) }()
+
+		all, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, len(src), len(all))
+
+		d := r.(seekable.Decoder)
+		checksums := map[uint32]bool{}
+		for id := int64(0); id < d.NumFrames(); id++ {
+			index := d.GetIndexByID(id)
+			assert.LessOrEqual(t, index.DecompSize, uint32(maxSize))
+			if id < d.NumFrames()-1 {
+				assert.GreaterOrEqual(t, index.DecompSize, uint32(minSize))
+			}
+			checksums[index.Checksum] = true
+		}
+		return checksums
+	}
+
+	stream := write(src, len(src))
+	// Boundaries do not depend on how data is written.
+	for _, step := range []int{1000, 64 << 10} {
+		assert.Equal(t, stream, write(src, step))
+	}
+
+	r, err := seekable.NewReader(bytes.NewReader(stream), dec)
+	require.NoError(t, err)
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, src, all)
+
+	// Average is around 2^14 + min chunk size.
+	original := frames(stream)
+	assert.Greater(t, len(original), len(src)/maxSize)
+	assert.Less(t, len(original), len(src)/minSize)
+
+	// Boundaries are resynchronized after an insertion, so most of the chunks are the same.
+	shifted := append([]byte{0x42}, src[:len(src)-1]...)
+	var same int
+	for checksum := range frames(write(shifted, len(shifted))) {
+		if original[checksum] {
+			same++
+		}
+	}
+	assert.Greater(t, same, len(original)*9/10)
+}
+
+func TestCDCOptions(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		opts []CDCOption
+		err  string
+	}{
+		{[]CDCOption{WithMinChunkSize(0)}, "min chunk size must be positive"},
+		{[]CDCOption{WithMaxChunkSize(-1)}, "max chunk size must be positive"},
+		{[]CDCOption{WithAverageBits(0)}, "average bits must be in [1, 32]"},
+		{[]CDCOption{WithAverageBits(33)}, "average bits must be in [1, 32]"},
+		{[]CDCOption{WithMinChunkSize(2), WithMaxChunkSize(1)}, "min chunk size is greater than max chunk size"},
+	} {
+		_, err := NewCDCWriter(io.Discard, nil, tc.opts...)
+		assert.ErrorContains(t, err, tc.err)
+	}
+}
@@ -0,0 +1,75 @@
+package cdc
+
+import "math/bits"
+
+const (
+	// rabinPolynomial is the irreducible polynomial of degree 53 used for fingerprints.
+	rabinPolynomial pol = 0x3DA3358B4DC173
+	// rabinWindowSize is the number of bytes the fingerprint is computed over.
+	rabinWindowSize = 64
+)
+
+// pol is a polynomial over GF(2), bit i is the coefficient of x^i.
+type pol uint64
+
+func (x pol) deg() int {
+	return bits.Len64(uint64(x)) - 1
+}
+
+// mod returns the remainder of x divided by d.
+func (x pol) mod(d pol) pol {
+	for x.deg() >= d.deg() {
+		x ^= d << uint(x.deg()-d.deg())
+	}
+	return x
+}
+
+// rabinTables speed up sliding the window by a byte.
+type rabinTables struct {
+	// out[b] is the fingerprint of b followed by rabinWindowSize-1 zero bytes,
+	// adding it removes b from the start of the window.
+	out [256]pol
+	// mod[b] reduces the fingerprint modulo the polynomial, where b are the 8 bits above its degree.
+	mod [256]pol
+}
+
+var tables = newRabinTables(rabinPolynomial)
+
+func newRabinTables(p pol) *rabinTables {
+	t := &rabinTables{}
+	for b := 0; b < 256; b++ {
+		h := (pol(b)).mod(p)
+		for i := 0; i < rabinWindowSize-1; i++ {
+			h = (h << 8).mod(p)
+		}
+		t.out[b] = h
+	}
+
+	k := p.deg()
+	for b := 0; b < 256; b++ {
+		// The lower part is the reduced value, the upper part cancels out the 8 bits above the degree.
+		t.mod[b] = (pol(b) << uint(k)).mod(p) | pol(b)<<uint(k)
+	}
+	return t
+}
+
+// roller computes the Rabin fingerprint of the last rabinWindowSize bytes.
+type roller struct {
+	window [rabinWindowSize]byte
+	pos    int
+	digest uint64
+}
+
+func (r *roller) reset() {
+	*r = roller{}
+}
+
+func (r *roller) roll(b byte) {
+	out := r.window[r.pos]
+	r.window[r.pos] = b
+	r.pos = (r.pos + 1) % rabinWindowSize
+
+	r.digest ^= uint64(tables.out[out])
+	index := r.digest >> uint(rabinPolynomial.deg()-8)
+	r.digest = (r.digest<<8 | uint64(b)) ^ uint64(tables.mod[index])
+}
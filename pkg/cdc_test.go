@@ -0,0 +1,94 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newlinePolicy cuts chunks after newlines, bounding them by maxSize.
+type newlinePolicy struct {
+	maxSize int
+}
+
+func (p newlinePolicy) Next(data []byte) int {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 && i < p.maxSize {
+		return i + 1
+	}
+	if len(data) >= p.maxSize {
+		return p.maxSize
+	}
+	return -1
+}
+
+type badPolicy struct{}
+
+func (badPolicy) Next(data []byte) int { return len(data) + 1 }
+
+func TestCDCWriter(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = NewCDCWriter(nil, enc, nil)
+	require.ErrorContains(t, err, "chunking policy is required")
+
+	src := []byte("first\nsecond\n\nverylongline\nlast")
+	expected := []string{"first\n", "second\n", "\n", "verylong", "line\n", "last"}
+
+	var reference []byte
+	for _, step := range []int{1, 3, 7, len(src)} {
+		var b bytes.Buffer
+		w, err := NewCDCWriter(&b, enc, newlinePolicy{maxSize: 8})
+		require.NoError(t, err)
+		for off := 0; off < len(src); off += step {
+			n, err := w.Write(src[off:min(off+step, len(src))])
+			require.NoError(t, err)
+			assert.Equal(t, min(step, len(src)-off), n)
+		}
+		require.NoError(t, w.Close())
+		require.NoError(t, w.Close())
+
+		_, err = w.Write(src)
+		require.ErrorContains(t, err, "closed")
+
+		// Boundaries do not depend on how data is written.
+		if reference == nil {
+			reference = b.Bytes()
+		}
+		assert.Equal(t, reference, b.Bytes())
+
+		r, err := NewReader(bytes.NewReader(b.Bytes()), dec)
+		require.NoError(t, err)
+		all, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, src, all)
+
+		d := r.(Decoder)
+		require.Equal(t, int64(len(expected)), d.NumFrames())
+		for i, chunk := range expected {
+			assert.Equal(t, uint32(len(chunk)), d.GetIndexByID(int64(i)).DecompSize)
+		}
+		require.NoError(t, r.Close())
+	}
+
+	// Errors.
+	w, err := NewCDCWriter(io.Discard, enc, badPolicy{})
+	require.NoError(t, err)
+	_, err = w.Write(src)
+	require.ErrorContains(t, err, "invalid chunk boundary")
+
+	w, err = NewCDCWriter(failingWriter{}, enc, newlinePolicy{maxSize: 8})
+	require.NoError(t, err)
+	_, err = w.Write(src)
+	require.ErrorContains(t, err, "failed to write chunk")
+}
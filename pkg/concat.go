@@ -0,0 +1,88 @@
+package seekable
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// Concat writes the seekable stream to dst that decompresses to the concatenation of sources.
+// Compressed frames are copied as is with WriteRaw, so sources are only decompressed if they
+// lack checksums, which are then computed from the decompressed data.
+// Magic prefixes of sources are dropped, streams with hierarchical index are not supported.
+//
+// encoder is only used to create the Writer for dst.
+func Concat(dst io.Writer, encoder ZSTDEncoder, sources ...io.ReadSeeker) error {
+	w, err := NewWriter(dst, encoder)
+	if err != nil {
+		return err
+	}
+
+	for i, src := range sources {
+		if err = concatOne(w, src); err != nil {
+			return fmt.Errorf("source %d: %w", i, err)
+		}
+	}
+
+	return w.Close()
+}
+
+// concatOne copies all frames of src to w.
+func concatOne(w Writer, src io.ReadSeeker) error {
+	sr, err := NewReader(src, nil, WithDefaultDecoder())
+	if err != nil {
+		return fmt.Errorf("failed to open stream: %w", err)
+	}
+	defer sr.Close()
+
+	r := sr.(*readerImpl)
+	if r.hierarchical {
+		return fmt.Errorf("hierarchical index is not supported")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for index := range r.IterFrames(ctx) {
+		frame, checksum, err := r.rawFrame(index)
+		if err != nil {
+			return err
+		}
+		if err = w.WriteRaw(frame, index.DecompSize, checksum); err != nil {
+			return fmt.Errorf("failed to write frame %d: %w", index.ID, err)
+		}
+	}
+	return nil
+}
+
+// rawFrame returns the compressed frame as is along with its checksum.
+// If the stream has no checksums, the frame is decompressed to compute it.
+func (r *readerImpl) rawFrame(index *env.FrameOffsetEntry) ([]byte, uint32, error) {
+	frame, err := r.env.GetFrameByIndex(*index)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read frame %d: %w", index.ID, err)
+	}
+	if len(frame) != int(index.CompSize) {
+		return nil, 0, fmt.Errorf("frame %d: compressed size mismatch: expected: %d, actual: %d",
+			index.ID, index.CompSize, len(frame))
+	}
+	if frame, err = r.resolveReference(r.env, index, frame); err != nil {
+		return nil, 0, err
+	}
+
+	if r.checksums {
+		return frame, index.Checksum, nil
+	}
+	var decompressed []byte
+	if index.DecompSize > 0 {
+		decompressed, err = r.decompressFrame(r.env, r.dec, index)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decompress frame %d: %w", index.ID, err)
+		}
+	}
+	return frame, uint32((xxhash.Sum64(decompressed) << 32) >> 32), nil
+}
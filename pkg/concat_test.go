@@ -0,0 +1,73 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcat(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(1))
+	var sources []io.ReadSeeker
+	var expected []byte
+	for _, frames := range []int{3, 1} {
+		var b bytes.Buffer
+		w, err := NewWriter(&b, enc, WithFrameMetadata("frames", []byte{byte(frames)}))
+		require.NoError(t, err)
+		for i := 0; i < frames; i++ {
+			frame := make([]byte, 100+rng.Intn(1000))
+			_, _ = rng.Read(frame)
+			_, err = w.Write(frame)
+			require.NoError(t, err)
+			expected = append(expected, frame...)
+		}
+		require.NoError(t, w.Close())
+		sources = append(sources, bytes.NewReader(b.Bytes()))
+	}
+	// Checksums of a stream without them are computed.
+	sources = append(sources, bytes.NewReader(noChecksum))
+	expected = append(expected, sourceString...)
+
+	var b bytes.Buffer
+	require.NoError(t, Concat(&b, enc, sources...))
+
+	r, err := NewReader(bytes.NewReader(b.Bytes()), nil, WithDefaultDecoder(), WithSizeValidation())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	actual, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	problems, err := Validate(bytes.NewReader(b.Bytes()), nil)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	// Stream is also valid for regular ZSTD decoders.
+	dec, err := zstd.NewReader(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+	defer dec.Close()
+	actual, err = io.ReadAll(dec)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestConcatErrors(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	err = Concat(&b, enc, bytes.NewReader(checksum), bytes.NewReader(checksum[:30]))
+	require.ErrorContains(t, err, "source 1: failed to open stream")
+}
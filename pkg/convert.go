@@ -0,0 +1,123 @@
+package seekable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// streamDecoder is implemented by decoders that can decompress a stream incrementally,
+// e.g. *zstd.Decoder from github.com/klauspost/compress/zstd.
+type streamDecoder interface {
+	io.Reader
+	Reset(r io.Reader) error
+}
+
+// Convert decompresses the standard (non-seekable) ZSTD stream src with decoder and writes it
+// to dst as the seekable stream of chunkSize frames compressed with encoder.  Recompressing
+// with a different compression level only requires passing the encoder created with it.
+//
+// If decoder supports streaming decompression (e.g. *zstd.Decoder), decompressed data is passed
+// to the seekable writer through io.Pipe, so that src is never held in memory as a whole.
+// In this case decoder must not be used for streaming decompression concurrently.
+// Otherwise src is read entirely and decompressed with DecodeAll.
+//
+// opts are passed to NewWriter.
+func Convert(dst io.Writer, src io.ReadSeeker, decoder ZSTDDecoder, encoder ZSTDEncoder,
+	chunkSize int, opts ...wOption,
+) error {
+	if chunkSize < 1 || int64(chunkSize) > maxChunkSize {
+		return fmt.Errorf("chunk size must be in [1, %d]: %d", maxChunkSize, chunkSize)
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to the start of the source: %w", err)
+	}
+
+	w, err := NewWriter(dst, encoder, opts...)
+	if err != nil {
+		return err
+	}
+
+	sd, ok := decoder.(streamDecoder)
+	if !ok {
+		compressed, err := io.ReadAll(src)
+		if err != nil {
+			return fmt.Errorf("failed to read source: %w", err)
+		}
+		decompressed, err := decoder.DecodeAll(compressed, nil)
+		if err != nil {
+			return fmt.Errorf("failed to decompress source: %w", err)
+		}
+		if err = w.WriteFrom(bytes.NewReader(decompressed), chunkSize); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+
+	if err = sd.Reset(src); err != nil {
+		return fmt.Errorf("failed to reset decoder: %w", err)
+	}
+	// Release the reference to src.
+	defer func() { _ = sd.Reset(nil) }()
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := io.Copy(pw, sd)
+		if err != nil {
+			// Not wrapped: WriteFrom treats io.ErrUnexpectedEOF of a truncated source as the end of data.
+			err = fmt.Errorf("failed to decompress source: %v", err)
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err = w.WriteFrom(pr, chunkSize)
+	// Unblock the decompressing goroutine if the writer failed.
+	pr.CloseWithError(err)
+	<-done
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// FromGzip decompresses the gzip stream gzSrc on the fly and writes it to dst as the seekable
+// stream of chunkSize frames compressed with encoder.  Decompressed data is passed to
+// NewFixedChunkWriter through io.Pipe, so that at most a chunk of it is held in memory.
+//
+// Multistream gzip files, e.g. produced by concatenation, are read as a whole.
+// opts are passed to NewWriter.
+func FromGzip(dst io.Writer, gzSrc io.Reader, encoder ZSTDEncoder, chunkSize int, opts ...wOption) error {
+	w, err := NewFixedChunkWriter(dst, encoder, chunkSize, opts...)
+	if err != nil {
+		return err
+	}
+
+	zr, err := gzip.NewReader(gzSrc)
+	if err != nil {
+		return fmt.Errorf("failed to read gzip header: %w", err)
+	}
+	defer zr.Close()
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := io.Copy(pw, zr)
+		if err != nil {
+			err = fmt.Errorf("failed to decompress gzip source: %w", err)
+		}
+		pw.CloseWithError(err)
+	}()
+
+	_, err = io.Copy(w, pr)
+	// Unblock the decompressing goroutine if the writer failed.
+	pr.CloseWithError(err)
+	<-done
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,130 @@
+package seekable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allDecoder hides streaming methods of the wrapped decoder.
+type allDecoder struct {
+	ZSTDDecoder
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+
+	var original []byte
+	for i := 0; i < 10; i++ {
+		original = append(original, makeTestFrame(t, i)...)
+	}
+
+	// Standard, non-seekable, ZSTD stream.
+	var src bytes.Buffer
+	zw, err := zstd.NewWriter(&src, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	require.NoError(t, err)
+	_, err = zw.Write(original)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	const chunkSize = 1000
+	for name, decoder := range map[string]ZSTDDecoder{
+		"stream": dec,
+		"all":    allDecoder{dec},
+	} {
+		var dst bytes.Buffer
+		srcReader := bytes.NewReader(src.Bytes())
+		// Convert starts at the beginning of the source.
+		_, err = srcReader.Seek(10, io.SeekStart)
+		require.NoError(t, err)
+		require.NoError(t, Convert(&dst, srcReader, decoder, enc, chunkSize), name)
+
+		r, err := NewReader(bytes.NewReader(dst.Bytes()), dec, WithSizeValidation())
+		require.NoError(t, err, name)
+		all, err := io.ReadAll(r)
+		require.NoError(t, err, name)
+		assert.Equal(t, original, all, name)
+
+		d := r.(Decoder)
+		assert.Equal(t, int64((len(original)+chunkSize-1)/chunkSize), d.NumFrames(), name)
+		assert.Equal(t, uint32(chunkSize), d.GetIndexByID(0).DecompSize, name)
+		require.NoError(t, r.Close())
+	}
+
+	for name, decoder := range map[string]ZSTDDecoder{
+		"stream": dec,
+		"all":    allDecoder{dec},
+	} {
+		err = Convert(io.Discard, bytes.NewReader(src.Bytes()[:src.Len()/2]), decoder, enc, chunkSize)
+		assert.ErrorContains(t, err, "failed to decompress source", name)
+
+		err = Convert(failingWriter{}, bytes.NewReader(src.Bytes()), decoder, enc, chunkSize)
+		assert.ErrorContains(t, err, "failed to write frame", name)
+	}
+
+	err = Convert(io.Discard, bytes.NewReader(src.Bytes()), dec, enc, 0)
+	assert.ErrorContains(t, err, "chunk size must be in")
+}
+
+func TestFromGzip(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+
+	// Compressible, but not trivially, data.
+	original := make([]byte, 5<<20)
+	rng := rand.New(rand.NewSource(1))
+	for i := range original {
+		original[i] = byte('a' + rng.Intn(16))
+	}
+
+	var src bytes.Buffer
+	zw := gzip.NewWriter(&src)
+	_, err = zw.Write(original)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	const chunkSize = 1 << 20
+	var dst bytes.Buffer
+	require.NoError(t, FromGzip(&dst, bytes.NewReader(src.Bytes()), enc, chunkSize))
+
+	r, err := NewReader(bytes.NewReader(dst.Bytes()), dec, WithSizeValidation())
+	require.NoError(t, err)
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, original, all)
+	d := r.(Decoder)
+	assert.Equal(t, int64(5), d.NumFrames())
+	assert.Equal(t, uint32(chunkSize), d.GetIndexByID(0).DecompSize)
+	require.NoError(t, r.Close())
+
+	err = FromGzip(io.Discard, bytes.NewReader(src.Bytes()[:src.Len()/2]), enc, chunkSize)
+	assert.ErrorContains(t, err, "failed to decompress gzip source")
+
+	err = FromGzip(io.Discard, bytes.NewReader([]byte(sourceString)), enc, chunkSize)
+	assert.ErrorContains(t, err, "failed to read gzip header")
+
+	err = FromGzip(failingWriter{}, bytes.NewReader(src.Bytes()), enc, chunkSize)
+	assert.ErrorContains(t, err, "failed to write chunk")
+
+	err = FromGzip(io.Discard, bytes.NewReader(src.Bytes()), enc, 0)
+	assert.ErrorContains(t, err, "chunk size must be in")
+}
@@ -1,25 +1,93 @@
 package seekable
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+
+	"github.com/google/btree"
+	"golang.org/x/sync/errgroup"
+
 	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
 )
 
 // Decoder is a byte-oriented API that is useful for cases where wrapping io.ReadSeeker is not desirable.
+//
+// Top-level seek table of the hierarchical index (see WithHierarchicalIndex) describes spans of frames
+// rather than frames, so frame lookups return nil, IterFrames sends nothing, NumFrames returns -1,
+// and methods returning an error fail for such streams.
 type Decoder interface {
 	// GetIndexByDecompOffset returns FrameOffsetEntry for an offset in the decompressed stream.
 	// Will return nil if offset is greater or equal than Size().
 	GetIndexByDecompOffset(off uint64) *env.FrameOffsetEntry
 
+	// GetNearestFrames returns FrameOffsetEntry for an offset in the decompressed stream
+	// along with the entry of the frame following it.
+	// Next is nil for the last frame, both are nil if offset is greater or equal than Size().
+	GetNearestFrames(off uint64) (current, next *env.FrameOffsetEntry)
+
+	// GetFrameRange returns entries of frames overlapping the [start, end) range of the decompressed stream,
+	// ordered by DecompOffset.  end is capped to Size(), frames without data are skipped.
+	// If start equals end, only the frame containing start is returned.
+	// Will return nil if start is greater or equal than Size() or greater than end.
+	GetFrameRange(start, end uint64) []*env.FrameOffsetEntry
+
+	// GetIndexByCompOffset returns FrameOffsetEntry for an offset in the compressed stream.
+	// Will return nil if offset is not within any frame, e.g. it points to the seek table.
+	// Lookups are linear unless the decoder was created WithCompressedOffsetIndex.
+	GetIndexByCompOffset(off uint64) *env.FrameOffsetEntry
+
 	// GetIndexByID returns FrameOffsetEntry for a given frame id.
 	// Will return nil if offset is greater or equal than NumFrames() or less than 0.
 	GetIndexByID(id int64) *env.FrameOffsetEntry
 
+	// IterFrames sends entries of all frames, ordered by DecompOffset, to the returned channel.
+	// The channel is closed once all frames are sent or ctx is canceled, so callers that stop
+	// reading early must cancel ctx to release the iterating goroutine.
+	IterFrames(ctx context.Context) <-chan *env.FrameOffsetEntry
+
 	// Size returns the size of the uncompressed stream.
 	Size() int64
 
-	// NumFrames returns number of frames in the compressed stream.
+	// NumFrames returns number of frames in the compressed stream, or -1 for hierarchical index.
 	NumFrames() int64
 
+	// Prefetch concurrently fetches and decompresses frames with given ids into the frame cache
+	// (see WithCacheSize), so that subsequent reads of these frames do not need any I/O.
+	// Prefetched frames are kept in addition to WithCacheSize frames until they are read,
+	// but WithCacheByteCapacity still applies to them.
+	// If e is nil, the environment of the decoder is used, see NewDecoderFromReadSeeker.
+	// Returns the first error encountered.
+	Prefetch(ctx context.Context, ids []int64, e env.REnvironment, dec ZSTDDecoder) error
+
+	// DecompressRange returns the [start, end) range of the decompressed stream.  Frames overlapping
+	// the range are fetched via e and decompressed with the decoder passed to NewDecoder, unless they were
+	// prefetched into the frame cache.  Frame checksums are verified if the seek table has them.
+	// If e is nil, the environment of the decoder is used, see NewDecoderFromReadSeeker.
+	DecompressRange(e env.REnvironment, start, end uint64) ([]byte, error)
+
+	// ParallelDecompressRange is like DecompressRange, but fetches and decompresses frames concurrently
+	// with up to concurrency goroutines, e.g. for ranges spanning many frames of high-latency storage.
+	// Once any frame fails, frames that are not fetched yet are skipped and the first error is returned.
+	ParallelDecompressRange(ctx context.Context, e env.REnvironment, start, end uint64, concurrency int) ([]byte, error)
+
+	// MarshalBinary serializes the parsed seek table back into a seek table skippable frame
+	// that can be passed to NewDecoder.  Chunked, compressed and varint seek tables are serialized in the regular format.
+	MarshalBinary() ([]byte, error)
+
+	// UnmarshalBinary replaces the decoder's index with the one parsed from a seek table.
+	// This method is NOT goroutine-safe.
+	UnmarshalBinary(seekTable []byte) error
+
+	// SaveIndex writes the parsed index to w in a format that can be loaded with LoadIndex.
+	SaveIndex(w io.Writer) error
+
+	// Validate checks that the parsed index is internally consistent without any I/O, see VerifyIndex.
+	// All violations found are returned as a combined error.
+	Validate() error
+
 	// Close closes the decoder feeing up any resources.
 	Close() error
 }
@@ -41,6 +109,32 @@ func NewDecoder(seekTable []byte, decoder ZSTDDecoder, opts ...rOption) (Decoder
 	return sr.(*readerImpl), err
 }
 
+// NewDecoderFromReadSeeker creates a Decoder from the seekable stream, parsing its seek table
+// the same way NewReader does.  Unlike the one created by NewDecoder, the returned Decoder keeps
+// rs as its environment, so nil can be passed as the environment to Prefetch and DecompressRange.
+func NewDecoderFromReadSeeker(rs io.ReadSeeker, decoder ZSTDDecoder, opts ...rOption) (Decoder, error) {
+	if rs == nil {
+		return nil, fmt.Errorf("read seeker is nil")
+	}
+
+	sr, err := NewReader(rs, decoder, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return sr.(*readerImpl), nil
+}
+
+// decoderEnvOrDefault returns e, falling back to the environment of the decoder.
+func (r *readerImpl) decoderEnvOrDefault(e env.REnvironment) (env.REnvironment, error) {
+	if e != nil {
+		return e, nil
+	}
+	if r.env == nil {
+		return nil, fmt.Errorf("environment is not set")
+	}
+	return r.env, nil
+}
+
 type decoderEnv struct {
 	seekTable []byte
 }
@@ -57,27 +151,185 @@ func (d *decoderEnv) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
 	return d.seekTable, nil
 }
 
+func (r *readerImpl) MarshalBinary() ([]byte, error) {
+	if r.hierarchical {
+		return nil, fmt.Errorf("marshaling hierarchical index is not supported")
+	}
+	entries := make([]seekTableEntry, 0, r.numFrames)
+	r.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		entries = append(entries, seekTableEntry{
+			CompressedSize:   index.CompSize,
+			DecompressedSize: index.DecompSize,
+			Checksum:         index.Checksum,
+		})
+		return true
+	})
+	return marshalSeekTable(entries, r.checksums)
+}
+
+func (r *readerImpl) UnmarshalBinary(seekTable []byte) error {
+	prevEnv, prevChecksums, prevHierarchical, prevSeekTableSize := r.env, r.checksums, r.hierarchical, r.seekTableSize
+	r.env = &decoderEnv{seekTable: seekTable}
+	tree, last, err := r.indexFooter()
+	r.env = prevEnv
+	if err != nil {
+		r.checksums, r.hierarchical, r.seekTableSize = prevChecksums, prevHierarchical, prevSeekTableSize
+		return err
+	}
+
+	r.setIndex(tree, last)
+	r.offset = 0
+	r.cache.clear()
+	r.fine = fineIndexCache{}
+	return nil
+}
+
+// iterFramesBufferSize is the capacity of the channel returned by IterFrames.
+const iterFramesBufferSize = 64
+
+func (r *readerImpl) IterFrames(ctx context.Context) <-chan *env.FrameOffsetEntry {
+	ch := make(chan *env.FrameOffsetEntry, min(r.numFrames, iterFramesBufferSize))
+	tree := r.index
+	if tree == nil || r.hierarchical {
+		close(ch)
+		return ch
+	}
+
+	go func() {
+		defer close(ch)
+		tree.Ascend(func(index *env.FrameOffsetEntry) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			select {
+			case ch <- index:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
 func (r *readerImpl) Size() int64 {
 	return r.endOffset
 }
 
 func (r *readerImpl) NumFrames() int64 {
+	if r.hierarchical {
+		return -1
+	}
 	return r.numFrames
 }
 
-func (r *readerImpl) GetIndexByDecompOffset(off uint64) (found *env.FrameOffsetEntry) {
+func (r *readerImpl) GetIndexByDecompOffset(off uint64) *env.FrameOffsetEntry {
+	if r.hierarchical {
+		return nil
+	}
+	return r.indexByDecompOffset(off)
+}
+
+// indexByDecompOffset is GetIndexByDecompOffset that also returns spans of the hierarchical index.
+func (r *readerImpl) indexByDecompOffset(off uint64) (found *env.FrameOffsetEntry) {
 	if off >= uint64(r.endOffset) {
 		return nil
 	}
 
-	r.index.DescendLessOrEqual(&env.FrameOffsetEntry{DecompOffset: off}, func(index *env.FrameOffsetEntry) bool {
+	return findByDecompOffset(r.index, off)
+}
+
+// findByDecompOffset returns the last frame of the tree starting at or before the offset.
+func findByDecompOffset(tree *btree.BTreeG[*env.FrameOffsetEntry], off uint64) (found *env.FrameOffsetEntry) {
+	// Max ID skips empty frames sharing the offset with the frame that contains it.
+	pivot := &env.FrameOffsetEntry{DecompOffset: off, ID: math.MaxInt64}
+	tree.DescendLessOrEqual(pivot, func(index *env.FrameOffsetEntry) bool {
 		found = index
 		return false
 	})
 	return
 }
 
-func (r *readerImpl) GetIndexByID(id int64) (found *env.FrameOffsetEntry) {
+func (r *readerImpl) GetIndexByCompOffset(off uint64) (found *env.FrameOffsetEntry) {
+	if r.hierarchical {
+		return nil
+	}
+
+	contains := func(index *env.FrameOffsetEntry) bool {
+		return index.CompOffset <= off && off < index.CompOffset+uint64(index.CompSize)
+	}
+
+	if r.compIndex == nil {
+		r.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+			if contains(index) {
+				found = index
+				return false
+			}
+			return index.CompOffset <= off
+		})
+		return
+	}
+
+	// Max ID skips empty frames sharing the offset with the frame that contains it.
+	pivot := &env.FrameOffsetEntry{CompOffset: off, ID: math.MaxInt64}
+	r.compIndex.DescendLessOrEqual(pivot, func(index *env.FrameOffsetEntry) bool {
+		if contains(index) {
+			found = index
+		}
+		return false
+	})
+	return
+}
+
+func (r *readerImpl) GetNearestFrames(off uint64) (current, next *env.FrameOffsetEntry) {
+	current = r.GetIndexByDecompOffset(off)
+	if current == nil {
+		return nil, nil
+	}
+
+	// The tree can not step from current to the next entry, so it takes a second lookup.
+	r.index.AscendGreaterOrEqual(current, func(index *env.FrameOffsetEntry) bool {
+		if index == current {
+			return true
+		}
+		next = index
+		return false
+	})
+	return
+}
+
+func (r *readerImpl) GetFrameRange(start, end uint64) []*env.FrameOffsetEntry {
+	first := r.GetIndexByDecompOffset(start)
+	if first == nil || start > end {
+		return nil
+	}
+	if start == end {
+		return []*env.FrameOffsetEntry{first}
+	}
+
+	end = min(end, uint64(r.endOffset))
+	var frames []*env.FrameOffsetEntry
+	r.index.AscendGreaterOrEqual(first, func(index *env.FrameOffsetEntry) bool {
+		if index.DecompOffset >= end {
+			return false
+		}
+		if index.DecompSize != 0 {
+			frames = append(frames, index)
+		}
+		return true
+	})
+	return frames
+}
+
+func (r *readerImpl) GetIndexByID(id int64) *env.FrameOffsetEntry {
+	if r.hierarchical {
+		return nil
+	}
+	return r.indexByID(id)
+}
+
+// indexByID is GetIndexByID that also returns spans of the hierarchical index.
+func (r *readerImpl) indexByID(id int64) (found *env.FrameOffsetEntry) {
 	if id < 0 {
 		return nil
 	}
@@ -91,3 +343,167 @@ func (r *readerImpl) GetIndexByID(id int64) (found *env.FrameOffsetEntry) {
 	})
 	return
 }
+
+func (r *readerImpl) DecompressRange(e env.REnvironment, start, end uint64) ([]byte, error) {
+	if r.hierarchical {
+		return nil, fmt.Errorf("decompressing ranges is not supported for hierarchical index")
+	}
+	if r.dec == nil {
+		return nil, fmt.Errorf("decoder is not set")
+	}
+	e, err := r.decoderEnvOrDefault(e)
+	if err != nil {
+		return nil, err
+	}
+	if start > end || end > uint64(r.endOffset) {
+		return nil, fmt.Errorf("invalid range: [%d, %d) for stream of size %d", start, end, r.endOffset)
+	}
+
+	dst := make([]byte, 0, end-start)
+	first := r.GetIndexByDecompOffset(start)
+	if first == nil {
+		return dst, nil
+	}
+
+	r.index.AscendGreaterOrEqual(first, func(index *env.FrameOffsetEntry) bool {
+		frameStart, frameEnd := index.DecompOffset, index.DecompOffset+uint64(index.DecompSize)
+		if frameStart >= end {
+			return false
+		}
+		if index.DecompSize == 0 {
+			return true
+		}
+
+		decompressed, ok := r.cache.get(index.ID)
+		if !ok {
+			decompressed, err = r.decompressFrame(e, r.dec, index)
+			if err != nil {
+				return false
+			}
+		}
+		if len(decompressed) != int(index.DecompSize) {
+			err = fmt.Errorf("index corruption: len: %d, expected: %d", len(decompressed), int(index.DecompSize))
+			return false
+		}
+
+		dst = append(dst, decompressed[max(frameStart, start)-frameStart:min(frameEnd, end)-frameStart]...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+func (r *readerImpl) ParallelDecompressRange(ctx context.Context, e env.REnvironment, start, end uint64,
+	concurrency int,
+) ([]byte, error) {
+	if r.hierarchical {
+		return nil, fmt.Errorf("decompressing ranges is not supported for hierarchical index")
+	}
+	if r.dec == nil {
+		return nil, fmt.Errorf("decoder is not set")
+	}
+	if concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be positive: %d", concurrency)
+	}
+	e, err := r.decoderEnvOrDefault(e)
+	if err != nil {
+		return nil, err
+	}
+	if start > end || end > uint64(r.endOffset) {
+		return nil, fmt.Errorf("invalid range: [%d, %d) for stream of size %d", start, end, r.endOffset)
+	}
+
+	dst := make([]byte, end-start)
+	if start == end {
+		return dst, nil
+	}
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, index := range r.GetFrameRange(start, end) {
+		g.Go(func() error {
+			if err := gCtx.Err(); err != nil {
+				return err
+			}
+
+			decompressed, ok := r.cache.get(index.ID)
+			if !ok {
+				traceEnd := r.tracer.StartFrameRead(gCtx, *index)
+				var err error
+				decompressed, err = r.decompressFrame(e, r.dec, index)
+				traceEnd(false, err)
+				if err != nil {
+					return err
+				}
+			}
+			if len(decompressed) != int(index.DecompSize) {
+				return fmt.Errorf("index corruption: len: %d, expected: %d", len(decompressed), int(index.DecompSize))
+			}
+
+			// Frames do not overlap, so each goroutine writes its own part of dst.
+			frameStart, frameEnd := index.DecompOffset, index.DecompOffset+uint64(index.DecompSize)
+			copy(dst[max(frameStart, start)-start:],
+				decompressed[max(frameStart, start)-frameStart:min(frameEnd, end)-frameStart])
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+func (r *readerImpl) Prefetch(ctx context.Context, ids []int64, e env.REnvironment, dec ZSTDDecoder) error {
+	if r.hierarchical {
+		return fmt.Errorf("prefetch is not supported for hierarchical index")
+	}
+	e, err := r.decoderEnvOrDefault(e)
+	if err != nil {
+		return err
+	}
+
+	indexes := make(map[int64]*env.FrameOffsetEntry, len(ids))
+	for _, id := range ids {
+		indexes[id] = nil
+	}
+	found := 0
+	r.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		if _, ok := indexes[index.ID]; ok {
+			indexes[index.ID] = index
+			found++
+		}
+		return found < len(indexes)
+	})
+	for _, id := range ids {
+		if indexes[id] == nil {
+			return fmt.Errorf("failed to get index by id: %d", id)
+		}
+	}
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(runtime.GOMAXPROCS(0))
+
+	for _, id := range ids {
+		index := indexes[id]
+		g.Go(func() error {
+			if err := gCtx.Err(); err != nil {
+				return err
+			}
+			if r.cache.pin(id) {
+				return nil
+			}
+
+			end := r.tracer.StartFrameRead(gCtx, *index)
+			decompressed, err := r.decompressFrame(e, dec, index)
+			end(false, err)
+			if err != nil {
+				return err
+			}
+			r.cache.putPrefetched(id, decompressed)
+			return nil
+		})
+	}
+	return g.Wait()
+}
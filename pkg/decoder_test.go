@@ -1,11 +1,21 @@
 package seekable
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
 )
 
 func TestDecoder(t *testing.T) {
@@ -68,3 +78,623 @@ func TestDecoder(t *testing.T) {
 		assert.Nil(t, d.GetIndexByID(id))
 	}
 }
+
+// TestDecoderMaxNumberOfFrames exercises seek table parsing with
+// `Number_Of_Frames` set to maxNumberOfFrames.  Materializing such a stream is
+// impractical (the seek table alone would be ~48GiB), so only the footer is
+// crafted and the decoder is expected to reject it before allocating anything.
+func TestDecoderMaxNumberOfFrames(t *testing.T) {
+	t.Parallel()
+
+	for _, descriptor := range []byte{0x00, 0x80} {
+		footer := []byte{
+			0xff, 0xff, 0xff, 0xff,
+			descriptor,
+			0xb1, 0xea, 0x92, 0x8f,
+		}
+
+		_, err := NewDecoder(footer, nil)
+		require.ErrorContains(t, err, "frame offset is too big")
+	}
+}
+
+func TestDecoderEmptyFrames(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer enc.Close()
+
+	e, err := NewEncoder(enc)
+	require.NoError(t, err)
+
+	for _, src := range []string{"", "test", "", "test2", ""} {
+		_, err = e.Encode([]byte(src))
+		require.NoError(t, err)
+	}
+	seekTable, err := e.EndStream()
+	require.NoError(t, err)
+
+	d, err := NewDecoder(seekTable, nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, d.Close()) }()
+
+	assert.Equal(t, int64(5), d.NumFrames())
+	assert.Equal(t, int64(len(sourceString)), d.Size())
+
+	// Empty frames are kept in the index.
+	for id := int64(0); id < 5; id++ {
+		index := d.GetIndexByID(id)
+		require.NotNil(t, index)
+		assert.Equal(t, id, index.ID)
+	}
+
+	// Offset lookups skip empty frames.
+	assert.Equal(t, int64(1), d.GetIndexByDecompOffset(0).ID)
+	assert.Equal(t, int64(1), d.GetIndexByDecompOffset(3).ID)
+	assert.Equal(t, int64(3), d.GetIndexByDecompOffset(4).ID)
+	assert.Equal(t, int64(3), d.GetIndexByDecompOffset(8).ID)
+	assert.Nil(t, d.GetIndexByDecompOffset(9))
+
+	// Next frame may be empty.
+	current, next := d.GetNearestFrames(3)
+	assert.Equal(t, int64(1), current.ID)
+	assert.Equal(t, int64(2), next.ID)
+	current, next = d.GetNearestFrames(4)
+	assert.Equal(t, int64(3), current.ID)
+	assert.Equal(t, int64(4), next.ID)
+}
+
+func TestDecoderIterFrames(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer enc.Close()
+
+	e, err := NewEncoder(enc)
+	require.NoError(t, err)
+	for i := 0; i < 200; i++ {
+		_, err = e.Encode([]byte(strconv.Itoa(i)))
+		require.NoError(t, err)
+	}
+	seekTable, err := e.EndStream()
+	require.NoError(t, err)
+
+	d, err := NewDecoder(seekTable, nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, d.Close()) }()
+
+	// Frames are delivered in order.
+	var id int64
+	var off uint64
+	for index := range d.IterFrames(context.Background()) {
+		assert.Equal(t, id, index.ID)
+		assert.Equal(t, off, index.DecompOffset)
+		id++
+		off += uint64(index.DecompSize)
+	}
+	assert.Equal(t, d.NumFrames(), id)
+
+	// Cancellation closes the channel.
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := d.IterFrames(ctx)
+	<-ch
+	cancel()
+	n := 1
+	for range ch {
+		n++
+	}
+	assert.Less(t, n, 200)
+
+	// No frames.
+	e, err = NewEncoder(enc)
+	require.NoError(t, err)
+	seekTable, err = e.EndStream()
+	require.NoError(t, err)
+	empty, err := NewDecoder(seekTable, nil)
+	require.NoError(t, err)
+	require.Zero(t, empty.NumFrames())
+	_, ok := <-empty.IterFrames(context.Background())
+	assert.False(t, ok)
+
+	// Closed decoder.
+	require.NoError(t, empty.Close())
+	_, ok = <-empty.IterFrames(context.Background())
+	assert.False(t, ok)
+}
+
+func TestDecoderGetNearestFrames(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDecoder(checksum[17+18:], nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, d.Close()) }()
+
+	for off := uint64(0); off < 4; off++ {
+		current, next := d.GetNearestFrames(off)
+		require.NotNil(t, current)
+		require.NotNil(t, next)
+		assert.Equal(t, int64(0), current.ID)
+		assert.Equal(t, int64(1), next.ID)
+		assert.Equal(t, current.DecompOffset+uint64(current.DecompSize), next.DecompOffset)
+	}
+
+	for off := uint64(4); off < 9; off++ {
+		current, next := d.GetNearestFrames(off)
+		require.NotNil(t, current)
+		assert.Equal(t, int64(1), current.ID)
+		assert.Nil(t, next)
+	}
+
+	current, next := d.GetNearestFrames(9)
+	assert.Nil(t, current)
+	assert.Nil(t, next)
+}
+
+func TestDecoderGetFrameRange(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer enc.Close()
+
+	e, err := NewEncoder(enc)
+	require.NoError(t, err)
+	for _, src := range []string{"t", "te", "", "tes", "test"} {
+		_, err = e.Encode([]byte(src))
+		require.NoError(t, err)
+	}
+	seekTable, err := e.EndStream()
+	require.NoError(t, err)
+
+	d, err := NewDecoder(seekTable, nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, d.Close()) }()
+	require.Equal(t, int64(5), d.NumFrames())
+
+	size := uint64(d.Size())
+	for start := uint64(0); start <= size+2; start++ {
+		for end := uint64(0); end <= size+2; end++ {
+			var expected []int64
+			for id := int64(0); id < d.NumFrames(); id++ {
+				index := d.GetIndexByID(id)
+				frameStart, frameEnd := index.DecompOffset, index.DecompOffset+uint64(index.DecompSize)
+				if start < size && start == end && frameStart <= start && start < frameEnd {
+					expected = append(expected, id)
+				}
+				if start < size && start < end && frameStart < end && start < frameEnd && index.DecompSize != 0 {
+					expected = append(expected, id)
+				}
+			}
+
+			var actual []int64
+			for _, index := range d.GetFrameRange(start, end) {
+				actual = append(actual, index.ID)
+			}
+			assert.Equal(t, expected, actual, "[%d, %d)", start, end)
+		}
+	}
+}
+
+func TestDecoderGetIndexByCompOffset(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer enc.Close()
+
+	e, err := NewEncoder(enc)
+	require.NoError(t, err)
+
+	var compSize uint64
+	for _, src := range []string{"", "test", "", "test2", ""} {
+		dst, err := e.Encode([]byte(src))
+		require.NoError(t, err)
+		compSize += uint64(len(dst))
+	}
+	seekTable, err := e.EndStream()
+	require.NoError(t, err)
+
+	linear, err := NewDecoder(seekTable, nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, linear.Close()) }()
+	indexed, err := NewDecoder(seekTable, nil, WithCompressedOffsetIndex())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, indexed.Close()) }()
+
+	for _, d := range []Decoder{linear, indexed} {
+		frame1, frame3 := d.GetIndexByID(1), d.GetIndexByID(3)
+		for off := uint64(0); off < compSize+10; off++ {
+			index := d.GetIndexByCompOffset(off)
+			switch {
+			case off < frame3.CompOffset:
+				assert.Equal(t, frame1, index)
+			case off < compSize:
+				assert.Equal(t, frame3, index)
+			default:
+				assert.Nil(t, index)
+			}
+		}
+	}
+
+	// Index is rebuilt.
+	require.NoError(t, indexed.UnmarshalBinary(checksum[17+18:]))
+	assert.Equal(t, int64(1), indexed.GetIndexByCompOffset(17).ID)
+	assert.Equal(t, int64(0), indexed.GetIndexByCompOffset(16).ID)
+	assert.Nil(t, indexed.GetIndexByCompOffset(35))
+}
+
+func TestDecoderMarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	for _, seekTable := range [][]byte{checksum[17+18:], noChecksum[17+18:]} {
+		d, err := NewDecoder(seekTable, nil)
+		require.NoError(t, err)
+
+		b, err := d.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, seekTable, b)
+
+		// Empty decoder.
+		empty, err := NewSeekTableBuilder(true).Bytes()
+		require.NoError(t, err)
+		d, err = NewDecoder(empty, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), d.NumFrames())
+
+		require.NoError(t, d.UnmarshalBinary(b))
+		assert.Equal(t, int64(2), d.NumFrames())
+		assert.Equal(t, int64(len(sourceString)), d.Size())
+		assert.Equal(t, uint64(4), d.GetIndexByID(1).DecompOffset)
+
+		// State is kept on error.
+		require.Error(t, d.UnmarshalBinary(b[1:]))
+		assert.Equal(t, int64(2), d.NumFrames())
+
+		b, err = d.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, seekTable, b)
+	}
+
+	// Chunked seek tables are serialized in the regular format.
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+
+	chunked, err := NewEncoder(enc, WithChunkedSeekTable(2))
+	require.NoError(t, err)
+	regular, err := NewEncoder(enc)
+	require.NoError(t, err)
+	for _, src := range []string{"test", "", "test2", "test3", "test4"} {
+		_, err = chunked.Encode([]byte(src))
+		require.NoError(t, err)
+		_, err = regular.Encode([]byte(src))
+		require.NoError(t, err)
+	}
+	chunkedTable, err := chunked.EndStream()
+	require.NoError(t, err)
+	regularTable, err := regular.EndStream()
+	require.NoError(t, err)
+
+	d, err := NewDecoder(chunkedTable, nil)
+	require.NoError(t, err)
+	b, err := d.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, regularTable, b)
+}
+
+// countingReadEnvironment counts GetFrameByIndex calls.
+type countingReadEnvironment struct {
+	env.REnvironment
+	calls atomic.Int64
+}
+
+func (c *countingReadEnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	c.calls.Inc()
+	return c.REnvironment.GetFrameByIndex(index)
+}
+
+func TestDecoderPrefetch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = NewReader(nil, dec, WithCacheSize(0))
+	require.ErrorContains(t, err, "cache size must be positive")
+
+	e := &countingReadEnvironment{REnvironment: &fakeReadEnvironment{}}
+	r, err := NewReader(nil, dec, WithREnvironment(e), WithCacheSize(2))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	d := r.(Decoder)
+	require.NoError(t, d.Prefetch(ctx, []int64{0, 1}, e, dec))
+	assert.Equal(t, int64(2), e.calls.Load())
+
+	// Already cached frames are not fetched again.
+	require.NoError(t, d.Prefetch(ctx, []int64{1}, e, dec))
+	assert.Equal(t, int64(2), e.calls.Load())
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), all)
+	assert.Equal(t, int64(2), e.calls.Load())
+
+	// Errors.
+	require.ErrorContains(t, d.Prefetch(ctx, []int64{2}, e, dec), "failed to get index by id: 2")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, d.Prefetch(canceled, []int64{0}, e, dec), context.Canceled)
+
+	// Decoder without a ReadSeeker.
+	d, err = NewDecoder(checksum[17+18:], dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, d.Close()) }()
+
+	// Cache of the default size keeps all prefetched frames until they are read.
+	e = &countingReadEnvironment{REnvironment: &fakeReadEnvironment{}}
+	require.NoError(t, d.Prefetch(ctx, []int64{1, 0}, e, dec))
+	assert.Equal(t, int64(2), e.calls.Load())
+	assert.Equal(t, 2, d.(*readerImpl).cache.len())
+
+	got, err := d.DecompressRange(e, 0, uint64(len(sourceString)))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), got)
+	assert.Equal(t, int64(2), e.calls.Load())
+	assert.Equal(t, 1, d.(*readerImpl).cache.len())
+}
+
+func TestDecoderPrefetchCacheSize(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	const frameCount = 10
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	var original []byte
+	ids := make([]int64, 0, frameCount)
+	for i := 0; i < frameCount; i++ {
+		frame := makeTestFrame(t, i)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		original = append(original, frame...)
+		ids = append(ids, int64(i))
+	}
+	require.NoError(t, w.Close())
+
+	e := &countingReadEnvironment{REnvironment: NewReadSeekerEnv(bytes.NewReader(b.Bytes()))}
+	r, err := NewReader(nil, dec, WithREnvironment(e), WithCacheSize(2))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	sr := r.(*readerImpl)
+
+	require.NoError(t, sr.Prefetch(context.Background(), ids, nil, dec))
+	assert.Equal(t, int64(frameCount), e.calls.Load())
+	assert.Equal(t, frameCount, sr.cache.len())
+
+	// Frames are evicted down to the cache size once they are read.
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, original, all)
+	assert.Equal(t, int64(frameCount), e.calls.Load())
+	assert.Equal(t, 2, sr.cache.len())
+}
+
+func TestDecoderDecompressRange(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	// Frames of 100, 200, ..., 500 bytes.
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	var original []byte
+	for i := 1; i <= 5; i++ {
+		frame := bytes.Repeat([]byte(strconv.Itoa(i)), i*100)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		original = append(original, frame...)
+	}
+	require.NoError(t, w.Close())
+	stream := b.Bytes()
+
+	r, err := NewReader(bytes.NewReader(stream), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	d := r.(Decoder)
+	e := &countingReadEnvironment{REnvironment: NewReadSeekerEnv(bytes.NewReader(stream))}
+
+	for _, tc := range []struct {
+		name       string
+		start, end uint64
+		frames     int64
+	}{
+		{"single frame", 120, 250, 1},
+		{"multiple frames", 50, 1450, 5},
+		{"exact frame", 100, 300, 1},
+		{"exact frames", 300, 1000, 2},
+		{"whole stream", 0, 1500, 5},
+		{"empty", 300, 300, 0},
+		{"empty at the end", 1500, 1500, 0},
+	} {
+		calls := e.calls.Load()
+		actual, err := d.DecompressRange(e, tc.start, tc.end)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, original[tc.start:tc.end], actual, tc.name)
+		assert.Equal(t, tc.frames, e.calls.Load()-calls, tc.name)
+	}
+
+	// Prefetched frames are not fetched again.
+	require.NoError(t, d.Prefetch(context.Background(), []int64{4}, e, dec))
+	calls := e.calls.Load()
+	actual, err := d.DecompressRange(e, 1000, 1500)
+	require.NoError(t, err)
+	assert.Equal(t, original[1000:], actual)
+	assert.Equal(t, calls, e.calls.Load())
+
+	_, err = d.DecompressRange(e, 2, 1)
+	require.ErrorContains(t, err, "invalid range: [2, 1)")
+	_, err = d.DecompressRange(e, 0, 1501)
+	require.ErrorContains(t, err, "invalid range: [0, 1501)")
+
+	// Checksums are verified.
+	corrupted := bytes.Clone(checksum)
+	corrupted[51] ^= 0xff
+	d, err = NewDecoder(corrupted[17+18:], dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, d.Close()) }()
+	actual, err = d.DecompressRange(NewReadSeekerEnv(bytes.NewReader(corrupted)), 4, 9)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("test2"), actual)
+	_, err = d.DecompressRange(NewReadSeekerEnv(bytes.NewReader(corrupted)), 3, 5)
+	require.ErrorContains(t, err, "checksum verification failed")
+}
+
+func TestDecoderParallelDecompressRange(t *testing.T) {
+	t.Parallel()
+
+	const numFrames, latency = 10, 50 * time.Millisecond
+	ctx := context.Background()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	var original []byte
+	for i := 0; i < numFrames; i++ {
+		frame := makeTestFrame(t, i)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		original = append(original, frame...)
+	}
+	require.NoError(t, w.Close())
+	stream := b.Bytes()
+
+	r, err := NewReader(bytes.NewReader(stream), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	d := r.(Decoder)
+	newEnv := func(failID int64) *countingReadEnvironment {
+		return &countingReadEnvironment{REnvironment: &latencyReadEnvironment{
+			REnvironment: NewReadSeekerEnv(bytes.NewReader(stream)),
+			latency:      latency,
+			failID:       failID,
+		}}
+	}
+
+	size := uint64(len(original))
+	for _, tc := range []struct {
+		name       string
+		start, end uint64
+	}{
+		{"whole stream", 0, size},
+		{"partial frames", 10, size - 10},
+		{"single frame", 1, 2},
+		{"empty", 5, 5},
+		{"empty at the end", size, size},
+	} {
+		actual, err := d.ParallelDecompressRange(ctx, newEnv(-1), tc.start, tc.end, 4)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, original[tc.start:tc.end], actual, tc.name)
+	}
+
+	// Frames are fetched concurrently.
+	start := time.Now()
+	_, err = d.ParallelDecompressRange(ctx, newEnv(-1), 0, size, numFrames)
+	require.NoError(t, err)
+	parallel := time.Since(start)
+	start = time.Now()
+	_, err = d.DecompressRange(newEnv(-1), 0, size)
+	require.NoError(t, err)
+	sequential := time.Since(start)
+	assert.Less(t, parallel, 5*latency)
+	assert.GreaterOrEqual(t, sequential, numFrames*latency)
+
+	// Frames that are not fetched yet are skipped once a frame fails.
+	e := newEnv(0)
+	_, err = d.ParallelDecompressRange(ctx, e, 0, size, 1)
+	require.ErrorContains(t, err, "test error")
+	assert.Equal(t, int64(1), e.calls.Load())
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = d.ParallelDecompressRange(canceled, e, 0, size, 1)
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = d.ParallelDecompressRange(ctx, e, 0, size, 0)
+	require.ErrorContains(t, err, "concurrency must be positive")
+	_, err = d.ParallelDecompressRange(ctx, e, 0, size+1, 1)
+	require.ErrorContains(t, err, "invalid range")
+
+	// Checksums are verified.
+	corrupted := bytes.Clone(checksum)
+	corrupted[51] ^= 0xff
+	cd, err := NewDecoder(corrupted[17+18:], dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, cd.Close()) }()
+	_, err = cd.ParallelDecompressRange(ctx, NewReadSeekerEnv(bytes.NewReader(corrupted)), 0, 9, 2)
+	require.ErrorContains(t, err, "checksum verification failed")
+}
+
+func TestNewDecoderFromReadSeeker(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	path := filepath.Join(t.TempDir(), "stream.zst")
+	require.NoError(t, os.WriteFile(path, checksum, 0o600))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	d, err := NewDecoderFromReadSeeker(f, dec, WithCacheSize(2))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, d.Close()) }()
+
+	assert.Equal(t, int64(len(sourceString)), d.Size())
+	assert.Equal(t, int64(2), d.NumFrames())
+	assert.Equal(t, d.GetIndexByID(1), d.GetIndexByDecompOffset(4))
+
+	actual, err := d.DecompressRange(nil, 2, 7)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString[2:7]), actual)
+
+	require.NoError(t, d.Prefetch(context.Background(), []int64{0, 1}, nil, dec))
+	actual, err = d.DecompressRange(nil, 0, uint64(d.Size()))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), actual)
+
+	_, err = NewDecoderFromReadSeeker(nil, dec)
+	require.ErrorContains(t, err, "read seeker is nil")
+
+	// Decoders created from the seek table alone need an explicit environment.
+	d, err = NewDecoder(checksum[17+18:], dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, d.Close()) }()
+	_, err = d.DecompressRange(nil, 0, 1)
+	require.ErrorContains(t, err, "environment is not set")
+	require.ErrorContains(t, d.Prefetch(context.Background(), []int64{0}, nil, dec), "environment is not set")
+}
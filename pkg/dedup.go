@@ -0,0 +1,131 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// dedupKey identifies the frame content.  Compression is deterministic for the same encoder,
+// so besides the checksum of the decompressed data, the hash of the compressed frame is
+// compared, which makes accidental collisions of the 32-bit checksum harmless.
+type dedupKey struct {
+	checksum   uint32
+	decompSize uint32
+	compHash   uint64
+}
+
+// dedupRef is the location of the first occurrence of the frame in the stream.
+type dedupRef struct {
+	id         int64
+	compOffset uint64
+	compSize   uint32
+}
+
+/*
+marshalReference serializes the location of a previously written frame into a reference
+skippable frame (tagged with referenceTag) that is written instead of its duplicate:
+
+	|`Skippable_Magic_Number`|`Frame_Size`|`Frame_ID`|`Comp_Offset`|`Comp_Size`|
+	|------------------------|------------|----------|-------------|-----------|
+	| 4 bytes                | 4 bytes    | 8 bytes  | 8 bytes     | 4 bytes   |
+
+`Frame_ID`, `Comp_Offset` and `Comp_Size` describe the referenced frame, the ID is passed
+to the environment along with the location, so that e.g. frames fetched in bulk are reused.
+The seek table entry of the reference frame has `Compressed_Size` of the reference frame itself,
+so that offsets of the following frames stay correct, while `Decompressed_Size` and `Checksum`
+are the ones of the referenced frame.
+*/
+func marshalReference(ref dedupRef) ([]byte, error) {
+	payload := make([]byte, referenceFrameSize-skippableMagicNumberFieldSize-frameSizeFieldSize)
+	binary.LittleEndian.PutUint64(payload, uint64(ref.id))
+	binary.LittleEndian.PutUint64(payload[8:], ref.compOffset)
+	binary.LittleEndian.PutUint32(payload[16:], ref.compSize)
+	return createSkippableFrame(referenceTag, payload)
+}
+
+// unmarshalReference parses the reference frame created by marshalReference,
+// ok is false if src is not a reference frame.
+func unmarshalReference(src []byte) (ref dedupRef, ok bool) {
+	if len(src) != referenceFrameSize ||
+		binary.LittleEndian.Uint32(src) != skippableFrameMagic+referenceTag ||
+		binary.LittleEndian.Uint32(src[skippableMagicNumberFieldSize:]) != referenceFrameSize-skippableMagicNumberFieldSize-frameSizeFieldSize {
+		return dedupRef{}, false
+	}
+	payload := src[skippableMagicNumberFieldSize+frameSizeFieldSize:]
+	return dedupRef{
+		id:         int64(binary.LittleEndian.Uint64(payload)),
+		compOffset: binary.LittleEndian.Uint64(payload[8:]),
+		compSize:   binary.LittleEndian.Uint32(payload[16:]),
+	}, true
+}
+
+// dedupFrame returns the reference frame and its entry in place of dst if a frame with
+// the same content was already written, otherwise it remembers the location of dst.
+// It must be called right before appendEntry, in the order frames are written.
+func (s *writerImpl) dedupFrame(dst []byte, entry seekTableEntry) ([]byte, seekTableEntry, error) {
+	if s.dedup == nil || len(dst) == 0 {
+		return dst, entry, nil
+	}
+
+	key := dedupKey{
+		checksum:   entry.Checksum,
+		decompSize: entry.DecompressedSize,
+		compHash:   xxhash.Sum64(dst),
+	}
+	ref, ok := s.dedup[key]
+	if !ok {
+		s.dedup[key] = dedupRef{
+			id:         s.nextFrameID(),
+			compOffset: s.compOffset,
+			compSize:   entry.CompressedSize,
+		}
+		return dst, entry, nil
+	}
+
+	frame, err := marshalReference(ref)
+	if err != nil {
+		return nil, seekTableEntry{}, err
+	}
+	entry.CompressedSize = uint32(len(frame))
+	return frame, entry, nil
+}
+
+// resolveReference returns the referenced frame if src is a reference frame written
+// by WithDeduplication, otherwise src is returned as is.
+func (r *readerImpl) resolveReference(e env.REnvironment, index *env.FrameOffsetEntry, src []byte) ([]byte, error) {
+	if index.DecompSize == 0 {
+		return src, nil
+	}
+	ref, ok := unmarshalReference(src)
+	if !ok {
+		return src, nil
+	}
+
+	if int64(ref.compSize) > r.maxFrameSize {
+		return nil, fmt.Errorf("frame %d: referenced frame is too big: %d > %d",
+			index.ID, ref.compSize, r.maxFrameSize)
+	}
+	if ref.id < 0 || ref.id >= index.ID || ref.compOffset >= index.CompOffset {
+		return nil, fmt.Errorf("frame %d: reference must point backwards: frame %d at %#x",
+			index.ID, ref.id, ref.compOffset)
+	}
+
+	referenced := *index
+	referenced.ID = ref.id
+	referenced.CompOffset = ref.compOffset
+	referenced.CompSize = ref.compSize
+	src, err := e.GetFrameByIndex(referenced)
+	if err != nil {
+		return nil, fmt.Errorf("frame %d: failed to read referenced frame at: %d, %w", index.ID, ref.compOffset, err)
+	}
+	r.stats.bytesReadCompressed.Add(uint64(len(src)))
+	if len(src) != int(ref.compSize) {
+		return nil, fmt.Errorf("frame %d: referenced frame size mismatch: expected: %d, actual: %d",
+			index.ID, ref.compSize, len(src))
+	}
+	return src, nil
+}
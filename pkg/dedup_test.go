@@ -0,0 +1,152 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeduplication(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	frame := makeTestFrame(t, 0)
+	single := len(enc.EncodeAll(frame, nil))
+
+	var frames [][]byte
+	var original []byte
+	for i := 0; i < 10; i++ {
+		// Every other frame is unique, the rest repeat the first one.
+		f := frame
+		if i%2 == 1 {
+			f = makeTestFrame(t, i)
+		}
+		frames = append(frames, f)
+		original = append(original, f...)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		write func(w ConcurrentWriter) error
+	}{
+		{"Write", func(w ConcurrentWriter) error {
+			for _, f := range frames {
+				if _, err := w.Write(f); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{"WriteMany", func(w ConcurrentWriter) error {
+			return w.WriteMany(context.Background(), makeTestFrameSource(frames), WithConcurrency(3))
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriter(&b, enc, WithDeduplication(), WithWMagicPrefix([]byte("prefix")))
+			require.NoError(t, err)
+			require.NoError(t, tc.write(w))
+			require.NoError(t, w.Close())
+
+			var plain bytes.Buffer
+			pw, err := NewWriter(&plain, enc)
+			require.NoError(t, err)
+			require.NoError(t, pw.WriteMany(context.Background(), makeTestFrameSource(frames)))
+			require.NoError(t, pw.Close())
+			assert.Equal(t, 4*(single-referenceFrameSize), plain.Len()-(b.Len()-len("prefix")))
+
+			r, err := NewReader(bytes.NewReader(b.Bytes()), dec,
+				WithRMagicPrefix([]byte("prefix")), WithStrictMode(true))
+			require.NoError(t, err)
+			defer func() { require.NoError(t, r.Close()) }()
+
+			all, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, original, all)
+
+			d := r.(Decoder)
+			assert.Equal(t, int64(10), d.NumFrames())
+			buf := make([]byte, 10)
+			_, err = r.ReadAt(buf, int64(len(original)-len(frames[9])-len(frame)+5))
+			require.NoError(t, err)
+			assert.Equal(t, frame[5:15], buf)
+		})
+	}
+}
+
+func TestDeduplicationReset(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc, WithDeduplication())
+	require.NoError(t, err)
+	_, err = w.Write([]byte(sourceString))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// Frames of the previous stream are not referenced from the new one.
+	var b2 bytes.Buffer
+	w.ResetTo(&b2)
+	for i := 0; i < 2; i++ {
+		_, err = w.Write([]byte(sourceString))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(b2.Bytes()), dec)
+	require.NoError(t, err)
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, sourceString+sourceString, string(all))
+	require.NoError(t, r.Close())
+	require.NoError(t, FullValidate(bytes.NewReader(b2.Bytes()), dec))
+
+	_, err = NewWriter(&b, enc, WithDeduplication(), WithHierarchicalIndex(2))
+	assert.ErrorContains(t, err, "deduplication can not be used")
+}
+
+func TestResolveReference(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc, WithDeduplication())
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = w.Write([]byte(sourceString))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	// Point the reference to itself.
+	ref := len(enc.EncodeAll([]byte(sourceString), nil))
+	data := bytes.Clone(b.Bytes())
+	data[ref+skippableMagicNumberFieldSize+frameSizeFieldSize] = 1
+
+	r, err := NewReader(bytes.NewReader(data), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	_, err = io.ReadAll(r)
+	assert.ErrorContains(t, err, "reference must point backwards")
+}
@@ -1,10 +1,14 @@
 package seekable
 
 import (
+	"context"
+	"encoding/binary"
 	"fmt"
+	"sync"
 
 	"github.com/cespare/xxhash/v2"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 // Encoder is a byte-oriented API that is useful where wrapping io.Writer is not desirable.
@@ -12,8 +16,35 @@ type Encoder interface {
 	// Encode returns compressed data and appends a frame to in-memory seek table.
 	Encode(src []byte) ([]byte, error)
 
+	// EncodeBatch is like calling Encode for each of frames, but compresses them concurrently
+	// with up to concurrency goroutines.  Results are in the same order as frames.
+	// On error, including cancellation of ctx, nothing is appended to the seek table.
+	EncodeBatch(ctx context.Context, frames [][]byte, concurrency int) ([][]byte, error)
+
 	// EndStream returns in-memory seek table as a ZSTD's skippable frame.
 	EndStream() ([]byte, error)
+
+	// Reset discards the in-memory seek table, so that the Encoder can be reused for a new stream
+	// as if it were freshly created with the same options.  Memory of the seek table is retained.
+	Reset()
+
+	// TotalCompressedBytes returns the total size of frames in the seek table, for the hierarchical
+	// index it includes the fine indexes of completed spans.
+	TotalCompressedBytes() uint64
+
+	// TotalDecompressedBytes returns the total decompressed size of frames in the seek table.
+	TotalDecompressedBytes() uint64
+
+	// CompressionRatio returns TotalDecompressedBytes / TotalCompressedBytes,
+	// or 1.0 if no frames have been written.
+	CompressionRatio() float64
+
+	// NumFrames returns the number of frames encoded so far.
+	NumFrames() int64
+
+	// TotalBytesWritten returns the total size of data encoded so far.
+	// Unlike TotalDecompressedBytes, it is goroutine-safe.
+	TotalBytesWritten() uint64
 }
 
 func NewEncoder(encoder ZSTDEncoder, opts ...wOption) (Encoder, error) {
@@ -25,7 +56,11 @@ func NewEncoder(encoder ZSTDEncoder, opts ...wOption) (Encoder, error) {
 	return sw.(*writerImpl), err
 }
 
-func (s *writerImpl) encodeOne(src []byte) ([]byte, seekTableEntry, error) {
+// encodeOne compresses src as the frame with the given id without adding it to the seek table.
+func (s *writerImpl) encodeOne(ctx context.Context, id int64, src []byte) (dst []byte, entry seekTableEntry, err error) {
+	end := s.tracer.StartFrameWrite(ctx, id, uint64(len(src)))
+	defer func() { end(uint64(len(dst)), err) }()
+
 	if int64(len(src)) > maxChunkSize {
 		return nil, seekTableEntry{},
 			fmt.Errorf("chunk size too big for seekable format: %d > %d",
@@ -36,7 +71,10 @@ func (s *writerImpl) encodeOne(src []byte) ([]byte, seekTableEntry, error) {
 		return nil, seekTableEntry{}, nil
 	}
 
-	dst := s.enc.EncodeAll(src, nil)
+	dst = s.enc.EncodeAll(src, nil)
+	if s.cipher != nil {
+		dst = s.cipher.sealFrame(id, dst)
+	}
 
 	if int64(len(dst)) > maxChunkSize {
 		return nil, seekTableEntry{},
@@ -52,35 +90,335 @@ func (s *writerImpl) encodeOne(src []byte) ([]byte, seekTableEntry, error) {
 }
 
 func (s *writerImpl) Encode(src []byte) ([]byte, error) {
-	dst, entry, err := s.encodeOne(src)
+	return s.encode(context.Background(), src)
+}
+
+func (s *writerImpl) encode(ctx context.Context, src []byte) ([]byte, error) {
+	dst, entry, err := s.encodeOne(ctx, s.nextFrameID(), src)
 	if err != nil {
 		return nil, err
 	}
+	if dst, entry, err = s.dedupFrame(dst, entry); err != nil {
+		return nil, err
+	}
 
 	s.logger.Debug("appending frame", zap.Object("frame", &entry))
-	s.frameEntries = append(s.frameEntries, entry)
-	return dst, nil
+	fine, err := s.appendEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, fine...), nil
+}
+
+func (s *writerImpl) EncodeBatch(ctx context.Context, frames [][]byte, concurrency int) ([][]byte, error) {
+	if concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be positive: %d", concurrency)
+	}
+
+	firstID := s.nextFrameID()
+	dsts := make([][]byte, len(frames))
+	entries := make([]seekTableEntry, len(frames))
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, frame := range frames {
+		g.Go(func() error {
+			if err := gCtx.Err(); err != nil {
+				return err
+			}
+			var err error
+			dsts[i], entries[i], err = s.encodeOne(gCtx, firstID+int64(i), frame)
+			if err != nil {
+				return fmt.Errorf("failed to encode frame %d: %w", i, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Entries are appended in order once all frames are compressed.
+	for i, entry := range entries {
+		var err error
+		if dsts[i], entry, err = s.dedupFrame(dsts[i], entry); err != nil {
+			return nil, err
+		}
+		s.logger.Debug("appending frame", zap.Object("frame", &entry))
+		fine, err := s.appendEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		dsts[i] = append(dsts[i], fine...)
+	}
+	return dsts, nil
+}
+
+func (s *writerImpl) Reset() {
+	s.frameEntries = s.frameEntries[:0]
+	s.spanEntries = s.spanEntries[:0]
+	s.spanFirstID, s.spanCompSize, s.spanDecompSize = 0, 0, 0
+	s.numFrames.Store(0)
+	s.bytesWritten.Store(0)
+	s.seekTable = nil
+	s.magicPrefixWritten = false
+	s.compOffset = uint64(len(s.magicPrefix))
+	if s.dedup != nil {
+		clear(s.dedup)
+	}
+	s.once = &sync.Once{}
+	if s.cipher != nil {
+		s.cipher.setSalt(newEncryptionSalt())
+	}
+}
+
+func (s *writerImpl) TotalCompressedBytes() uint64 {
+	var total uint64
+	for _, entries := range [][]seekTableEntry{s.spanEntries, s.frameEntries} {
+		for _, e := range entries {
+			total += uint64(e.CompressedSize)
+		}
+	}
+	return total
+}
+
+func (s *writerImpl) TotalDecompressedBytes() uint64 {
+	var total uint64
+	for _, entries := range [][]seekTableEntry{s.spanEntries, s.frameEntries} {
+		for _, e := range entries {
+			total += uint64(e.DecompressedSize)
+		}
+	}
+	return total
+}
+
+func (s *writerImpl) CompressionRatio() float64 {
+	compressed := s.TotalCompressedBytes()
+	if compressed == 0 {
+		return 1.0
+	}
+	return float64(s.TotalDecompressedBytes()) / float64(compressed)
 }
 
 func (s *writerImpl) EndStream() ([]byte, error) {
-	if int64(len(s.frameEntries)) > maxNumberOfFrames {
+	if s.spanFrames > 0 {
+		return s.endHierarchicalStream()
+	}
+	if s.cipher != nil {
+		return marshalEncryptedSeekTable(s.frameEntries, s.cipher)
+	}
+	if s.chunkEntries > 0 {
+		return marshalChunkedSeekTable(s.frameEntries, true, s.chunkEntries)
+	}
+	if s.compressSeekTable {
+		return marshalCompressedSeekTable(s.frameEntries, true, s.enc)
+	}
+	if s.varintSeekTable {
+		return marshalVarintSeekTable(s.frameEntries, true)
+	}
+	return marshalSeekTable(s.frameEntries, true)
+}
+
+// marshalSeekTable serializes entries into a seek table skippable frame.
+func marshalSeekTable(entries []seekTableEntry, checksums bool) ([]byte, error) {
+	return marshalSeekTableWithDescriptor(entries, seekTableDescriptor{ChecksumFlag: checksums})
+}
+
+// marshalSeekTableWithDescriptor serializes entries into a seek table skippable frame
+// with the given descriptor.
+func marshalSeekTableWithDescriptor(entries []seekTableEntry, descriptor seekTableDescriptor) ([]byte, error) {
+	if int64(len(entries)) > maxNumberOfFrames {
+		return nil, fmt.Errorf("number of frames for seekable format: %d > %d",
+			len(entries), maxNumberOfFrames)
+	}
+
+	entrySize := 8
+	if descriptor.ChecksumFlag {
+		entrySize += 4
+	}
+
+	seekTable := make([]byte, len(entries)*entrySize+seekTableFooterOffset)
+	for i, e := range entries {
+		e.marshalBinaryInline(seekTable[i*entrySize : (i+1)*entrySize])
+	}
+
+	footer := seekTableFooter{
+		NumberOfFrames:      uint32(len(entries)),
+		SeekTableDescriptor: descriptor,
+		SeekableMagicNumber: seekableMagicNumber,
+	}
+
+	footer.marshalBinaryInline(seekTable[len(entries)*entrySize:])
+	return createSkippableFrame(seekableTag, seekTable)
+}
+
+/*
+marshalCompressedSeekTable serializes entries into a seek table skippable frame (tagged with seekableTag)
+where `Seek_Table_Entries` are compressed as a single ZSTD frame:
+
+	|`Skippable_Magic_Number`|`Frame_Size`|`Compressed_Entries`|`Compressed_Size`|`Seek_Table_Footer`|
+	|------------------------|------------|--------------------|-----------------|-------------------|
+	| 4 bytes                | 4 bytes    | n bytes            | 4 bytes         | 9 bytes           |
+
+`Compressed_Size` is the size of `Compressed_Entries` and the `Compressed_Flag` is set in the footer.
+*/
+func marshalCompressedSeekTable(entries []seekTableEntry, checksums bool, enc ZSTDEncoder) ([]byte, error) {
+	if int64(len(entries)) > maxNumberOfFrames {
 		return nil, fmt.Errorf("number of frames for seekable format: %d > %d",
-			len(s.frameEntries), maxNumberOfFrames)
+			len(entries), maxNumberOfFrames)
+	}
+
+	entrySize := 8
+	if checksums {
+		entrySize += 4
 	}
 
-	seekTable := make([]byte, len(s.frameEntries)*12+9)
-	for i, e := range s.frameEntries {
-		e.marshalBinaryInline(seekTable[i*12 : (i+1)*12])
+	raw := make([]byte, len(entries)*entrySize)
+	for i, e := range entries {
+		e.marshalBinaryInline(raw[i*entrySize : (i+1)*entrySize])
+	}
+	payload := enc.EncodeAll(raw, nil)
+	if int64(len(payload)) > maxChunkSize {
+		return nil, fmt.Errorf("compressed seek table is too big: %d > %d", len(payload), maxChunkSize)
 	}
 
+	trailer := make([]byte, payloadSeekTableTrailerSize)
+	binary.LittleEndian.PutUint32(trailer, uint32(len(payload)))
 	footer := seekTableFooter{
-		NumberOfFrames: uint32(len(s.frameEntries)),
+		NumberOfFrames: uint32(len(entries)),
 		SeekTableDescriptor: seekTableDescriptor{
-			ChecksumFlag: true,
+			ChecksumFlag:   checksums,
+			CompressedFlag: true,
 		},
 		SeekableMagicNumber: seekableMagicNumber,
 	}
+	footer.marshalBinaryInline(trailer[payloadSizeFieldSize:])
 
-	footer.marshalBinaryInline(seekTable[len(s.frameEntries)*12 : len(s.frameEntries)*12+9])
-	return createSkippableFrame(seekableTag, seekTable)
+	return createSkippableFrame(seekableTag, append(payload, trailer...))
+}
+
+/*
+marshalVarintSeekTable serializes entries into a seek table skippable frame (tagged with seekableTag)
+where each of `Seek_Table_Entries` is encoded as:
+
+	|`Compressed_Size_Delta`|`Decompressed_Size_Delta`|`[Checksum]`|
+	|-----------------------|-------------------------|------------|
+	| 1-5 bytes             | 1-5 bytes               | 4 bytes    |
+
+Deltas are differences with sizes of the previous frame (0 for the first one) encoded as
+zig-zag varints (see binary.AppendVarint), so that similarly sized frames take 2 bytes
+plus the checksum.  Entries are followed by their total size and the footer with `Varint_Flag` set:
+
+	|`Skippable_Magic_Number`|`Frame_Size`|`Varint_Entries`|`Entries_Size`|`Seek_Table_Footer`|
+	|------------------------|------------|----------------|--------------|-------------------|
+	| 4 bytes                | 4 bytes    | n bytes        | 4 bytes      | 9 bytes           |
+*/
+func marshalVarintSeekTable(entries []seekTableEntry, checksums bool) ([]byte, error) {
+	if int64(len(entries)) > maxNumberOfFrames {
+		return nil, fmt.Errorf("number of frames for seekable format: %d > %d",
+			len(entries), maxNumberOfFrames)
+	}
+
+	payload := make([]byte, 0, len(entries)*6+payloadSeekTableTrailerSize)
+	var prev seekTableEntry
+	for _, e := range entries {
+		payload = binary.AppendVarint(payload, int64(e.CompressedSize)-int64(prev.CompressedSize))
+		payload = binary.AppendVarint(payload, int64(e.DecompressedSize)-int64(prev.DecompressedSize))
+		if checksums {
+			payload = binary.LittleEndian.AppendUint32(payload, e.Checksum)
+		}
+		prev = e
+	}
+	if int64(len(payload)) > maxChunkSize {
+		return nil, fmt.Errorf("varint seek table is too big: %d > %d", len(payload), maxChunkSize)
+	}
+
+	payload = binary.LittleEndian.AppendUint32(payload, uint32(len(payload)))
+	footer := seekTableFooter{
+		NumberOfFrames: uint32(len(entries)),
+		SeekTableDescriptor: seekTableDescriptor{
+			ChecksumFlag: checksums,
+			VarintFlag:   true,
+		},
+		SeekableMagicNumber: seekableMagicNumber,
+	}
+	payload = append(payload, make([]byte, seekTableFooterOffset)...)
+	footer.marshalBinaryInline(payload[len(payload)-seekTableFooterOffset:])
+
+	return createSkippableFrame(seekableTag, payload)
+}
+
+/*
+marshalChunkedSeekTable serializes entries into a sequence of seek table skippable frames
+(all of them tagged with seekableTag) each holding up to chunkEntries entries:
+
+	|`Chunk`|...|`Chunk`|`Last_Chunk`|
+
+All chunks except for the last one contain exactly chunkEntries `Seek_Table_Entries`.
+The last chunk contains the remaining entries, `Chunk_Entries` and the footer:
+
+	|`Skippable_Magic_Number`|`Frame_Size`|`[Seek_Table_Entries]`|`Chunk_Entries`|`Seek_Table_Footer`|
+	|------------------------|------------|----------------------|---------------|-------------------|
+	| 4 bytes                | 4 bytes    | 8-12 bytes each      | 4 bytes       | 9 bytes           |
+
+`Number_Of_Frames` in the footer is the total number of frames across all chunks and
+the `Chunked_Flag` is set, so the reader can compute the layout of all chunks from
+the footer and `Chunk_Entries`.
+*/
+func marshalChunkedSeekTable(entries []seekTableEntry, checksums bool, chunkEntries int) ([]byte, error) {
+	if int64(len(entries)) > maxNumberOfFrames {
+		return nil, fmt.Errorf("number of frames for seekable format: %d > %d",
+			len(entries), maxNumberOfFrames)
+	}
+
+	entrySize := 8
+	if checksums {
+		entrySize += 4
+	}
+
+	numChunks := (len(entries) + chunkEntries - 1) / chunkEntries
+	if numChunks == 0 {
+		numChunks = 1
+	}
+	lastEntries := len(entries) - (numChunks-1)*chunkEntries
+
+	res := make([]byte, 0,
+		(numChunks-1)*(chunkEntries*entrySize+8)+lastEntries*entrySize+8+chunkedSeekTableTrailerSize)
+	for i := 0; i < numChunks; i++ {
+		chunk := entries[i*chunkEntries:]
+		if len(chunk) > chunkEntries {
+			chunk = chunk[:chunkEntries]
+		}
+
+		payloadSize := len(chunk) * entrySize
+		last := i == numChunks-1
+		if last {
+			payloadSize += chunkedSeekTableTrailerSize
+		}
+
+		payload := make([]byte, payloadSize)
+		for j, e := range chunk {
+			e.marshalBinaryInline(payload[j*entrySize : (j+1)*entrySize])
+		}
+
+		if last {
+			trailer := payload[len(chunk)*entrySize:]
+			binary.LittleEndian.PutUint32(trailer, uint32(chunkEntries))
+			footer := seekTableFooter{
+				NumberOfFrames: uint32(len(entries)),
+				SeekTableDescriptor: seekTableDescriptor{
+					ChecksumFlag: checksums,
+					ChunkedFlag:  true,
+				},
+				SeekableMagicNumber: seekableMagicNumber,
+			}
+			footer.marshalBinaryInline(trailer[chunkEntriesFieldSize:])
+		}
+
+		frame, err := createSkippableFrame(seekableTag, payload)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, frame...)
+	}
+	return res, nil
 }
@@ -1,6 +1,13 @@
 package seekable
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
 	"testing"
 
 	"github.com/klauspost/compress/zstd"
@@ -44,3 +51,344 @@ func TestEncoder(t *testing.T) {
 	assert.Equal(t, int64(len(sourceString)), d.Size())
 	assert.Equal(t, int64(2), d.NumFrames())
 }
+
+func TestEncoderReset(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	for _, opts := range [][]wOption{nil, {WithHierarchicalIndex(2)}} {
+		e, err := NewEncoder(enc, opts...)
+		require.NoError(t, err)
+
+		var first []byte
+		for i := 0; i < 3; i++ {
+			var stream []byte
+			for _, frame := range []string{"test", "test2", "test"} {
+				dst, err := e.Encode([]byte(frame))
+				require.NoError(t, err)
+				stream = append(stream, dst...)
+			}
+			footer, err := e.EndStream()
+			require.NoError(t, err)
+			stream = append(stream, footer...)
+
+			r, err := NewReader(bytes.NewReader(stream), nil, WithDefaultDecoder(), WithSizeValidation())
+			require.NoError(t, err)
+			actual, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "testtest2test", string(actual))
+			require.NoError(t, r.Close())
+
+			// Reused encoder produces the same stream as the fresh one.
+			if first == nil {
+				first = stream
+			}
+			assert.Equal(t, first, stream)
+
+			e.Reset()
+		}
+	}
+}
+
+func TestCompressionRatio(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	compressible := bytes.Repeat([]byte("a"), 64<<10)
+	incompressible := make([]byte, 64<<10)
+	_, err = rand.New(rand.NewSource(42)).Read(incompressible)
+	require.NoError(t, err)
+
+	for _, opts := range [][]wOption{nil, {WithHierarchicalIndex(2)}} {
+		e, err := NewEncoder(enc, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, e.CompressionRatio())
+		assert.Zero(t, e.TotalCompressedBytes())
+		assert.Zero(t, e.TotalDecompressedBytes())
+
+		var compressed uint64
+		for i := 0; i < 3; i++ {
+			dst, err := e.Encode(incompressible)
+			require.NoError(t, err)
+			compressed += uint64(len(dst))
+		}
+		assert.Equal(t, uint64(3*len(incompressible)), e.TotalDecompressedBytes())
+		assert.Equal(t, compressed, e.TotalCompressedBytes())
+		assert.InDelta(t, 1.0, e.CompressionRatio(), 0.01)
+
+		for i := 0; i < 3; i++ {
+			_, err = e.Encode(compressible)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, uint64(3*len(incompressible)+3*len(compressible)), e.TotalDecompressedBytes())
+		assert.InDelta(t, 2.0, e.CompressionRatio(), 0.1)
+
+		e.Reset()
+		assert.Equal(t, 1.0, e.CompressionRatio())
+	}
+}
+
+func TestEncodeBatch(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(1))
+	frames := make([][]byte, 100)
+	for i := range frames {
+		frames[i] = make([]byte, rng.Intn(4096))
+		_, _ = rng.Read(frames[i][:len(frames[i])/2])
+	}
+
+	for _, opts := range [][]wOption{nil, {WithHierarchicalIndex(7)}} {
+		sequential, err := NewEncoder(enc, opts...)
+		require.NoError(t, err)
+		batch, err := NewEncoder(enc, opts...)
+		require.NoError(t, err)
+
+		var expected [][]byte
+		for _, frame := range frames {
+			dst, err := sequential.Encode(frame)
+			require.NoError(t, err)
+			expected = append(expected, dst)
+		}
+		expectedSeekTable, err := sequential.EndStream()
+		require.NoError(t, err)
+
+		// Split into several batches to check that the seek table is continued.
+		var actual [][]byte
+		for _, part := range [][][]byte{frames[:30], frames[30:31], frames[31:]} {
+			dsts, err := batch.EncodeBatch(context.Background(), part, 8)
+			require.NoError(t, err)
+			actual = append(actual, dsts...)
+		}
+		seekTable, err := batch.EndStream()
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, actual)
+		assert.Equal(t, expectedSeekTable, seekTable)
+	}
+
+	e, err := NewEncoder(enc)
+	require.NoError(t, err)
+	_, err = e.EncodeBatch(context.Background(), frames, 0)
+	require.ErrorContains(t, err, "concurrency must be positive")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = e.EncodeBatch(ctx, frames, 4)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, e.(*writerImpl).frameEntries)
+}
+
+func BenchmarkEncodeBatch(b *testing.B) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	frames := make([][]byte, 256)
+	var size int64
+	for i := range frames {
+		frames[i] = make([]byte, 64<<10)
+		_, _ = rng.Read(frames[i][:len(frames[i])/2])
+		size += int64(len(frames[i]))
+	}
+
+	for concurrency := 1; concurrency <= runtime.GOMAXPROCS(0); concurrency *= 2 {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			e, err := NewEncoder(enc)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := e.EncodeBatch(context.Background(), frames, concurrency); err != nil {
+					b.Fatal(err)
+				}
+				e.Reset()
+			}
+		})
+	}
+}
+
+func BenchmarkEncoderReset(b *testing.B) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	if err != nil {
+		b.Fatal(err)
+	}
+	src := []byte(sourceString)
+
+	encode := func(e Encoder) {
+		for i := 0; i < 16; i++ {
+			if _, err := e.Encode(src); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if _, err := e.EndStream(); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			e, err := NewEncoder(enc)
+			if err != nil {
+				b.Fatal(err)
+			}
+			encode(e)
+		}
+	})
+	b.Run("reset", func(b *testing.B) {
+		e, err := NewEncoder(enc)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			e.Reset()
+			encode(e)
+		}
+	})
+}
+
+func TestVarintSeekTable(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+
+	_, err = NewEncoder(enc, WithVarintSeekTable(), WithCompressSeekTable())
+	require.ErrorContains(t, err, "varint seek table can not be chunked, hierarchical or compressed")
+
+	for _, checksums := range []bool{true, false} {
+		b := NewSeekTableBuilder(checksums)
+		var entries []seekTableEntry
+		for _, e := range []seekTableEntry{
+			{CompressedSize: 10, DecompressedSize: 20, Checksum: 1},
+			{CompressedSize: 0, DecompressedSize: 0, Checksum: 2},
+			{CompressedSize: math.MaxUint32, DecompressedSize: math.MaxUint32, Checksum: 3},
+			{CompressedSize: 1, DecompressedSize: 5, Checksum: 4},
+		} {
+			if !checksums {
+				e.Checksum = 0
+			}
+			b.AddFrame(e.CompressedSize, e.DecompressedSize, e.Checksum)
+			entries = append(entries, e)
+		}
+		regular, err := b.Bytes()
+		require.NoError(t, err)
+
+		varint, err := marshalVarintSeekTable(entries, checksums)
+		require.NoError(t, err)
+		assert.Equal(t, varintFlagBit|map[bool]uint8{true: checksumFlagBit}[checksums], varint[len(varint)-5])
+
+		d, err := NewDecoder(varint, nil, WithSizeValidation())
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), d.NumFrames())
+		assert.Equal(t, int64(math.MaxUint32+25), d.Size())
+
+		// Serialized in the regular format.
+		m, err := d.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, regular, m)
+	}
+
+	// Stream.
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, enc, WithVarintSeekTable())
+	require.NoError(t, err)
+	for _, src := range []string{"test", "", "test2"} {
+		_, err = w.Write([]byte(src))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), nil, WithDefaultDecoder(), WithSizeValidation())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, sourceString, string(all))
+
+	empty, err := marshalVarintSeekTable(nil, true)
+	require.NoError(t, err)
+	d, err := NewDecoder(empty, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), d.NumFrames())
+
+	// Corruption.
+	table, err := marshalVarintSeekTable([]seekTableEntry{{CompressedSize: 10, DecompressedSize: 20}}, false)
+	require.NoError(t, err)
+	for name, tc := range map[string]struct {
+		corrupt func(buf []byte)
+		err     string
+	}{
+		"truncated varint": {
+			corrupt: func(buf []byte) { buf[9] = 0x80 },
+			err:     "failed to parse varint of entry 0",
+		},
+		"negative size": {
+			corrupt: func(buf []byte) { buf[8] = 0x01 },
+			err:     "size of entry 0 is out of range: -1",
+		},
+		"number of frames": {
+			corrupt: func(buf []byte) { buf[len(buf)-9] = 0 },
+			err:     "varint seek table has 2 trailing bytes",
+		},
+		"payload size": {
+			corrupt: func(buf []byte) { buf[len(buf)-13] = 1 },
+			err:     "skippable frame magic mismatch",
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := bytes.Clone(table)
+			tc.corrupt(buf)
+			_, err := NewDecoder(buf, nil)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func BenchmarkVarintSeekTable(b *testing.B) {
+	// Frames of a typical chunked archive.
+	rng := rand.New(rand.NewSource(0))
+	entries := make([]seekTableEntry, 100_000)
+	for i := range entries {
+		entries[i] = seekTableEntry{
+			CompressedSize:   uint32(20<<10 + rng.Intn(1<<10)),
+			DecompressedSize: 64 << 10,
+			Checksum:         rng.Uint32(),
+		}
+	}
+
+	for name, marshal := range map[string]func() ([]byte, error){
+		"fixed":  func() ([]byte, error) { return marshalSeekTable(entries, true) },
+		"varint": func() ([]byte, error) { return marshalVarintSeekTable(entries, true) },
+	} {
+		b.Run(name, func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				table, err := marshal()
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(table)
+			}
+			b.ReportMetric(float64(size), "table-bytes")
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package seekable
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/btree"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+const (
+	// encryptionKeySize is the size of the AES-256 key.
+	encryptionKeySize = 32
+	// encryptionSaltSize is the size of the random salt of the stream.
+	encryptionSaltSize = 16
+	// encryptionNonceSize is the size of the AES-GCM nonce.
+	encryptionNonceSize = 12
+	// encryptionOverhead is the size added by encryption: the prepended nonce and the GCM tag.
+	encryptionOverhead = encryptionNonceSize + 16
+)
+
+/*
+frameCipher encrypts frames and the seek table of a single stream with AES-256-GCM.
+
+Each frame is stored as:
+
+	|`Nonce`   |`Ciphertext`                  |
+	|----------|------------------------------|
+	| 12 bytes | compressed frame + 16 bytes  |
+
+where `Nonce` is HKDF-SHA256 (RFC 5869) of the key with the salt of the stream and the frame id
+(8 bytes, little-endian) as info.  Since frame ids are bound to nonces, reordered frames fail to decrypt.
+The random salt makes nonces unique across streams encrypted with the same key.
+*/
+type frameCipher struct {
+	aead cipher.AEAD
+	key  []byte
+	salt []byte
+	// prk is the HKDF pseudorandom key extracted from key and salt.
+	prk []byte
+}
+
+func newFrameCipher(key, salt []byte) (*frameCipher, error) {
+	if len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes: %d", encryptionKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	c := &frameCipher{aead: aead, key: key}
+	c.setSalt(salt)
+	return c, nil
+}
+
+// newEncryptionSalt returns a random salt for a new stream.
+func newEncryptionSalt() []byte {
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		// Reusing a salt would reuse nonces, which breaks GCM, so there is no safe fallback.
+		panic(fmt.Sprintf("failed to generate encryption salt: %v", err))
+	}
+	return salt
+}
+
+// setSalt switches the cipher to the stream with the given salt.
+func (c *frameCipher) setSalt(salt []byte) {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(c.key)
+	c.salt = salt
+	c.prk = mac.Sum(nil)
+}
+
+// frameNonce returns the first encryptionNonceSize bytes of HKDF-Expand(prk, id).
+func (c *frameCipher) frameNonce(id int64) []byte {
+	var info [8 + 1]byte
+	binary.LittleEndian.PutUint64(info[:], uint64(id))
+	info[8] = 1
+
+	mac := hmac.New(sha256.New, c.prk)
+	mac.Write(info[:])
+	return mac.Sum(nil)[:encryptionNonceSize]
+}
+
+// sealFrame encrypts the compressed frame with the given id.
+func (c *frameCipher) sealFrame(id int64, frame []byte) []byte {
+	nonce := c.frameNonce(id)
+	dst := make([]byte, 0, len(frame)+encryptionOverhead)
+	dst = append(dst, nonce...)
+	return c.aead.Seal(dst, nonce, frame, nil)
+}
+
+// openFrame decrypts the frame with the given id.
+func (c *frameCipher) openFrame(id int64, frame []byte) ([]byte, error) {
+	if len(frame) < encryptionOverhead {
+		return nil, fmt.Errorf("frame %d: encrypted frame is too small: %d", id, len(frame))
+	}
+	nonce := c.frameNonce(id)
+	if !hmac.Equal(frame[:encryptionNonceSize], nonce) {
+		return nil, fmt.Errorf("frame %d: nonce mismatch, the frame is out of place", id)
+	}
+	decrypted, err := c.aead.Open(nil, nonce, frame[encryptionNonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("frame %d: failed to decrypt: %w", id, err)
+	}
+	return decrypted, nil
+}
+
+/*
+marshalEncryptedSeekTable serializes entries into a seek table skippable frame (tagged with seekableTag)
+where `Seek_Table_Entries` are encrypted with the stream's cipher:
+
+	|`Skippable_Magic_Number`|`Frame_Size`|`Salt`  |`Nonce` |`Encrypted_Entries`|`Payload_Size`|`Seek_Table_Footer`|
+	|------------------------|------------|--------|--------|-------------------|--------------|-------------------|
+	| 4 bytes                | 4 bytes    |16 bytes|12 bytes| n + 16 bytes      | 4 bytes      | 9 bytes           |
+
+`Nonce` is random, `Salt` and `Seek_Table_Footer` are authenticated as additional data.
+`Payload_Size` is the size of `Salt`, `Nonce` and `Encrypted_Entries` and the `Encrypted_Flag` is set in the footer.
+*/
+func marshalEncryptedSeekTable(entries []seekTableEntry, c *frameCipher) ([]byte, error) {
+	if int64(len(entries)) > maxNumberOfFrames {
+		return nil, fmt.Errorf("number of frames for seekable format: %d > %d",
+			len(entries), maxNumberOfFrames)
+	}
+
+	const entrySize = 12
+	raw := make([]byte, len(entries)*entrySize)
+	for i, e := range entries {
+		e.marshalBinaryInline(raw[i*entrySize : (i+1)*entrySize])
+	}
+
+	footer := seekTableFooter{
+		NumberOfFrames: uint32(len(entries)),
+		SeekTableDescriptor: seekTableDescriptor{
+			ChecksumFlag:  true,
+			EncryptedFlag: true,
+		},
+		SeekableMagicNumber: seekableMagicNumber,
+	}
+	footerBytes, err := footer.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, 0, encryptionSaltSize+encryptionOverhead+len(raw)+payloadSeekTableTrailerSize)
+	payload = append(payload, c.salt...)
+	nonce := make([]byte, encryptionNonceSize)
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	payload = append(payload, nonce...)
+	payload = c.aead.Seal(payload, nonce, raw, append(c.salt[:len(c.salt):len(c.salt)], footerBytes...))
+	if int64(len(payload)) > maxChunkSize {
+		return nil, fmt.Errorf("encrypted seek table is too big: %d > %d", len(payload), maxChunkSize)
+	}
+
+	payload = binary.LittleEndian.AppendUint32(payload, uint32(len(payload)))
+	payload = append(payload, footerBytes...)
+	return createSkippableFrame(seekableTag, payload)
+}
+
+func (r *readerImpl) indexEncryptedSeekTable(footer *seekTableFooter, entrySize int64) (
+	*btree.BTreeG[*env.FrameOffsetEntry], *env.FrameOffsetEntry, error,
+) {
+	if r.encryptionKey == nil {
+		return nil, nil, fmt.Errorf("seek table is encrypted, see WithREncryption")
+	}
+
+	size := int64(footer.NumberOfFrames) * entrySize
+	if size > maxDecoderFrameSize {
+		return nil, nil, fmt.Errorf("seek table is too big: %d > %d", size, maxDecoderFrameSize)
+	}
+
+	payload, err := r.readSeekTablePayload()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read encrypted seek table: %w", err)
+	}
+	if len(payload) < encryptionSaltSize+encryptionOverhead {
+		return nil, nil, fmt.Errorf("encrypted seek table is too small: %d", len(payload))
+	}
+
+	salt := append([]byte(nil), payload[:encryptionSaltSize]...)
+	c, err := newFrameCipher(r.encryptionKey, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	footerBytes, err := footer.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := payload[encryptionSaltSize : encryptionSaltSize+encryptionNonceSize]
+	p, err := c.aead.Open(nil, nonce, payload[encryptionSaltSize+encryptionNonceSize:],
+		append(salt, footerBytes...))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt seek table, the key may be wrong: %w", err)
+	}
+	if int64(len(p)) != size {
+		return nil, nil, fmt.Errorf("decrypted seek table size mismatch: expected: %d, actual: %d", size, len(p))
+	}
+	r.cipher = c
+	return r.indexSeekTableEntries(p, uint64(entrySize))
+}
@@ -0,0 +1,138 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryption(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	key := bytes.Repeat([]byte{0x42}, encryptionKeySize)
+	frames := []string{strings.Repeat("secret", 100), "", strings.Repeat("plaintext", 50)}
+	original := strings.Join(frames, "")
+
+	write := func() []byte {
+		var b bytes.Buffer
+		w, err := NewWriter(&b, enc, WithWEncryption(key))
+		require.NoError(t, err)
+		for _, frame := range frames {
+			_, err = w.Write([]byte(frame))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		return b.Bytes()
+	}
+	encrypted := write()
+
+	// Neither the data, nor the ZSTD frames, nor the seek table entries are visible.
+	for _, s := range []string{"secret", "plaintext"} {
+		assert.NotContains(t, string(encrypted), s)
+	}
+	assert.NotContains(t, string(encrypted), string(enc.EncodeAll([]byte(frames[0]), nil)[:4]))
+	plain, err := NewEncoder(enc)
+	require.NoError(t, err)
+	for _, frame := range frames {
+		_, err = plain.Encode([]byte(frame))
+		require.NoError(t, err)
+	}
+	plainSeekTable, err := plain.EndStream()
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), string(plainSeekTable[8:8+12]))
+
+	// Streams use different salts, so encrypting the same data twice gives different results.
+	assert.NotEqual(t, encrypted, write())
+
+	read := func(stream []byte, opts ...rOption) ([]byte, error) {
+		r, err := NewReader(bytes.NewReader(stream), dec, opts...)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { require.NoError(t, r.Close()) }()
+		return io.ReadAll(r)
+	}
+
+	all, err := read(encrypted, WithREncryption(key), WithSizeValidation())
+	require.NoError(t, err)
+	assert.Equal(t, original, string(all))
+
+	wrongKey := bytes.Repeat([]byte{0x43}, encryptionKeySize)
+	_, err = read(encrypted, WithREncryption(wrongKey))
+	assert.ErrorContains(t, err, "failed to decrypt seek table")
+
+	_, err = read(encrypted)
+	assert.ErrorContains(t, err, "seek table is encrypted")
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(original))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, err = read(b.Bytes(), WithREncryption(key))
+	assert.ErrorContains(t, err, "stream is not encrypted")
+
+	// Tampered frame.
+	tampered := bytes.Clone(encrypted)
+	tampered[encryptionNonceSize+1] ^= 1
+	_, err = read(tampered, WithREncryption(key))
+	assert.ErrorContains(t, err, "frame 0: failed to decrypt")
+
+	// Tampered seek table.
+	tampered = bytes.Clone(encrypted)
+	tampered[len(tampered)-seekTableFooterOffset-payloadSizeFieldSize-1] ^= 1
+	_, err = read(tampered, WithREncryption(key))
+	assert.ErrorContains(t, err, "failed to decrypt seek table")
+
+	_, err = NewWriter(&b, enc, WithWEncryption(key[1:]))
+	assert.ErrorContains(t, err, "encryption key must be 32 bytes")
+	_, err = NewWriter(&b, enc, WithWEncryption(key), WithCompressSeekTable())
+	assert.ErrorContains(t, err, "encrypted seek table can not be")
+	_, err = NewReader(bytes.NewReader(encrypted), dec, WithREncryption(nil))
+	assert.ErrorContains(t, err, "encryption key must be 32 bytes")
+}
+
+func TestEncryptionFrameOrder(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	key := bytes.Repeat([]byte{0x42}, encryptionKeySize)
+	e, err := NewEncoder(enc, WithWEncryption(key))
+	require.NoError(t, err)
+	// Frames of the same size, so that they can be swapped without breaking the seek table.
+	frames, err := e.EncodeBatch(context.Background(), [][]byte{[]byte("test1"), []byte("test2")}, 2)
+	require.NoError(t, err)
+	require.Len(t, frames[0], len(frames[1]))
+	seekTable, err := e.EndStream()
+	require.NoError(t, err)
+
+	d, err := NewDecoder(seekTable, dec, WithREncryption(key))
+	require.NoError(t, err)
+
+	stream := bytes.Join([][]byte{frames[0], frames[1], seekTable}, nil)
+	data, err := d.DecompressRange(NewReadSeekerEnv(bytes.NewReader(stream)), 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "test1test2", string(data))
+
+	swapped := bytes.Join([][]byte{frames[1], frames[0], seekTable}, nil)
+	_, err = d.DecompressRange(NewReadSeekerEnv(bytes.NewReader(swapped)), 0, 10)
+	assert.ErrorContains(t, err, "frame 0: nonce mismatch")
+}
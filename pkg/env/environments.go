@@ -20,3 +20,10 @@ type REnvironment interface {
 	// including the `Skippable_Magic_Number` and `Frame_Size`.
 	ReadSkipFrame(skippableFrameOffset int64) ([]byte, error)
 }
+
+// BulkFrameFetcher can be optionally implemented by REnvironment to fetch multiple frames at once,
+// e.g. concurrently or with a single multi-range request.  It is used by Reader's BulkReadAt.
+type BulkFrameFetcher interface {
+	// GetFramesByIndex returns compressed frames by their indexes, in the same order.
+	GetFramesByIndex(indexes []FrameOffsetEntry) ([][]byte, error)
+}
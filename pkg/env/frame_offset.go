@@ -1,6 +1,8 @@
 package env
 
 import (
+	"cmp"
+
 	"go.uber.org/zap/zapcore"
 )
 
@@ -33,6 +35,33 @@ func (o *FrameOffsetEntry) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	return nil
 }
 
+// Less orders entries by DecompOffset and then by ID, consistently with FrameCompare.
+// The secondary key keeps empty frames (that share DecompOffset with the next frame)
+// from replacing each other in the btree.
 func Less(a, b *FrameOffsetEntry) bool {
-	return a.DecompOffset < b.DecompOffset
+	if a.DecompOffset != b.DecompOffset {
+		return a.DecompOffset < b.DecompOffset
+	}
+	return a.ID < b.ID
+}
+
+// CompLess orders entries by CompOffset and then by ID.  It is used for
+// the secondary index of frames by their offsets in the compressed stream.
+// The secondary key keeps empty frames (that share CompOffset with the next frame)
+// from replacing each other in the btree.
+func CompLess(a, b *FrameOffsetEntry) bool {
+	if a.CompOffset != b.CompOffset {
+		return a.CompOffset < b.CompOffset
+	}
+	return a.ID < b.ID
+}
+
+// FrameCompare returns -1, 0 or +1 depending on whether a is ordered before, same as or after b.
+// Entries are ordered by DecompOffset, ties are broken by ID.
+// It is suitable for slices.SortFunc and other standard library algorithms.
+func FrameCompare(a, b FrameOffsetEntry) int {
+	if c := cmp.Compare(a.DecompOffset, b.DecompOffset); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
 }
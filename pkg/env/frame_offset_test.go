@@ -0,0 +1,48 @@
+package env
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameCompare(t *testing.T) {
+	t.Parallel()
+
+	a := FrameOffsetEntry{ID: 0, DecompOffset: 0, DecompSize: 4}
+	empty := FrameOffsetEntry{ID: 1, DecompOffset: 4}
+	b := FrameOffsetEntry{ID: 2, DecompOffset: 4, DecompSize: 5}
+
+	assert.Equal(t, 0, FrameCompare(a, a))
+	assert.Equal(t, -1, FrameCompare(a, b))
+	assert.Equal(t, 1, FrameCompare(b, a))
+	// Ties are broken by ID.
+	assert.Equal(t, -1, FrameCompare(empty, b))
+	assert.Equal(t, 1, FrameCompare(b, empty))
+
+	entries := []FrameOffsetEntry{b, empty, a}
+	slices.SortFunc(entries, FrameCompare)
+	assert.Equal(t, []FrameOffsetEntry{a, empty, b}, entries)
+
+	for _, x := range entries {
+		for _, y := range entries {
+			assert.Equal(t, FrameCompare(x, y) < 0, Less(&x, &y))
+		}
+	}
+}
+
+func TestCompLess(t *testing.T) {
+	t.Parallel()
+
+	a := FrameOffsetEntry{ID: 0, CompOffset: 0, CompSize: 10, DecompOffset: 10}
+	empty := FrameOffsetEntry{ID: 1, CompOffset: 10}
+	b := FrameOffsetEntry{ID: 2, CompOffset: 10, CompSize: 10, DecompOffset: 0}
+
+	assert.False(t, CompLess(&a, &a))
+	assert.True(t, CompLess(&a, &b))
+	assert.False(t, CompLess(&b, &a))
+	// Ties are broken by ID.
+	assert.True(t, CompLess(&empty, &b))
+	assert.False(t, CompLess(&b, &empty))
+}
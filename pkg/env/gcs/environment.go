@@ -0,0 +1,100 @@
+// Package gcs implements env.REnvironment on top of Google Cloud Storage range reads,
+// so that seekable streams stored in GCS can be randomly accessed without downloading them entirely.
+package gcs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// seekTableFooterSize is the size of the `Seek_Table_Footer`.
+const seekTableFooterSize = 9
+
+// GCSREnvironment reads frames and the seek table of a seekable stream stored in GCS.
+// Each read issues a single ranged request via ObjectHandle.NewRangeReader.
+// The object size is fetched once with ObjectHandle.Attrs and cached.
+// It is goroutine-safe.
+type GCSREnvironment struct {
+	obj *storage.ObjectHandle
+
+	mu   sync.Mutex
+	size int64
+}
+
+var _ env.REnvironment = (*GCSREnvironment)(nil)
+
+// NewGCSREnvironment returns the environment that reads the object referenced by obj,
+// e.g. client.Bucket(bucket).Object(name).
+func NewGCSREnvironment(obj *storage.ObjectHandle) *GCSREnvironment {
+	return &GCSREnvironment{obj: obj, size: -1}
+}
+
+func (e *GCSREnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	if index.CompSize == 0 {
+		return []byte{}, nil
+	}
+	return e.get(int64(index.CompOffset), int64(index.CompSize))
+}
+
+func (e *GCSREnvironment) ReadFooter() ([]byte, error) {
+	return e.tail(seekTableFooterSize)
+}
+
+func (e *GCSREnvironment) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
+	if skippableFrameOffset <= 0 {
+		return nil, fmt.Errorf("invalid skippable frame offset: %d", skippableFrameOffset)
+	}
+	return e.tail(skippableFrameOffset)
+}
+
+// objectSize returns the size of the object, fetching its attributes on the first call.
+func (e *GCSREnvironment) objectSize() (int64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.size >= 0 {
+		return e.size, nil
+	}
+	attrs, err := e.obj.Attrs(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get attributes of gs://%s/%s: %w", e.obj.BucketName(), e.obj.ObjectName(), err)
+	}
+	e.size = attrs.Size
+	return e.size, nil
+}
+
+// tail fetches the last size bytes of the object.
+func (e *GCSREnvironment) tail(size int64) ([]byte, error) {
+	objectSize, err := e.objectSize()
+	if err != nil {
+		return nil, err
+	}
+	if size > objectSize {
+		return nil, fmt.Errorf("object gs://%s/%s is too small: %d < %d",
+			e.obj.BucketName(), e.obj.ObjectName(), objectSize, size)
+	}
+	return e.get(objectSize-size, size)
+}
+
+// get fetches exactly length bytes starting at offset.
+func (e *GCSREnvironment) get(offset, length int64) ([]byte, error) {
+	r, err := e.obj.NewRangeReader(context.Background(), offset, length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get range [%d, %d) of gs://%s/%s: %w",
+			offset, offset+length, e.obj.BucketName(), e.obj.ObjectName(), err)
+	}
+	defer r.Close()
+
+	buf := make([]byte, length)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("failed to read range [%d, %d) of gs://%s/%s: %w",
+			offset, offset+length, e.obj.BucketName(), e.obj.ObjectName(), err)
+	}
+	return buf, nil
+}
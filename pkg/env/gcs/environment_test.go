@@ -0,0 +1,153 @@
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// fakeGCS serves metadata and byte ranges of a static object over the GCS JSON and XML APIs
+// and records requests.
+type fakeGCS struct {
+	data []byte
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Attrs uses the JSON API, range reads use the XML API.
+	if strings.Contains(r.URL.Path, "/o/") {
+		f.record("attrs")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"bucket":     "bucket",
+			"name":       "key",
+			"size":       fmt.Sprint(len(f.data)),
+			"generation": "1",
+		})
+		return
+	}
+
+	rng := r.Header.Get("Range")
+	f.record(rng)
+	var start, end int64
+	if _, err := fmt.Sscanf(rng, "bytes=%d-%d", &start, &end); err != nil {
+		// Full-object reads are served too, so that tests can assert their absence.
+		start, end = 0, int64(len(f.data))-1
+	} else if start > end || end >= int64(len(f.data)) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(f.data)))
+	w.Header().Set("Content-Length", fmt.Sprint(end-start+1))
+	w.Header().Set("X-Goog-Generation", "1")
+	w.WriteHeader(http.StatusPartialContent)
+	_, _ = w.Write(f.data[start : end+1])
+}
+
+func (f *fakeGCS) record(r string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r)
+}
+
+func newTestObject(t *testing.T, f *fakeGCS) *storage.ObjectHandle {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	client, err := storage.NewClient(context.Background(),
+		option.WithEndpoint(srv.URL), option.WithoutAuthentication(), option.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client.Bucket("bucket").Object("key")
+}
+
+func writeTestStream(t *testing.T, frames ...string) []byte {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc)
+	require.NoError(t, err)
+	for _, s := range frames {
+		_, err = w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func TestGCSREnvironment(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	f := &fakeGCS{data: writeTestStream(t, "test", "test2", "test3")}
+	e := NewGCSREnvironment(newTestObject(t, f))
+	r, err := seekable.NewReader(nil, dec, seekable.WithREnvironment(e))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	size := int64(len(f.data))
+	d := r.(seekable.Decoder)
+	require.Equal(t, int64(3), d.NumFrames())
+	last := d.GetIndexByID(2)
+	seekTableOffset := int64(last.CompOffset + uint64(last.CompSize))
+	// Attributes are fetched only once.
+	expected := []string{
+		"attrs",
+		fmt.Sprintf("bytes=%d-%d", size-9, size-1),
+		fmt.Sprintf("bytes=%d-%d", seekTableOffset, size-1),
+	}
+	assert.Equal(t, expected, f.requests)
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testtest2test3"), all)
+
+	// Every frame is fetched by its own range, the whole object is never read.
+	for id := int64(0); id < 3; id++ {
+		index := d.GetIndexByID(id)
+		expected = append(expected, fmt.Sprintf("bytes=%d-%d", index.CompOffset, index.CompOffset+uint64(index.CompSize)-1))
+	}
+	assert.Equal(t, expected, f.requests)
+}
+
+func TestGCSREnvironmentErrors(t *testing.T) {
+	t.Parallel()
+
+	e := NewGCSREnvironment(newTestObject(t, &fakeGCS{data: []byte("short")}))
+	_, err := e.ReadFooter()
+	require.ErrorContains(t, err, "object gs://bucket/key is too small: 5 < 9")
+
+	_, err = e.GetFrameByIndex(env.FrameOffsetEntry{CompOffset: 3, CompSize: 10})
+	require.ErrorContains(t, err, "range [3, 13) of gs://bucket/key")
+
+	_, err = e.ReadSkipFrame(0)
+	require.ErrorContains(t, err, "invalid skippable frame offset")
+
+	// Empty frames are not requested.
+	p, err := e.GetFrameByIndex(env.FrameOffsetEntry{})
+	require.NoError(t, err)
+	assert.Empty(t, p)
+}
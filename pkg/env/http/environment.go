@@ -0,0 +1,79 @@
+// Package http implements env.REnvironment on top of HTTP Range requests,
+// so that seekable streams can be read from HTTP servers without downloading them entirely.
+package http
+
+import (
+	"fmt"
+	"io"
+	nethttp "net/http"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// seekTableFooterSize is the size of the `Seek_Table_Footer`.
+const seekTableFooterSize = 9
+
+// HTTPREnvironment reads frames and the seek table of a seekable stream served over HTTP.
+// Each call issues a single `Range` request, so the server must support range requests.
+// It is goroutine-safe as long as the passed *http.Client is.
+type HTTPREnvironment struct {
+	client *nethttp.Client
+	url    string
+}
+
+var _ env.REnvironment = (*HTTPREnvironment)(nil)
+
+// NewHTTPREnvironment returns the environment that reads the stream at url using client.
+// If client is nil, http.DefaultClient is used.
+func NewHTTPREnvironment(client *nethttp.Client, url string) *HTTPREnvironment {
+	if client == nil {
+		client = nethttp.DefaultClient
+	}
+	return &HTTPREnvironment{client: client, url: url}
+}
+
+func (e *HTTPREnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	if index.CompSize == 0 {
+		return []byte{}, nil
+	}
+	return e.get(fmt.Sprintf("bytes=%d-%d", index.CompOffset, index.CompOffset+uint64(index.CompSize)-1),
+		int64(index.CompSize))
+}
+
+func (e *HTTPREnvironment) ReadFooter() ([]byte, error) {
+	return e.get(fmt.Sprintf("bytes=-%d", seekTableFooterSize), seekTableFooterSize)
+}
+
+func (e *HTTPREnvironment) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
+	if skippableFrameOffset <= 0 {
+		return nil, fmt.Errorf("invalid skippable frame offset: %d", skippableFrameOffset)
+	}
+	return e.get(fmt.Sprintf("bytes=-%d", skippableFrameOffset), skippableFrameOffset)
+}
+
+// get fetches exactly size bytes of the given range.
+func (e *HTTPREnvironment) get(byteRange string, size int64) ([]byte, error) {
+	req, err := nethttp.NewRequest(nethttp.MethodGet, e.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Range", byteRange)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request range %q: %w", byteRange, err)
+	}
+	defer resp.Body.Close()
+
+	// Anything else, e.g. 200 OK, means that the server ignored the range
+	// and would send the whole stream.
+	if resp.StatusCode != nethttp.StatusPartialContent {
+		return nil, fmt.Errorf("unexpected status for range %q: %s", byteRange, resp.Status)
+	}
+
+	buf := make([]byte, size)
+	if _, err = io.ReadFull(resp.Body, buf); err != nil {
+		return nil, fmt.Errorf("failed to read range %q: %w", byteRange, err)
+	}
+	return buf, nil
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// rangeRecorder serves a static stream and records requested ranges.
+type rangeRecorder struct {
+	data []byte
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (s *rangeRecorder) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	s.mu.Lock()
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	s.mu.Unlock()
+	nethttp.ServeContent(w, r, "", time.Time{}, bytes.NewReader(s.data))
+}
+
+func TestHTTPREnvironment(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc)
+	require.NoError(t, err)
+	for _, s := range []string{"test", "test2", "test3"} {
+		_, err = w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	rec := &rangeRecorder{data: b.Bytes()}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	e := NewHTTPREnvironment(srv.Client(), srv.URL)
+	r, err := seekable.NewReader(nil, dec, seekable.WithREnvironment(e), seekable.WithCacheSize(3))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	d := r.(seekable.Decoder)
+	require.Equal(t, int64(3), d.NumFrames())
+	seekTableSize := int64(b.Len()) - int64(d.GetIndexByID(2).CompOffset+uint64(d.GetIndexByID(2).CompSize))
+	assert.Equal(t, []string{"bytes=-9", fmt.Sprintf("bytes=-%d", seekTableSize)}, rec.ranges)
+
+	tmp := make([]byte, 5)
+	_, err = r.ReadAt(tmp, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("test2"), tmp)
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testtest2test3"), all)
+
+	// Every frame is fetched by its own range, the stream is never downloaded entirely.
+	expected := []string{"bytes=-9", fmt.Sprintf("bytes=-%d", seekTableSize)}
+	for _, id := range []int64{1, 0, 2} {
+		index := d.GetIndexByID(id)
+		expected = append(expected, fmt.Sprintf("bytes=%d-%d", index.CompOffset, index.CompOffset+uint64(index.CompSize)-1))
+	}
+	assert.Equal(t, expected, rec.ranges)
+}
+
+func TestHTTPREnvironmentErrors(t *testing.T) {
+	t.Parallel()
+
+	// Server that ignores ranges.
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		_, _ = w.Write(make([]byte, 100))
+	}))
+	defer srv.Close()
+
+	e := NewHTTPREnvironment(nil, srv.URL)
+	_, err := e.ReadFooter()
+	require.ErrorContains(t, err, "unexpected status for range \"bytes=-9\": 200 OK")
+
+	srv = httptest.NewServer(nethttp.NotFoundHandler())
+	defer srv.Close()
+
+	e = NewHTTPREnvironment(srv.Client(), srv.URL)
+	_, err = e.ReadSkipFrame(17)
+	require.ErrorContains(t, err, "404 Not Found")
+	_, err = e.ReadSkipFrame(0)
+	require.ErrorContains(t, err, "invalid skippable frame offset")
+
+	// Empty frames are not requested.
+	p, err := e.GetFrameByIndex(env.FrameOffsetEntry{})
+	require.NoError(t, err)
+	assert.Empty(t, p)
+
+	// Short responses.
+	srv = httptest.NewServer(&rangeRecorder{data: []byte("short")})
+	defer srv.Close()
+
+	e = NewHTTPREnvironment(srv.Client(), srv.URL)
+	_, err = e.ReadFooter()
+	require.ErrorContains(t, err, "failed to read range")
+
+	_, err = NewHTTPREnvironment(nil, "http://[::1]:namedport").ReadFooter()
+	require.ErrorContains(t, err, "failed to create request")
+}
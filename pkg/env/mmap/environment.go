@@ -0,0 +1,100 @@
+// Package mmap implements env.REnvironment on top of a memory-mapped file,
+// so that frames of local seekable streams are read without syscalls and copying.
+package mmap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// seekTableFooterSize is the size of the `Seek_Table_Footer`.
+const seekTableFooterSize = 9
+
+// MmapREnvironment reads frames and the seek table of a seekable stream from a memory-mapped file.
+// Returned buffers point directly into the mapping: they must not be modified
+// and are only valid until Close.
+//
+// It is goroutine-safe, except for Close, which must not be called concurrently with reads.
+type MmapREnvironment struct {
+	data []byte
+	// mapping holds platform-specific state needed to unmap data.
+	mapping mapping
+	closed  bool
+}
+
+var _ env.REnvironment = (*MmapREnvironment)(nil)
+
+// NewMmapREnvironment maps the whole file f into memory.  The mapping is read-only and
+// stays valid after f is closed, so f can be closed right away.  The caller must Close
+// the environment once the reader is no longer used.
+func NewMmapREnvironment(f *os.File) (*MmapREnvironment, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	size := fi.Size()
+	if size < 0 || int64(int(size)) != size {
+		return nil, fmt.Errorf("file is too big to be mapped: %d", size)
+	}
+
+	e := &MmapREnvironment{}
+	if size == 0 {
+		// Empty files can not be mapped.
+		return e, nil
+	}
+	if e.data, e.mapping, err = mmap(f, int(size)); err != nil {
+		return nil, fmt.Errorf("failed to map file: %w", err)
+	}
+	return e, nil
+}
+
+// Close unmaps the file.  Buffers returned by the environment must not be used afterwards.
+func (e *MmapREnvironment) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	data := e.data
+	e.data = nil
+	if data == nil {
+		return nil
+	}
+	return munmap(data, e.mapping)
+}
+
+func (e *MmapREnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	end := index.CompOffset + uint64(index.CompSize)
+	if end < index.CompOffset || end > uint64(len(e.data)) {
+		return nil, e.outOfBounds(fmt.Errorf("frame %d is out of bounds: [%d, %d), size: %d",
+			index.ID, index.CompOffset, end, len(e.data)))
+	}
+	return e.data[index.CompOffset:end:end], nil
+}
+
+func (e *MmapREnvironment) ReadFooter() ([]byte, error) {
+	return e.tail(seekTableFooterSize)
+}
+
+func (e *MmapREnvironment) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
+	return e.tail(skippableFrameOffset)
+}
+
+// tail returns the last n bytes of the file.
+func (e *MmapREnvironment) tail(n int64) ([]byte, error) {
+	if n < 0 || n > int64(len(e.data)) {
+		return nil, e.outOfBounds(fmt.Errorf("offset from the end is out of bounds: %d, size: %d", n, len(e.data)))
+	}
+	return e.data[int64(len(e.data))-n:], nil
+}
+
+// outOfBounds reports reads after Close as such instead of the out of bounds error.
+func (e *MmapREnvironment) outOfBounds(err error) error {
+	if e.closed {
+		return errors.New("environment is closed")
+	}
+	return err
+}
@@ -0,0 +1,151 @@
+package mmap
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// writeStream writes a seekable stream of numFrames frames of frameSize bytes to a temporary file.
+func writeStream(tb testing.TB, numFrames, frameSize int) (string, []byte) {
+	tb.Helper()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(tb, err)
+
+	rng := rand.New(rand.NewSource(1))
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc)
+	require.NoError(tb, err)
+	var data []byte
+	for i := 0; i < numFrames; i++ {
+		frame := make([]byte, frameSize)
+		_, _ = rng.Read(frame[:frameSize/4])
+		_, err = w.Write(frame)
+		require.NoError(tb, err)
+		data = append(data, frame...)
+	}
+	require.NoError(tb, w.Close())
+
+	path := filepath.Join(tb.TempDir(), "stream.zst")
+	require.NoError(tb, os.WriteFile(path, b.Bytes(), 0o600))
+	return path, data
+}
+
+func openEnv(tb testing.TB, path string) *MmapREnvironment {
+	tb.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(tb, err)
+	defer f.Close()
+
+	e, err := NewMmapREnvironment(f)
+	require.NoError(tb, err)
+	return e
+}
+
+func TestMmapREnvironment(t *testing.T) {
+	t.Parallel()
+
+	path, expected := writeStream(t, 10, 1000)
+	e := openEnv(t, path)
+
+	r, err := seekable.NewReader(nil, nil, seekable.WithREnvironment(e), seekable.WithDefaultDecoder(),
+		seekable.WithSizeValidation())
+	require.NoError(t, err)
+	actual, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	index := r.(seekable.Decoder).GetIndexByID(3)
+	require.NotNil(t, index)
+	frame, err := e.GetFrameByIndex(*index)
+	require.NoError(t, err)
+	stream, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, stream[index.CompOffset:index.CompOffset+uint64(index.CompSize)], frame)
+	// Appending to the frame does not write to the mapping.
+	assert.Equal(t, len(frame), cap(frame))
+	require.NoError(t, r.Close())
+
+	footer, err := e.ReadFooter()
+	require.NoError(t, err)
+	assert.Equal(t, stream[len(stream)-seekTableFooterSize:], footer)
+
+	_, err = e.GetFrameByIndex(env.FrameOffsetEntry{CompOffset: uint64(len(stream)) - 1, CompSize: 2})
+	require.ErrorContains(t, err, "out of bounds")
+	_, err = e.ReadSkipFrame(int64(len(stream)) + 1)
+	require.ErrorContains(t, err, "out of bounds")
+
+	require.NoError(t, e.Close())
+	require.NoError(t, e.Close())
+	_, err = e.ReadFooter()
+	require.ErrorContains(t, err, "environment is closed")
+	_, err = e.GetFrameByIndex(*index)
+	require.ErrorContains(t, err, "environment is closed")
+}
+
+func TestMmapREnvironmentEmpty(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "empty.zst")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	e := openEnv(t, path)
+	defer func() { require.NoError(t, e.Close()) }()
+
+	_, err := e.ReadFooter()
+	require.ErrorContains(t, err, "out of bounds")
+	_, err = seekable.NewReader(nil, nil, seekable.WithREnvironment(e))
+	require.Error(t, err)
+}
+
+func BenchmarkMmapREnvironment(b *testing.B) {
+	const numFrames, frameSize = 50, 64 << 10
+	path, _ := writeStream(b, numFrames, frameSize)
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(b, err)
+	defer dec.Close()
+
+	read := func(b *testing.B, e env.REnvironment) {
+		// New reader has the frame cache cold.
+		r, err := seekable.NewReader(nil, dec, seekable.WithREnvironment(e))
+		require.NoError(b, err)
+		n, err := io.Copy(io.Discard, r)
+		require.NoError(b, err)
+		require.Equal(b, int64(numFrames*frameSize), n)
+		require.NoError(b, r.Close())
+	}
+
+	b.Run("seeker", func(b *testing.B) {
+		f, err := os.Open(path)
+		require.NoError(b, err)
+		defer f.Close()
+
+		b.SetBytes(numFrames * frameSize)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			read(b, seekable.NewReadSeekerEnv(f))
+		}
+	})
+	b.Run("mmap", func(b *testing.B) {
+		e := openEnv(b, path)
+		defer e.Close()
+
+		b.SetBytes(numFrames * frameSize)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			read(b, e)
+		}
+	})
+}
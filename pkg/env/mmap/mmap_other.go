@@ -0,0 +1,21 @@
+//go:build !unix && !windows
+
+package mmap
+
+import (
+	"io"
+	"os"
+)
+
+type mapping struct{}
+
+// mmap falls back to reading the whole file into memory on platforms without mmap.
+func mmap(f *os.File, size int) ([]byte, mapping, error) {
+	data := make([]byte, size)
+	_, err := io.ReadFull(io.NewSectionReader(f, 0, int64(size)), data)
+	return data, mapping{}, err
+}
+
+func munmap([]byte, mapping) error {
+	return nil
+}
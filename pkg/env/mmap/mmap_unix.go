@@ -0,0 +1,20 @@
+//go:build unix
+
+package mmap
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+type mapping struct{}
+
+func mmap(f *os.File, size int) ([]byte, mapping, error) {
+	data, err := unix.Mmap(int(f.Fd()), 0, size, unix.PROT_READ, unix.MAP_SHARED)
+	return data, mapping{}, err
+}
+
+func munmap(data []byte, _ mapping) error {
+	return unix.Munmap(data)
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package mmap
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// mapping is the handle of the file mapping object and the address of its view.
+type mapping struct {
+	handle windows.Handle
+	addr   uintptr
+}
+
+// sliceHeader is the runtime representation of a slice, used to wrap the view
+// that lives outside of the Go heap.
+type sliceHeader struct {
+	data uintptr
+	len  int
+	cap  int
+}
+
+func mmap(f *os.File, size int) ([]byte, mapping, error) {
+	h, err := windows.CreateFileMapping(windows.Handle(f.Fd()), nil, windows.PAGE_READONLY,
+		uint32(uint64(size)>>32), uint32(size), nil)
+	if err != nil {
+		return nil, mapping{}, fmt.Errorf("CreateFileMapping: %w", err)
+	}
+
+	addr, err := windows.MapViewOfFile(h, windows.FILE_MAP_READ, 0, 0, uintptr(size))
+	if err != nil {
+		_ = windows.CloseHandle(h)
+		return nil, mapping{}, fmt.Errorf("MapViewOfFile: %w", err)
+	}
+	data := *(*[]byte)(unsafe.Pointer(&sliceHeader{data: addr, len: size, cap: size}))
+	return data, mapping{handle: h, addr: addr}, nil
+}
+
+func munmap(_ []byte, m mapping) error {
+	if err := windows.UnmapViewOfFile(m.addr); err != nil {
+		return fmt.Errorf("UnmapViewOfFile: %w", err)
+	}
+	return windows.CloseHandle(m.handle)
+}
@@ -0,0 +1,84 @@
+// Package retry implements env.REnvironment that retries reads of the wrapped environment
+// with exponential backoff, so that transient errors of network environments,
+// e.g. connection resets or 503s, are not returned to the reader.
+package retry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// RetryREnvironment retries failed calls of the wrapped environment.
+// The delay before the n-th retry is baseDelay*2^(n-1) capped at maxDelay and passed through jitter, if set.
+// It is goroutine-safe as long as the wrapped environment is.
+type RetryREnvironment struct {
+	// IsRetryable reports whether the error is transient and the call should be retried.
+	// If nil, all errors are retried.
+	IsRetryable func(error) bool
+
+	env         env.REnvironment
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	jitter      func(time.Duration) time.Duration
+
+	// sleep is overridden in tests.
+	sleep func(time.Duration)
+}
+
+var _ env.REnvironment = (*RetryREnvironment)(nil)
+
+// NewRetryREnvironment returns the environment that calls e up to maxAttempts times (at least once).
+// jitter, if not nil, returns the actual delay for the computed one, e.g. a random duration in [d/2, d).
+func NewRetryREnvironment(e env.REnvironment, maxAttempts int, baseDelay, maxDelay time.Duration,
+	jitter func(time.Duration) time.Duration,
+) *RetryREnvironment {
+	return &RetryREnvironment{
+		env:         e,
+		maxAttempts: max(maxAttempts, 1),
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		jitter:      jitter,
+		sleep:       time.Sleep,
+	}
+}
+
+func (e *RetryREnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	return e.retry(func() ([]byte, error) { return e.env.GetFrameByIndex(index) })
+}
+
+func (e *RetryREnvironment) ReadFooter() ([]byte, error) {
+	return e.retry(e.env.ReadFooter)
+}
+
+func (e *RetryREnvironment) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
+	return e.retry(func() ([]byte, error) { return e.env.ReadSkipFrame(skippableFrameOffset) })
+}
+
+// retry calls fn until it succeeds, fails with a non-retryable error or runs out of attempts.
+func (e *RetryREnvironment) retry(fn func() ([]byte, error)) ([]byte, error) {
+	delay := e.baseDelay
+	for attempt := 1; ; attempt++ {
+		p, err := fn()
+		if err == nil {
+			return p, nil
+		}
+		if e.IsRetryable != nil && !e.IsRetryable(err) {
+			return nil, err
+		}
+		if attempt >= e.maxAttempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		d := min(delay, e.maxDelay)
+		if e.jitter != nil {
+			d = e.jitter(d)
+		}
+		e.sleep(d)
+		if delay < e.maxDelay {
+			delay *= 2
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+var (
+	errTransient = errors.New("transient")
+	errFatal     = errors.New("fatal")
+)
+
+// flakyEnvironment fails the first failures calls of each method with err.
+type flakyEnvironment struct {
+	failures int
+	err      error
+
+	calls int
+}
+
+func (f *flakyEnvironment) call(p []byte) ([]byte, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return p, nil
+}
+
+func (f *flakyEnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	return f.call([]byte("frame"))
+}
+
+func (f *flakyEnvironment) ReadFooter() ([]byte, error) {
+	return f.call([]byte("footer"))
+}
+
+func (f *flakyEnvironment) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
+	return f.call([]byte("skip frame"))
+}
+
+func newTestEnvironment(f *flakyEnvironment, maxAttempts int, jitter func(time.Duration) time.Duration) (
+	*RetryREnvironment, *[]time.Duration,
+) {
+	e := NewRetryREnvironment(f, maxAttempts, 10*time.Millisecond, 50*time.Millisecond, jitter)
+	var delays []time.Duration
+	e.sleep = func(d time.Duration) { delays = append(delays, d) }
+	return e, &delays
+}
+
+func TestRetryREnvironment(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		call func(e env.REnvironment) ([]byte, error)
+		data string
+	}{
+		{"GetFrameByIndex", func(e env.REnvironment) ([]byte, error) {
+			return e.GetFrameByIndex(env.FrameOffsetEntry{})
+		}, "frame"},
+		{"ReadFooter", func(e env.REnvironment) ([]byte, error) { return e.ReadFooter() }, "footer"},
+		{"ReadSkipFrame", func(e env.REnvironment) ([]byte, error) { return e.ReadSkipFrame(1) }, "skip frame"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := &flakyEnvironment{failures: 5, err: errTransient}
+			e, delays := newTestEnvironment(f, 6, nil)
+			p, err := tc.call(e)
+			require.NoError(t, err)
+			assert.Equal(t, []byte(tc.data), p)
+			assert.Equal(t, 6, f.calls)
+			assert.Equal(t, []time.Duration{
+				10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond,
+				50 * time.Millisecond, 50 * time.Millisecond,
+			}, *delays)
+		})
+	}
+}
+
+func TestRetryREnvironmentErrors(t *testing.T) {
+	t.Parallel()
+
+	f := &flakyEnvironment{failures: 3, err: errTransient}
+	e, delays := newTestEnvironment(f, 3, func(d time.Duration) time.Duration { return d / 2 })
+	_, err := e.ReadFooter()
+	require.ErrorIs(t, err, errTransient)
+	require.ErrorContains(t, err, "giving up after 3 attempts")
+	assert.Equal(t, 3, f.calls)
+	assert.Equal(t, []time.Duration{5 * time.Millisecond, 10 * time.Millisecond}, *delays)
+
+	f = &flakyEnvironment{failures: 3, err: errFatal}
+	e, delays = newTestEnvironment(f, 3, nil)
+	e.IsRetryable = func(err error) bool { return errors.Is(err, errTransient) }
+	_, err = e.ReadFooter()
+	require.Equal(t, errFatal, err)
+	assert.Equal(t, 1, f.calls)
+	assert.Empty(t, *delays)
+
+	// At least one attempt is made.
+	f = &flakyEnvironment{failures: 1, err: errTransient}
+	e, _ = newTestEnvironment(f, 0, nil)
+	_, err = e.ReadFooter()
+	require.ErrorContains(t, err, "giving up after 1 attempts")
+}
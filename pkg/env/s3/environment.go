@@ -0,0 +1,76 @@
+// Package s3 implements env.REnvironment on top of AWS S3 GetObject byte-range requests,
+// so that seekable streams stored in S3 can be randomly accessed without downloading them entirely.
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// seekTableFooterSize is the size of the `Seek_Table_Footer`.
+const seekTableFooterSize = 9
+
+// GetObjectAPI is the subset of *s3.Client used by S3REnvironment.
+type GetObjectAPI interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3REnvironment reads frames and the seek table of a seekable stream stored in S3.
+// Each call issues a single GetObject request with the `Range` header.
+// It is goroutine-safe as long as the passed client is, which is the case for *s3.Client.
+type S3REnvironment struct {
+	client GetObjectAPI
+	bucket string
+	key    string
+}
+
+var _ env.REnvironment = (*S3REnvironment)(nil)
+
+// NewS3REnvironment returns the environment that reads the object at bucket/key using client, e.g. *s3.Client.
+func NewS3REnvironment(client GetObjectAPI, bucket, key string) *S3REnvironment {
+	return &S3REnvironment{client: client, bucket: bucket, key: key}
+}
+
+func (e *S3REnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	if index.CompSize == 0 {
+		return []byte{}, nil
+	}
+	return e.get(fmt.Sprintf("bytes=%d-%d", index.CompOffset, index.CompOffset+uint64(index.CompSize)-1),
+		int64(index.CompSize))
+}
+
+func (e *S3REnvironment) ReadFooter() ([]byte, error) {
+	return e.get(fmt.Sprintf("bytes=-%d", seekTableFooterSize), seekTableFooterSize)
+}
+
+func (e *S3REnvironment) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
+	if skippableFrameOffset <= 0 {
+		return nil, fmt.Errorf("invalid skippable frame offset: %d", skippableFrameOffset)
+	}
+	return e.get(fmt.Sprintf("bytes=-%d", skippableFrameOffset), skippableFrameOffset)
+}
+
+// get fetches exactly size bytes of the given range.
+func (e *S3REnvironment) get(byteRange string, size int64) ([]byte, error) {
+	out, err := e.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(e.bucket),
+		Key:    aws.String(e.key),
+		Range:  aws.String(byteRange),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get range %q of s3://%s/%s: %w", byteRange, e.bucket, e.key, err)
+	}
+	defer out.Body.Close()
+
+	buf := make([]byte, size)
+	if _, err = io.ReadFull(out.Body, buf); err != nil {
+		return nil, fmt.Errorf("failed to read range %q of s3://%s/%s: %w", byteRange, e.bucket, e.key, err)
+	}
+	return buf, nil
+}
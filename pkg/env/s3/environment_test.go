@@ -0,0 +1,174 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// integrationEndpoint is the S3-compatible endpoint (e.g. MinIO) used by integration tests.
+// Integration tests are skipped unless ZSTD_SEEKABLE_S3_ENDPOINT is set.
+var integrationEndpoint string
+
+func TestMain(m *testing.M) {
+	integrationEndpoint = os.Getenv("ZSTD_SEEKABLE_S3_ENDPOINT")
+	os.Exit(m.Run())
+}
+
+// fakeS3 serves byte ranges of a static object and records requested ranges.
+type fakeS3 struct {
+	data []byte
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	r := aws.ToString(params.Range)
+	f.mu.Lock()
+	f.ranges = append(f.ranges, r)
+	f.mu.Unlock()
+
+	var start, end int64
+	if _, err := fmt.Sscanf(r, "bytes=-%d", &end); err == nil {
+		start, end = max(int64(len(f.data))-end, 0), int64(len(f.data))-1
+	} else if _, err := fmt.Sscanf(r, "bytes=%d-%d", &start, &end); err != nil {
+		return nil, fmt.Errorf("invalid range: %q", r)
+	}
+	if start > end || end >= int64(len(f.data)) {
+		return nil, errors.New("InvalidRange")
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(f.data[start : end+1]))}, nil
+}
+
+func writeTestStream(t *testing.T, frames ...string) []byte {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc)
+	require.NoError(t, err)
+	for _, s := range frames {
+		_, err = w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func TestS3REnvironment(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	client := &fakeS3{data: writeTestStream(t, "test", "test2", "test3")}
+	e := NewS3REnvironment(client, "bucket", "key")
+	r, err := seekable.NewReader(nil, dec, seekable.WithREnvironment(e))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	d := r.(seekable.Decoder)
+	require.Equal(t, int64(3), d.NumFrames())
+	last := d.GetIndexByID(2)
+	seekTableSize := int64(len(client.data)) - int64(last.CompOffset+uint64(last.CompSize))
+	expected := []string{"bytes=-9", fmt.Sprintf("bytes=-%d", seekTableSize)}
+	assert.Equal(t, expected, client.ranges)
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testtest2test3"), all)
+
+	// Every frame is fetched by its own range.
+	for id := int64(0); id < 3; id++ {
+		index := d.GetIndexByID(id)
+		expected = append(expected, fmt.Sprintf("bytes=%d-%d", index.CompOffset, index.CompOffset+uint64(index.CompSize)-1))
+	}
+	assert.Equal(t, expected, client.ranges)
+}
+
+func TestS3REnvironmentErrors(t *testing.T) {
+	t.Parallel()
+
+	e := NewS3REnvironment(&fakeS3{data: []byte("short")}, "bucket", "key")
+	_, err := e.ReadFooter()
+	require.ErrorContains(t, err, "failed to read range \"bytes=-9\" of s3://bucket/key")
+
+	_, err = e.GetFrameByIndex(env.FrameOffsetEntry{CompOffset: 3, CompSize: 10})
+	require.ErrorContains(t, err, "failed to get range \"bytes=3-12\" of s3://bucket/key: InvalidRange")
+
+	_, err = e.ReadSkipFrame(0)
+	require.ErrorContains(t, err, "invalid skippable frame offset")
+
+	// Empty frames are not requested.
+	p, err := e.GetFrameByIndex(env.FrameOffsetEntry{})
+	require.NoError(t, err)
+	assert.Empty(t, p)
+}
+
+// TestS3REnvironmentIntegration round-trips a stream through a real S3-compatible endpoint, e.g.:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	ZSTD_SEEKABLE_S3_ENDPOINT=http://localhost:9000 ZSTD_SEEKABLE_S3_BUCKET=test \
+//	AWS_ACCESS_KEY_ID=minioadmin AWS_SECRET_ACCESS_KEY=minioadmin go test ./...
+//
+// The bucket must exist.
+func TestS3REnvironmentIntegration(t *testing.T) {
+	if integrationEndpoint == "" {
+		t.Skip("ZSTD_SEEKABLE_S3_ENDPOINT is not set")
+	}
+
+	ctx := context.Background()
+	bucket := os.Getenv("ZSTD_SEEKABLE_S3_BUCKET")
+	require.NotEmpty(t, bucket, "ZSTD_SEEKABLE_S3_BUCKET is not set")
+
+	client := s3.New(s3.Options{
+		BaseEndpoint: aws.String(integrationEndpoint),
+		Region:       "us-east-1",
+		UsePathStyle: true,
+		Credentials: credentials.NewStaticCredentialsProvider(
+			os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), ""),
+	})
+
+	data := writeTestStream(t, "test", "test2", "test3")
+	key := fmt.Sprintf("%s.zst", t.Name())
+	_, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	require.NoError(t, err)
+	defer func() {
+		_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		require.NoError(t, err)
+	}()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	r, err := seekable.NewReader(nil, dec, seekable.WithREnvironment(NewS3REnvironment(client, bucket, key)))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testtest2test3"), all)
+}
@@ -0,0 +1,81 @@
+package seekable
+
+import (
+	"fmt"
+	"io"
+)
+
+// fixedChunkWriter buffers input and writes it as frames of chunkSize bytes.
+type fixedChunkWriter struct {
+	w      Writer
+	buf    []byte
+	closed bool
+}
+
+var _ io.WriteCloser = (*fixedChunkWriter)(nil)
+
+// NewFixedChunkWriter wraps the passed io.Writer and Encoder into an indexed ZSTD stream
+// where each frame holds exactly chunkSize bytes, regardless of sizes of Write calls,
+// e.g. to align frames with pages of database files.
+//
+// Data remaining in the buffer is written as the final, shorter, frame on Close.
+func NewFixedChunkWriter(w io.Writer, encoder ZSTDEncoder, chunkSize int, opts ...wOption) (io.WriteCloser, error) {
+	if chunkSize < 1 || int64(chunkSize) > maxChunkSize {
+		return nil, fmt.Errorf("chunk size must be in [1, %d]: %d", maxChunkSize, chunkSize)
+	}
+
+	sw, err := NewWriter(w, encoder, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fixedChunkWriter{
+		w:   sw,
+		buf: make([]byte, 0, chunkSize),
+	}, nil
+}
+
+func (f *fixedChunkWriter) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, fmt.Errorf("write to closed writer")
+	}
+
+	chunkSize := cap(f.buf)
+	n := 0
+	for n < len(p) {
+		// Whole chunks are written without copying them into the buffer.
+		if len(f.buf) == 0 && len(p)-n >= chunkSize {
+			if _, err := f.w.Write(p[n : n+chunkSize]); err != nil {
+				return n, fmt.Errorf("failed to write chunk: %w", err)
+			}
+			n += chunkSize
+			continue
+		}
+
+		copied := copy(f.buf[len(f.buf):chunkSize], p[n:])
+		f.buf = f.buf[:len(f.buf)+copied]
+		n += copied
+		if len(f.buf) == chunkSize {
+			if _, err := f.w.Write(f.buf); err != nil {
+				return n, fmt.Errorf("failed to write chunk: %w", err)
+			}
+			f.buf = f.buf[:0]
+		}
+	}
+	return n, nil
+}
+
+func (f *fixedChunkWriter) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	if len(f.buf) > 0 {
+		if _, err := f.w.Write(f.buf); err != nil {
+			return fmt.Errorf("failed to write chunk: %w", err)
+		}
+		f.buf = f.buf[:0]
+	}
+	return f.w.Close()
+}
@@ -0,0 +1,70 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixedChunkWriter(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	for _, chunkSize := range []int{0, -1} {
+		_, err = NewFixedChunkWriter(io.Discard, enc, chunkSize)
+		require.ErrorContains(t, err, "chunk size must be in")
+	}
+
+	const chunkSize = 64 << 10
+	rng := rand.New(rand.NewSource(0))
+	src := make([]byte, 10<<20+123)
+	rng.Read(src)
+
+	var reference []byte
+	for _, step := range []int{1000, chunkSize, 3 * chunkSize, len(src)} {
+		var b bytes.Buffer
+		w, err := NewFixedChunkWriter(&b, enc, chunkSize)
+		require.NoError(t, err)
+		for off := 0; off < len(src); off += step {
+			n, err := w.Write(src[off:min(off+step, len(src))])
+			require.NoError(t, err)
+			assert.Equal(t, min(step, len(src)-off), n)
+		}
+		require.NoError(t, w.Close())
+		require.NoError(t, w.Close())
+
+		_, err = w.Write(src)
+		require.ErrorContains(t, err, "closed")
+
+		// Frames do not depend on how data is written.
+		if reference == nil {
+			reference = b.Bytes()
+		}
+		assert.Equal(t, reference, b.Bytes())
+	}
+
+	r, err := NewReader(bytes.NewReader(reference), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, src, all)
+
+	d := r.(Decoder)
+	require.Equal(t, int64(len(src)/chunkSize+1), d.NumFrames())
+	for id := int64(0); id < d.NumFrames()-1; id++ {
+		assert.Equal(t, uint32(chunkSize), d.GetIndexByID(id).DecompSize)
+	}
+	assert.Equal(t, uint32(len(src)%chunkSize), d.GetIndexByID(d.NumFrames()-1).DecompSize)
+}
@@ -0,0 +1,222 @@
+// Package fs exposes files stored in a seekable stream as an io/fs.FS.
+// The stream itself does not describe files, so their names and decompressed
+// byte ranges are passed separately as FSIndex.
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"path"
+	"slices"
+	"time"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+// FSEntry describes a file stored in the [DecompOffset, DecompOffset+Size) range of the decompressed stream.
+// Name is a slash-separated path as accepted by io/fs.ValidPath; parent directories are created implicitly.
+type FSEntry struct {
+	Name         string
+	DecompOffset uint64
+	Size         uint64
+	Mode         iofs.FileMode
+}
+
+// FSIndex lists files of the stream.
+type FSIndex []FSEntry
+
+// FS is a read-only filesystem backed by a seekable stream.
+// Files are read with ReadAt of the Reader, so FS is goroutine-safe under the same conditions.
+type FS struct {
+	r     seekable.Reader
+	nodes map[string]*node
+}
+
+var (
+	_ iofs.FS        = (*FS)(nil)
+	_ iofs.ReadDirFS = (*FS)(nil)
+	_ iofs.StatFS    = (*FS)(nil)
+)
+
+// node is either a file with entry set or a directory with children names.
+type node struct {
+	entry    *FSEntry
+	children []string
+}
+
+// NewFS returns the filesystem with files of index stored in the stream read by r.
+func NewFS(r seekable.Reader, index FSIndex) (*FS, error) {
+	var size int64 = -1
+	if d, ok := r.(interface{ Size() int64 }); ok {
+		size = d.Size()
+	}
+
+	f := &FS{r: r, nodes: map[string]*node{".": {}}}
+	for i := range index {
+		e := &index[i]
+		if !iofs.ValidPath(e.Name) || e.Name == "." {
+			return nil, fmt.Errorf("invalid file name: %q", e.Name)
+		}
+		if e.Mode.Type() != 0 {
+			return nil, fmt.Errorf("file %q is not a regular file: %s", e.Name, e.Mode)
+		}
+		if e.DecompOffset+e.Size < e.DecompOffset || (size >= 0 && e.DecompOffset+e.Size > uint64(size)) {
+			return nil, fmt.Errorf("file %q is out of bounds: [%d, %d) for stream of size %d",
+				e.Name, e.DecompOffset, e.DecompOffset+e.Size, size)
+		}
+		if _, ok := f.nodes[e.Name]; ok {
+			return nil, fmt.Errorf("duplicate file name: %q", e.Name)
+		}
+		f.nodes[e.Name] = &node{entry: e}
+
+		// Create parent directories up to the first existing one.
+		for name := e.Name; ; {
+			dir := path.Dir(name)
+			parent, ok := f.nodes[dir]
+			if ok && parent.entry != nil {
+				return nil, fmt.Errorf("file %q is a parent of %q", dir, e.Name)
+			}
+			if !ok {
+				parent = &node{}
+				f.nodes[dir] = parent
+			}
+			parent.children = append(parent.children, path.Base(name))
+			if ok {
+				break
+			}
+			name = dir
+		}
+	}
+	for _, n := range f.nodes {
+		slices.Sort(n.children)
+	}
+	return f, nil
+}
+
+func (f *FS) lookup(op, name string) (*node, error) {
+	if !iofs.ValidPath(name) {
+		return nil, &iofs.PathError{Op: op, Path: name, Err: iofs.ErrInvalid}
+	}
+	n, ok := f.nodes[name]
+	if !ok {
+		return nil, &iofs.PathError{Op: op, Path: name, Err: iofs.ErrNotExist}
+	}
+	return n, nil
+}
+
+// Open implements io/fs.FS.  Returned files also implement io.Seeker and io.ReaderAt.
+func (f *FS) Open(name string) (iofs.File, error) {
+	n, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.entry == nil {
+		entries, err := f.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &dir{info: fileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+	}
+	return &file{
+		SectionReader: io.NewSectionReader(f.r, int64(n.entry.DecompOffset), int64(n.entry.Size)),
+		info:          newFileInfo(n.entry),
+	}, nil
+}
+
+// ReadDir implements io/fs.ReadDirFS.
+func (f *FS) ReadDir(name string) ([]iofs.DirEntry, error) {
+	n, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.entry != nil {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	entries := make([]iofs.DirEntry, 0, len(n.children))
+	for _, child := range n.children {
+		info, err := f.Stat(path.Join(name, child))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, iofs.FileInfoToDirEntry(info))
+	}
+	return entries, nil
+}
+
+// Stat implements io/fs.StatFS.
+func (f *FS) Stat(name string) (iofs.FileInfo, error) {
+	n, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.entry == nil {
+		return fileInfo{name: path.Base(name), dir: true}, nil
+	}
+	return newFileInfo(n.entry), nil
+}
+
+type fileInfo struct {
+	name string
+	size int64
+	mode iofs.FileMode
+	dir  bool
+}
+
+func newFileInfo(e *FSEntry) fileInfo {
+	return fileInfo{name: path.Base(e.Name), size: int64(e.Size), mode: e.Mode}
+}
+
+func (i fileInfo) Name() string { return i.name }
+func (i fileInfo) Size() int64  { return i.size }
+
+func (i fileInfo) Mode() iofs.FileMode {
+	if i.dir {
+		return iofs.ModeDir | 0o555
+	}
+	return i.mode
+}
+
+func (i fileInfo) ModTime() time.Time { return time.Time{} }
+func (i fileInfo) IsDir() bool        { return i.dir }
+func (i fileInfo) Sys() any           { return nil }
+
+// file reads its range of the stream with ReadAt.
+type file struct {
+	*io.SectionReader
+	info fileInfo
+}
+
+func (f *file) Stat() (iofs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error                 { return nil }
+
+// dir lists entries of a directory.
+type dir struct {
+	info    fileInfo
+	entries []iofs.DirEntry
+}
+
+func (d *dir) Stat() (iofs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error                 { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &iofs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir implements io/fs.ReadDirFile.
+func (d *dir) ReadDir(n int) ([]iofs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
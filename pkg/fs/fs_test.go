@@ -0,0 +1,125 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	iofs "io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+func TestFS(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"a.txt", "first file"},
+		{"dir/b.txt", "second file"},
+		{"dir/sub/c.txt", "third file"},
+	}
+
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc)
+	require.NoError(t, err)
+	var index FSIndex
+	var off uint64
+	for _, f := range files {
+		index = append(index, FSEntry{Name: f.name, DecompOffset: off, Size: uint64(len(f.content)), Mode: 0o444})
+		off += uint64(len(f.content))
+	}
+	// Frames are not aligned with files.
+	all := []byte(files[0].content + files[1].content + files[2].content)
+	for _, frame := range [][]byte{all[:5], all[5:20], all[20:]} {
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := seekable.NewReader(bytes.NewReader(b.Bytes()), dec, seekable.WithCacheSize(3))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	fsys, err := NewFS(r, index)
+	require.NoError(t, err)
+
+	for _, f := range files {
+		content, err := iofs.ReadFile(fsys, f.name)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(f.content), content)
+	}
+
+	entries, err := fsys.ReadDir("dir")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b.txt", entries[0].Name())
+	assert.False(t, entries[0].IsDir())
+	assert.Equal(t, "sub", entries[1].Name())
+	assert.True(t, entries[1].IsDir())
+
+	info, err := fsys.Stat("dir/sub/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(files[2].content)), info.Size())
+	assert.Equal(t, iofs.FileMode(0o444), info.Mode())
+
+	f, err := fsys.Open("dir/b.txt")
+	require.NoError(t, err)
+	_, err = f.(io.Seeker).Seek(7, io.SeekStart)
+	require.NoError(t, err)
+	rest, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("file"), rest)
+	require.NoError(t, f.Close())
+
+	_, err = fsys.Open("missing")
+	require.ErrorIs(t, err, iofs.ErrNotExist)
+	_, err = fsys.ReadDir("a.txt")
+	require.ErrorContains(t, err, "not a directory")
+
+	require.NoError(t, fstest.TestFS(fsys, "a.txt", "dir/b.txt", "dir/sub/c.txt"))
+}
+
+func TestNewFSErrors(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := seekable.NewReader(bytes.NewReader(b.Bytes()), nil, seekable.WithDefaultDecoder())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	for _, tc := range []struct {
+		index FSIndex
+		err   string
+	}{
+		{FSIndex{{Name: "/abs", Size: 1}}, "invalid file name"},
+		{FSIndex{{Name: ".", Size: 1}}, "invalid file name"},
+		{FSIndex{{Name: "a", Size: 5}}, "file \"a\" is out of bounds: [0, 5) for stream of size 4"},
+		{FSIndex{{Name: "a", Size: 1}, {Name: "a", Size: 1}}, "duplicate file name"},
+		{FSIndex{{Name: "a", Size: 1}, {Name: "a/b", Size: 1}}, "file \"a\" is a parent of \"a/b\""},
+		{FSIndex{{Name: "a", Mode: iofs.ModeSymlink}}, "not a regular file"},
+	} {
+		_, err := NewFS(r, tc.index)
+		require.ErrorContains(t, err, tc.err)
+	}
+}
@@ -8,9 +8,11 @@ require (
 	github.com/klauspost/compress v1.17.10
 	github.com/stretchr/testify v1.9.0
 	go.uber.org/atomic v1.11.0
+	go.uber.org/goleak v1.3.0
 	go.uber.org/multierr v1.11.0
 	go.uber.org/zap v1.27.0
 	golang.org/x/sync v0.8.0
+	golang.org/x/sys v0.26.0
 )
 
 require (
@@ -0,0 +1,249 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/google/btree"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+/*
+marshalFineIndex serializes entries of a span of frames into a fine index skippable frame
+(tagged with fineIndexTag) that is written right after the last frame of the span:
+
+	|`Skippable_Magic_Number`|`Frame_Size`|`[Seek_Table_Entries]`|`First_Frame_ID`|`Seek_Table_Footer`|
+	|------------------------|------------|----------------------|----------------|-------------------|
+	| 4 bytes                | 4 bytes    | 8-12 bytes each      | 8 bytes        | 9 bytes           |
+
+`First_Frame_ID` is the ID of the first frame of the span in the whole stream.
+`Number_Of_Frames` in the footer is the number of frames in the span.
+
+The seek table of the stream then contains one entry per span, covering all frames of the span
+along with its fine index, and has the `Hierarchical_Flag` set.  Decoders compliant with the spec
+assume that each entry is a single ZSTD frame and thus must reject such streams.
+*/
+func marshalFineIndex(entries []seekTableEntry, firstID uint64) ([]byte, error) {
+	entrySize := 12
+
+	payload := make([]byte, len(entries)*entrySize+fineIndexTrailerSize)
+	for i, e := range entries {
+		e.marshalBinaryInline(payload[i*entrySize : (i+1)*entrySize])
+	}
+
+	trailer := payload[len(entries)*entrySize:]
+	binary.LittleEndian.PutUint64(trailer, firstID)
+	footer := seekTableFooter{
+		NumberOfFrames: uint32(len(entries)),
+		SeekTableDescriptor: seekTableDescriptor{
+			ChecksumFlag: true,
+		},
+		SeekableMagicNumber: seekableMagicNumber,
+	}
+	footer.marshalBinaryInline(trailer[firstFrameIDFieldSize:])
+
+	return createSkippableFrame(fineIndexTag, payload)
+}
+
+// fineIndexSize returns the size of the fine index skippable frame for numFrames frames.
+func fineIndexSize(numFrames int64, entrySize int64) int64 {
+	return skippableMagicNumberFieldSize + frameSizeFieldSize + numFrames*entrySize + fineIndexTrailerSize
+}
+
+// nextFrameID returns the id of the frame that will be appended next.
+func (s *writerImpl) nextFrameID() int64 {
+	return int64(s.spanFirstID) + int64(len(s.frameEntries))
+}
+
+// appendEntry records the frame in the seek table.  For the hierarchical index,
+// it returns the fine index that needs to be written after the frame if it completes a span.
+func (s *writerImpl) appendEntry(entry seekTableEntry) ([]byte, error) {
+	id := s.nextFrameID()
+	if s.spanFrames == 0 {
+		s.frameEntries = append(s.frameEntries, entry)
+		s.frameWritten(id, entry)
+		return nil, nil
+	}
+
+	compSize := s.spanCompSize + uint64(entry.CompressedSize) +
+		uint64(fineIndexSize(int64(len(s.frameEntries))+1, 12))
+	decompSize := s.spanDecompSize + uint64(entry.DecompressedSize)
+	if compSize > math.MaxUint32 || decompSize > math.MaxUint32 {
+		return nil, fmt.Errorf("hierarchical index span is too big: compressed: %d, decompressed: %d",
+			compSize, decompSize)
+	}
+
+	s.frameEntries = append(s.frameEntries, entry)
+	s.frameWritten(id, entry)
+	s.spanCompSize += uint64(entry.CompressedSize)
+	s.spanDecompSize += uint64(entry.DecompressedSize)
+	if len(s.frameEntries) < s.spanFrames {
+		return nil, nil
+	}
+	return s.endSpan()
+}
+
+// frameWritten updates counters and metrics once the frame is recorded in the seek table.
+func (s *writerImpl) frameWritten(id int64, entry seekTableEntry) {
+	s.numFrames.Inc()
+	s.bytesWritten.Add(uint64(entry.DecompressedSize))
+	s.compOffset += uint64(entry.CompressedSize)
+	s.metrics.OnFrameWritten(id, uint64(entry.CompressedSize), uint64(entry.DecompressedSize))
+}
+
+// endSpan returns the fine index for the frames of the current span and
+// records the span in the coarse seek table.
+func (s *writerImpl) endSpan() ([]byte, error) {
+	if int64(len(s.spanEntries)) >= maxNumberOfFrames {
+		return nil, fmt.Errorf("number of spans for seekable format: %d >= %d",
+			len(s.spanEntries), maxNumberOfFrames)
+	}
+
+	fine, err := marshalFineIndex(s.frameEntries, s.spanFirstID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.spanEntries = append(s.spanEntries, seekTableEntry{
+		CompressedSize:   uint32(s.spanCompSize) + uint32(len(fine)),
+		DecompressedSize: uint32(s.spanDecompSize),
+	})
+	s.spanFirstID += uint64(len(s.frameEntries))
+	s.frameEntries = s.frameEntries[:0]
+	s.spanCompSize, s.spanDecompSize = 0, 0
+	return fine, nil
+}
+
+// endHierarchicalStream returns the fine index of the last (incomplete) span
+// followed by the coarse seek table.
+func (s *writerImpl) endHierarchicalStream() ([]byte, error) {
+	var res []byte
+	if len(s.frameEntries) > 0 {
+		fine, err := s.endSpan()
+		if err != nil {
+			return nil, err
+		}
+		res = fine
+	}
+
+	seekTable, err := marshalSeekTableWithDescriptor(s.spanEntries, seekTableDescriptor{
+		HierarchicalFlag: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(res, seekTable...), nil
+}
+
+// fineIndexCache holds the fine index of the last accessed span.
+type fineIndexCache struct {
+	m sync.Mutex
+
+	spanID int64
+	index  *btree.BTreeG[*env.FrameOffsetEntry]
+}
+
+// fineIndexByDecompOffset returns the frame containing the offset within the span,
+// loading the fine index of the span if needed.
+func (r *readerImpl) fineIndexByDecompOffset(span *env.FrameOffsetEntry, off uint64) (*env.FrameOffsetEntry, error) {
+	r.fine.m.Lock()
+	if r.fine.index == nil || r.fine.spanID != span.ID {
+		index, err := r.loadFineIndex(span)
+		if err != nil {
+			r.fine.m.Unlock()
+			return nil, fmt.Errorf("failed to load fine index of span %d: %w", span.ID, err)
+		}
+		r.fine.spanID = span.ID
+		r.fine.index = index
+	}
+	index := r.fine.index
+	r.fine.m.Unlock()
+
+	found := findByDecompOffset(index, off)
+	if found == nil {
+		return nil, fmt.Errorf("failed to get index by offset: %d in span %d", off, span.ID)
+	}
+	return found, nil
+}
+
+// loadFineIndex reads and parses the fine index stored at the end of the span.
+func (r *readerImpl) loadFineIndex(span *env.FrameOffsetEntry) (*btree.BTreeG[*env.FrameOffsetEntry], error) {
+	if int64(span.CompSize) < fineIndexSize(0, 8) {
+		return nil, fmt.Errorf("span is too small: %d", span.CompSize)
+	}
+	spanEnd := span.CompOffset + uint64(span.CompSize)
+
+	trailer, err := r.env.GetFrameByIndex(env.FrameOffsetEntry{
+		ID:         span.ID,
+		CompOffset: spanEnd - fineIndexTrailerSize,
+		CompSize:   fineIndexTrailerSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fine index footer: %w", err)
+	}
+	if len(trailer) != fineIndexTrailerSize {
+		return nil, fmt.Errorf("fine index footer size mismatch: %d vs %d", len(trailer), fineIndexTrailerSize)
+	}
+
+	footer := seekTableFooter{}
+	if err = footer.UnmarshalBinary(trailer[firstFrameIDFieldSize:]); err != nil {
+		return nil, fmt.Errorf("failed to parse fine index footer: %w", err)
+	}
+	firstID := binary.LittleEndian.Uint64(trailer)
+	if firstID > math.MaxInt64 {
+		return nil, fmt.Errorf("fine index first frame ID is too big: %d", firstID)
+	}
+
+	entrySize := int64(8)
+	if footer.SeekTableDescriptor.ChecksumFlag {
+		entrySize += 4
+	}
+	size := fineIndexSize(int64(footer.NumberOfFrames), entrySize)
+	if size > maxDecoderFrameSize || size > int64(span.CompSize) {
+		return nil, fmt.Errorf("fine index is too big: %d (span: %d)", size, span.CompSize)
+	}
+
+	buf, err := r.env.GetFrameByIndex(env.FrameOffsetEntry{
+		ID:         span.ID,
+		CompOffset: spanEnd - uint64(size),
+		CompSize:   uint32(size),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fine index: %w", err)
+	}
+	if int64(len(buf)) != size {
+		return nil, fmt.Errorf("fine index size mismatch: %d vs %d", len(buf), size)
+	}
+
+	magic := binary.LittleEndian.Uint32(buf[0:4])
+	if magic != skippableFrameMagic+fineIndexTag {
+		return nil, fmt.Errorf("fine index magic mismatch %d vs %d", magic, skippableFrameMagic+fineIndexTag)
+	}
+	frameSize := int64(binary.LittleEndian.Uint32(buf[4:8]))
+	if frameSize != size-frameSizeFieldSize-skippableMagicNumberFieldSize {
+		return nil, fmt.Errorf("fine index frame size mismatch: expected: %d, actual: %d",
+			size-frameSizeFieldSize-skippableMagicNumberFieldSize, frameSize)
+	}
+
+	entries := buf[8 : int64(len(buf))-fineIndexTrailerSize]
+	index, last, err := r.indexSeekTableEntriesAt(entries, uint64(entrySize), env.FrameOffsetEntry{
+		ID:           int64(firstID),
+		CompOffset:   span.CompOffset,
+		DecompOffset: span.DecompOffset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		return nil, fmt.Errorf("fine index is empty")
+	}
+
+	if last.CompOffset+uint64(last.CompSize)+uint64(size) != spanEnd ||
+		last.DecompOffset+uint64(last.DecompSize) != span.DecompOffset+uint64(span.DecompSize) {
+		return nil, fmt.Errorf("fine index does not match span: %+v vs %+v", last, span)
+	}
+	return index, nil
+}
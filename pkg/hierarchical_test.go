@@ -0,0 +1,184 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHierarchicalIndex(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = NewWriter(nil, enc, WithHierarchicalIndex(0))
+	require.ErrorContains(t, err, "coarse granularity must be positive")
+	_, err = NewWriter(nil, enc, WithHierarchicalIndex(maxDecoderFrameSize))
+	require.ErrorContains(t, err, "fine index is too big")
+	_, err = NewWriter(nil, enc, WithHierarchicalIndex(3), WithChunkedSeekTable(3))
+	require.ErrorContains(t, err, "mutually exclusive")
+
+	for _, frameCount := range []int{0, 1, 3, 10} {
+		var frames [][]byte
+		concat := []byte{}
+		for i := 0; i < frameCount; i++ {
+			frame := makeTestFrame(t, i)
+			if i == 1 {
+				frame = []byte{}
+			}
+			frames = append(frames, frame)
+			concat = append(concat, frame...)
+		}
+
+		var b bytes.Buffer
+		w, err := NewWriter(&b, enc, WithHierarchicalIndex(3))
+		require.NoError(t, err)
+		for _, frame := range frames {
+			_, err = w.Write(frame)
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		var concurrent bytes.Buffer
+		w, err = NewWriter(&concurrent, enc, WithHierarchicalIndex(3))
+		require.NoError(t, err)
+		require.NoError(t, w.WriteMany(context.Background(), makeTestFrameSource(frames), WithConcurrency(2)))
+		require.NoError(t, w.Close())
+		assert.Equal(t, b.Bytes(), concurrent.Bytes())
+
+		buf := b.Bytes()
+		assert.Equal(t, hierarchicalFlagBit, buf[len(buf)-5])
+
+		// Spans are valid ZSTD data.
+		decoded, err := dec.DecodeAll(buf, []byte{})
+		require.NoError(t, err)
+		assert.Equal(t, concat, decoded)
+
+		r, err := NewReader(bytes.NewReader(buf), dec, WithSizeValidation())
+		require.NoError(t, err)
+		defer func() { require.NoError(t, r.Close()) }()
+
+		// Index only has spans.
+		sr := r.(*readerImpl)
+		assert.Equal(t, int64((frameCount+2)/3), sr.numFrames)
+
+		// Frame lookups do not return spans.
+		assert.Equal(t, int64(-1), sr.NumFrames())
+		assert.Nil(t, sr.GetIndexByID(0))
+		assert.Nil(t, sr.GetIndexByDecompOffset(0))
+		assert.Nil(t, sr.GetIndexByCompOffset(0))
+		assert.Nil(t, sr.GetFrameRange(0, 1))
+		current, next := sr.GetNearestFrames(0)
+		assert.Nil(t, current)
+		assert.Nil(t, next)
+		for range sr.IterFrames(context.Background()) {
+			assert.Fail(t, "unexpected frame")
+		}
+		_, err = sr.MarshalBinary()
+		assert.ErrorContains(t, err, "marshaling hierarchical index is not supported")
+		_, err = sr.SeekToFrame(0)
+		assert.ErrorContains(t, err, "seeking to frame is not supported")
+
+		all, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, concat, all)
+
+		// Random access across spans.
+		for off := len(concat) - 1; off >= 0; off -= 97 {
+			p := make([]byte, 300)
+			n, err := r.ReadAt(p, int64(off))
+			if err != nil {
+				require.ErrorIs(t, err, io.EOF)
+			}
+			assert.Equal(t, concat[off:off+n], p[:n])
+		}
+
+		// Frames are cached by their IDs in the stream.
+		if frameCount == 10 {
+			_, ok := sr.cache.get(9)
+			assert.False(t, ok)
+			_, err = r.ReadAt(make([]byte, 1), int64(len(concat)-1))
+			require.NoError(t, err)
+			_, ok = sr.cache.get(9)
+			assert.True(t, ok)
+
+			require.ErrorContains(t, sr.Prefetch(context.Background(), []int64{0}, sr.env, dec),
+				"prefetch is not supported")
+		}
+	}
+}
+
+func TestHierarchicalIndexCorruption(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc, WithHierarchicalIndex(2))
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		_, err = w.Write(makeTestFrame(t, i))
+		require.NoError(t, err)
+	}
+	_, err = w.Checkpoint(io.Discard)
+	require.ErrorContains(t, err, "checkpoint is not supported")
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	span := *r.(*readerImpl).indexByID(0)
+	spanEnd := span.CompOffset + uint64(span.CompSize)
+
+	for name, corrupt := range map[string]func(buf []byte){
+		"first frame ID": func(buf []byte) {
+			binary.LittleEndian.PutUint64(buf[spanEnd-fineIndexTrailerSize:], 1<<63)
+		},
+		"footer magic": func(buf []byte) {
+			buf[spanEnd-1] ^= 0xff
+		},
+		"number of frames": func(buf []byte) {
+			binary.LittleEndian.PutUint32(buf[spanEnd-seekTableFooterOffset:], 1<<30)
+		},
+		"skippable magic": func(buf []byte) {
+			size := uint64(fineIndexSize(2, 12))
+			buf[spanEnd-size] ^= 0xff
+		},
+		"entries": func(buf []byte) {
+			size := uint64(fineIndexSize(2, 12))
+			binary.LittleEndian.PutUint32(buf[spanEnd-size+8:], 1)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.Clone(b.Bytes())
+			corrupt(buf)
+
+			r, err := NewReader(bytes.NewReader(buf), dec)
+			require.NoError(t, err)
+			defer func() { require.NoError(t, r.Close()) }()
+
+			_, err = r.ReadAt(make([]byte, 1), 0)
+			require.ErrorContains(t, err, "failed to load fine index of span 0")
+
+			// Other spans are not affected.
+			_, err = r.ReadAt(make([]byte, 1), r.(*readerImpl).Size()-1)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package seekable
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+/*
+writeHMAC adds an entry of the HMAC frame to the seek table, writes the frame and returns the signed seek table.
+The HMAC frame is written right before the seek table and is a skippable frame (tagged with hmacTag):
+
+	|`Skippable_Magic_Number`|`Frame_Size`|`HMAC`   |
+	|------------------------|------------|---------|
+	| 4 bytes                | 4 bytes    | 32 bytes|
+
+where `HMAC` is the HMAC-SHA256 of the whole seek table, including its skippable frame header.
+*/
+func (s *writerImpl) writeHMAC() ([]byte, error) {
+	s.frameEntries = append(s.frameEntries, seekTableEntry{
+		CompressedSize: hmacFrameSize,
+		Checksum:       uint32((xxhash.Sum64(nil) << 32) >> 32),
+	})
+
+	seekTableBytes, err := s.EndStream()
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := createSkippableFrame(hmacTag, seekTableHMAC(s.hmacKey, seekTableBytes))
+	if err != nil {
+		return nil, err
+	}
+	if err = s.writeMagicPrefix(); err != nil {
+		return nil, err
+	}
+
+	n, err := s.env.WriteFrame(frame)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write HMAC: %w", err)
+	}
+	if n != len(frame) {
+		return nil, fmt.Errorf("partial write: %d out of %d", n, len(frame))
+	}
+	return seekTableBytes, nil
+}
+
+func seekTableHMAC(key, seekTable []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(seekTable)
+	return mac.Sum(nil)
+}
+
+// verifyHMAC checks that the seek table is preceded by the HMAC frame written with WithWHMAC
+// and that the HMAC matches.
+func (r *readerImpl) verifyHMAC() error {
+	if r.hierarchical {
+		return fmt.Errorf("HMAC verification is not supported for hierarchical index")
+	}
+
+	size := r.seekTableSize + hmacFrameSize
+	buf, err := r.env.ReadSkipFrame(size)
+	if err != nil {
+		return fmt.Errorf("failed to read HMAC frame: %w", err)
+	}
+	if int64(len(buf)) < size {
+		return fmt.Errorf("HMAC frame is missing")
+	}
+	buf = buf[int64(len(buf))-size:]
+
+	frame, seekTable := buf[:hmacFrameSize], buf[hmacFrameSize:]
+	if binary.LittleEndian.Uint32(frame) != skippableFrameMagic+hmacTag ||
+		binary.LittleEndian.Uint32(frame[skippableMagicNumberFieldSize:]) != sha256.Size {
+		return fmt.Errorf("HMAC frame is missing")
+	}
+	if !hmac.Equal(frame[skippableMagicNumberFieldSize+frameSizeFieldSize:], seekTableHMAC(r.hmacKey, seekTable)) {
+		return fmt.Errorf("HMAC mismatch")
+	}
+	return nil
+}
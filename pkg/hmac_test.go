@@ -0,0 +1,87 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMAC(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	key := []byte("secret")
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc, WithWHMAC(key))
+	require.NoError(t, err)
+	for _, s := range []string{"test", "test2"} {
+		_, err = w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	signed := b.Bytes()
+
+	// Frames, HMAC frame and the seek table with an entry for each of them.
+	seekTableSize := skippableMagicNumberFieldSize + frameSizeFieldSize + 3*12 + seekTableFooterOffset
+	hmacOffset := len(signed) - seekTableSize - hmacFrameSize
+
+	read := func(stream []byte, opts ...rOption) ([]byte, error) {
+		r, err := NewReader(bytes.NewReader(stream), dec, opts...)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { require.NoError(t, r.Close()) }()
+		return io.ReadAll(r)
+	}
+
+	all, err := read(signed, WithRHMAC(key), WithSizeValidation())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testtest2"), all)
+
+	// Signed streams are readable without the key.
+	all, err = read(signed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testtest2"), all)
+
+	_, err = read(signed, WithRHMAC([]byte("wrong")))
+	require.ErrorContains(t, err, "HMAC mismatch")
+
+	for _, off := range []int{
+		hmacOffset + hmacFrameSize - 1,     // HMAC itself
+		hmacOffset + hmacFrameSize + 8 + 8, // checksum of the first entry
+	} {
+		tampered := bytes.Clone(signed)
+		tampered[off] ^= 0x1
+		_, err = read(tampered, WithRHMAC(key))
+		require.ErrorContains(t, err, "HMAC mismatch", "offset %d", off)
+	}
+
+	tampered := bytes.Clone(signed)
+	tampered[hmacOffset] ^= 0x1
+	_, err = read(tampered, WithRHMAC(key))
+	require.ErrorContains(t, err, "HMAC frame is missing")
+
+	_, err = read(checksum, WithRHMAC(key))
+	require.ErrorContains(t, err, "failed to read HMAC frame")
+
+	_, err = NewWriter(&b, enc, WithWHMAC(key), WithPipeMode())
+	require.ErrorContains(t, err, "HMAC can not be used")
+	_, err = NewWriter(&b, enc, WithWHMAC(nil))
+	require.ErrorContains(t, err, "HMAC key is empty")
+	_, err = NewReader(bytes.NewReader(signed), dec, WithRHMAC(nil))
+	require.ErrorContains(t, err, "HMAC key is empty")
+
+	w, err = NewWriter(&b, enc, WithWHMAC(key))
+	require.NoError(t, err)
+	_, err = w.EndStreamTo(io.Discard)
+	require.ErrorContains(t, err, "not supported with HMAC")
+}
@@ -0,0 +1,53 @@
+// Package http serves the decompressed content of seekable streams over HTTP.
+// Range requests address the decompressed stream, so clients can fetch parts
+// of the original data without downloading and decompressing the whole stream.
+package http
+
+import (
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"time"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+// Handler serves the decompressed content of a seekable stream.
+// Requests are served with ReadAt of the Reader, so Handler is goroutine-safe under the same conditions.
+type Handler struct {
+	r    seekable.Reader
+	size int64
+}
+
+var _ nethttp.Handler = (*Handler)(nil)
+
+// NewHTTPHandler returns the handler serving the decompressed content of r.
+//
+// Requests with a `Range: bytes=N-M` header are answered with `206 Partial Content` and
+// the requested range of the decompressed stream, requests without one get the whole stream.
+// Range parsing, conditional requests and multipart ranges are handled by http.ServeContent.
+func NewHTTPHandler(r seekable.Reader) (*Handler, error) {
+	var size int64
+	if d, ok := r.(interface{ Size() int64 }); ok {
+		size = d.Size()
+	} else {
+		var err error
+		if size, err = r.Seek(0, io.SeekEnd); err != nil {
+			return nil, fmt.Errorf("failed to get size of the stream: %w", err)
+		}
+	}
+	return &Handler{r: r, size: size}, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w nethttp.ResponseWriter, req *nethttp.Request) {
+	if req.Method != nethttp.MethodGet && req.Method != nethttp.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		nethttp.Error(w, nethttp.StatusText(nethttp.StatusMethodNotAllowed), nethttp.StatusMethodNotAllowed)
+		return
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	nethttp.ServeContent(w, req, "", time.Time{}, io.NewSectionReader(h.r, 0, h.size))
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	// Frames of 100 bytes each.
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc)
+	require.NoError(t, err)
+	var original []byte
+	for i := 0; i < 3; i++ {
+		frame := bytes.Repeat([]byte(strconv.Itoa(i)), 100)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		original = append(original, frame...)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := seekable.NewReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	h, err := NewHTTPHandler(r)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name       string
+		rangeHdr   string
+		start, end int
+	}{
+		{"single frame", "bytes=10-20", 10, 21},
+		{"multiple frames", "bytes=50-250", 50, 251},
+		{"frame boundary", "bytes=99-100", 99, 101},
+		{"exact frame", "bytes=100-199", 100, 200},
+		{"open ended", "bytes=290-", 290, 300},
+		{"suffix", "bytes=-150", 150, 300},
+		{"capped", "bytes=250-1000", 250, 300},
+	} {
+		req := httptest.NewRequest(nethttp.MethodGet, "/", nil)
+		req.Header.Set("Range", tc.rangeHdr)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, nethttp.StatusPartialContent, rec.Code, tc.name)
+		assert.Equal(t, original[tc.start:tc.end], rec.Body.Bytes(), tc.name)
+		assert.Equal(t, strconv.Itoa(tc.end-tc.start), rec.Header().Get("Content-Length"), tc.name)
+		assert.Equal(t, fmt.Sprintf("bytes %d-%d/%d", tc.start, tc.end-1, len(original)),
+			rec.Header().Get("Content-Range"), tc.name)
+	}
+
+	// Whole stream.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodGet, "/", nil))
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+	assert.Equal(t, original, rec.Body.Bytes())
+	assert.Equal(t, strconv.Itoa(len(original)), rec.Header().Get("Content-Length"))
+	assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+	assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+
+	// HEAD has no body.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodHead, "/", nil))
+	assert.Equal(t, nethttp.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.Bytes())
+	assert.Equal(t, strconv.Itoa(len(original)), rec.Header().Get("Content-Length"))
+
+	// Range past the end.
+	req := httptest.NewRequest(nethttp.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=300-400")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusRequestedRangeNotSatisfiable, rec.Code)
+
+	// Unsupported method.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodPost, "/", nil))
+	assert.Equal(t, nethttp.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+}
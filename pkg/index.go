@@ -0,0 +1,145 @@
+package seekable
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/google/btree"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+const (
+	// indexMagic is the magic number of the index written by SaveIndex ("ZSIX").
+	indexMagic uint32 = 0x5849535A
+
+	indexHeaderSize = 4 + 1 + 8
+	indexEntrySize  = 8 + 8 + 8 + 4 + 4 + 4
+
+	indexChecksumFlag = 1 << 0
+)
+
+/*
+SaveIndex writes the decoder's index to w so that it can later be loaded with LoadIndex
+without parsing the seek table again.  The index is serialized as:
+
+	|`Magic_Number`|`Flags`|`Number_Of_Entries`|`Entries`                       |
+	|--------------|-------|-------------------|--------------------------------|
+	| 4 bytes      | 1 byte| 8 bytes           | 36 * `Number_Of_Entries` bytes |
+
+where each entry holds the fields of env.FrameOffsetEntry in the declaration order:
+
+	|`ID`   |`CompOffset`|`DecompOffset`|`CompSize`|`DecompSize`|`Checksum`|
+	|-------|------------|--------------|----------|------------|----------|
+	|8 bytes| 8 bytes    | 8 bytes      | 4 bytes  | 4 bytes    | 4 bytes  |
+
+All fields are little-endian, bit 0 of `Flags` is set if entries have checksums.
+Hierarchical indexes are not supported.
+*/
+func (r *readerImpl) SaveIndex(w io.Writer) error {
+	if r.hierarchical {
+		return fmt.Errorf("saving hierarchical index is not supported")
+	}
+	if r.cipher != nil {
+		return fmt.Errorf("saving index of encrypted stream is not supported")
+	}
+
+	var flags byte
+	if r.checksums {
+		flags |= indexChecksumFlag
+	}
+
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, 0, indexHeaderSize)
+	buf = binary.LittleEndian.AppendUint32(buf, indexMagic)
+	buf = append(buf, flags)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(r.numFrames))
+	if _, err := bw.Write(buf); err != nil {
+		return fmt.Errorf("failed to write index header: %w", err)
+	}
+
+	var err error
+	r.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		buf = buf[:0]
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(index.ID))
+		buf = binary.LittleEndian.AppendUint64(buf, index.CompOffset)
+		buf = binary.LittleEndian.AppendUint64(buf, index.DecompOffset)
+		buf = binary.LittleEndian.AppendUint32(buf, index.CompSize)
+		buf = binary.LittleEndian.AppendUint32(buf, index.DecompSize)
+		buf = binary.LittleEndian.AppendUint32(buf, index.Checksum)
+		_, err = bw.Write(buf)
+		return err == nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write index entry: %w", err)
+	}
+
+	if err = bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// LoadIndex creates a Decoder from an index previously written by Decoder's SaveIndex.
+// Entries are validated with VerifyIndex, the decoder and options are the same as for NewDecoder.
+func LoadIndex(r io.Reader, decoder ZSTDDecoder, opts ...rOption) (Decoder, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, indexHeaderSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("failed to read index header: %w", err)
+	}
+	if magic := binary.LittleEndian.Uint32(header[0:4]); magic != indexMagic {
+		return nil, fmt.Errorf("index magic mismatch: expected: %x, actual: %x", indexMagic, magic)
+	}
+	flags := header[4]
+	if flags&^indexChecksumFlag != 0 {
+		return nil, fmt.Errorf("unknown index flags: %x", flags)
+	}
+	numEntries := binary.LittleEndian.Uint64(header[5:13])
+	if numEntries > uint64(maxNumberOfFrames) {
+		return nil, fmt.Errorf("number of frames in index: %d > %d", numEntries, maxNumberOfFrames)
+	}
+
+	// Do not trust numEntries for the allocation size, the stream may be truncated.
+	entries := make([]env.FrameOffsetEntry, 0, min(numEntries, 1<<16))
+	buf := make([]byte, indexEntrySize)
+	for i := uint64(0); i < numEntries; i++ {
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, fmt.Errorf("failed to read index entry %d: %w", i, err)
+		}
+		entries = append(entries, env.FrameOffsetEntry{
+			ID:           int64(binary.LittleEndian.Uint64(buf[0:8])),
+			CompOffset:   binary.LittleEndian.Uint64(buf[8:16]),
+			DecompOffset: binary.LittleEndian.Uint64(buf[16:24]),
+			CompSize:     binary.LittleEndian.Uint32(buf[24:28]),
+			DecompSize:   binary.LittleEndian.Uint32(buf[28:32]),
+			Checksum:     binary.LittleEndian.Uint32(buf[32:36]),
+		})
+	}
+	if err := VerifyIndex(entries); err != nil {
+		return nil, fmt.Errorf("invalid index: %w", err)
+	}
+
+	checksums := flags&indexChecksumFlag != 0
+	seekTable, err := marshalSeekTable(nil, checksums)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewDecoder(seekTable, decoder, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := btree.NewG(8, env.Less)
+	var last *env.FrameOffsetEntry
+	for i := range entries {
+		last = &entries[i]
+		tree.ReplaceOrInsert(last)
+	}
+	sr := d.(*readerImpl)
+	sr.setIndex(tree, last)
+	return sr, nil
+}
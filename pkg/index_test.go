@@ -0,0 +1,130 @@
+package seekable
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLoadIndex(t *testing.T) {
+	t.Parallel()
+
+	b := NewSeekTableBuilder(true)
+	for _, size := range []uint32{4, 0, 5, 17, 0, 0, 3} {
+		b.AddFrame(size+9, size, size*7)
+	}
+	table, err := b.Bytes()
+	require.NoError(t, err)
+
+	for _, seekTable := range [][]byte{checksum[17+18:], noChecksum[17+18:], table} {
+		d, err := NewDecoder(seekTable, nil)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, d.SaveIndex(&buf))
+		assert.Equal(t, indexHeaderSize+indexEntrySize*int(d.NumFrames()), buf.Len())
+
+		loaded, err := LoadIndex(bytes.NewReader(buf.Bytes()), nil)
+		require.NoError(t, err)
+		assert.Equal(t, d.Size(), loaded.Size())
+		assert.Equal(t, d.NumFrames(), loaded.NumFrames())
+		for id := int64(-1); id <= d.NumFrames(); id++ {
+			assert.Equal(t, d.GetIndexByID(id), loaded.GetIndexByID(id), "id: %d", id)
+		}
+		for off := uint64(0); off <= uint64(d.Size()); off++ {
+			assert.Equal(t, d.GetIndexByDecompOffset(off), loaded.GetIndexByDecompOffset(off), "offset: %d", off)
+		}
+
+		marshaled, err := loaded.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, seekTable, marshaled)
+	}
+
+	// Loaded decoder decompresses.
+	d, err := NewDecoder(checksum[17+18:], nil)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, d.SaveIndex(&buf))
+	loaded, err := LoadIndex(&buf, nil, WithDefaultDecoder())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, loaded.Close()) }()
+	data, err := loaded.DecompressRange(NewReadSeekerEnv(bytes.NewReader(checksum)), 0, uint64(len(sourceString)))
+	require.NoError(t, err)
+	assert.Equal(t, sourceString, string(data))
+}
+
+func TestLoadIndexErrors(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDecoder(checksum[17+18:], nil)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, d.SaveIndex(&buf))
+	saved := buf.Bytes()
+
+	corrupt := func(i int, v byte) []byte {
+		b := bytes.Clone(saved)
+		b[i] = v
+		return b
+	}
+
+	for name, tc := range map[string]struct {
+		index []byte
+		err   string
+	}{
+		"empty":     {nil, "failed to read index header"},
+		"magic":     {corrupt(0, 0), "index magic mismatch"},
+		"flags":     {corrupt(4, 0x80), "unknown index flags"},
+		"frames":    {corrupt(12, 0xff), "number of frames in index"},
+		"truncated": {saved[:len(saved)-1], "failed to read index entry 1"},
+		"id":        {corrupt(indexHeaderSize, 1), "invalid index: frame 0: unexpected ID: 1"},
+		"offset": {
+			corrupt(indexHeaderSize+indexEntrySize+8, 0),
+			"invalid index: frame 1: compressed offset mismatch",
+		},
+	} {
+		_, err := LoadIndex(bytes.NewReader(tc.index), nil)
+		assert.ErrorContains(t, err, tc.err, name)
+	}
+}
+
+func BenchmarkLoadIndex(b *testing.B) {
+	rng := rand.New(rand.NewSource(0))
+	builder := NewSeekTableBuilder(true)
+	for i := 0; i < 1_000_000; i++ {
+		builder.AddFrame(uint32(20<<10+rng.Intn(1<<10)), 64<<10, rng.Uint32())
+	}
+	seekTable, err := builder.Bytes()
+	if err != nil {
+		b.Fatal(err)
+	}
+	d, err := NewDecoder(seekTable, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = d.SaveIndex(&buf); err != nil {
+		b.Fatal(err)
+	}
+	index := buf.Bytes()
+
+	b.Run("NewDecoder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := NewDecoder(seekTable, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("LoadIndex", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := LoadIndex(bytes.NewReader(index), nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
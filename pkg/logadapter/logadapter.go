@@ -0,0 +1,109 @@
+// Package logadapter implements zapcore.Core that forwards log entries to slog.Handler,
+// so that the standard library logger can be used where *zap.Logger is expected.
+package logadapter
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogCore forwards entries to the slog.Handler.  Fields are converted to attributes
+// in the same order, objects (e.g. the ones added with zap.Object) become groups.
+type SlogCore struct {
+	h slog.Handler
+}
+
+var _ zapcore.Core = (*SlogCore)(nil)
+
+// NewSlogCore returns the core that writes entries to h.
+func NewSlogCore(h slog.Handler) *SlogCore {
+	return &SlogCore{h: h}
+}
+
+// NewZapLogger returns *zap.Logger that writes entries to l.
+func NewZapLogger(l *slog.Logger) *zap.Logger {
+	return zap.New(NewSlogCore(l.Handler()))
+}
+
+func (c *SlogCore) Enabled(lvl zapcore.Level) bool {
+	return c.h.Enabled(context.Background(), slogLevel(lvl))
+}
+
+func (c *SlogCore) With(fields []zapcore.Field) zapcore.Core {
+	return &SlogCore{h: c.h.WithAttrs(attrs(fields))}
+}
+
+func (c *SlogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *SlogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	r := slog.NewRecord(ent.Time, slogLevel(ent.Level), ent.Message, 0)
+	if ent.LoggerName != "" {
+		r.AddAttrs(slog.String("logger", ent.LoggerName))
+	}
+	r.AddAttrs(attrs(fields)...)
+	return c.h.Handle(context.Background(), r)
+}
+
+func (c *SlogCore) Sync() error {
+	return nil
+}
+
+// slogLevel maps zap levels to slog ones, levels above Error are logged as Error
+// (zap itself panics or exits after writing them).
+func slogLevel(lvl zapcore.Level) slog.Level {
+	switch {
+	case lvl <= zapcore.DebugLevel:
+		return slog.LevelDebug
+	case lvl == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case lvl == zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// attrs converts fields to attributes with the help of zapcore.MapObjectEncoder.
+func attrs(fields []zapcore.Field) []slog.Attr {
+	res := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		for _, k := range sortedKeys(enc.Fields) {
+			res = append(res, attr(k, enc.Fields[k]))
+		}
+	}
+	return res
+}
+
+func attr(key string, v any) slog.Attr {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return slog.Any(key, v)
+	}
+
+	group := make([]any, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		group = append(group, attr(k, m[k]))
+	}
+	return slog.Group(key, group...)
+}
+
+// sortedKeys returns keys of m in the deterministic order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package logadapter_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/logadapter"
+)
+
+type testObject struct{}
+
+func (testObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("b", "2")
+	enc.AddInt("a", 1)
+	return nil
+}
+
+// records parses JSON lines written by slog.JSONHandler.
+func records(t *testing.T, b *bytes.Buffer) []map[string]any {
+	var res []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		res = append(res, m)
+	}
+	return res
+}
+
+func TestSlogCore(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&b, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger := logadapter.NewZapLogger(l).Named("test").With(zap.String("with", "value"))
+
+	logger.Debug("filtered")
+	logger.Info("info", zap.Int("int", 1), zap.Object("object", testObject{}))
+	logger.Warn("warn", zap.Error(errors.New("failed")))
+	logger.Error("error")
+
+	recs := records(t, &b)
+	require.Len(t, recs, 3)
+	for _, rec := range recs {
+		delete(rec, "time")
+	}
+	assert.Equal(t, []map[string]any{
+		{
+			"level": "INFO", "msg": "info", "logger": "test", "with": "value",
+			"int": 1.0, "object": map[string]any{"a": 1.0, "b": "2"},
+		},
+		{"level": "WARN", "msg": "warn", "logger": "test", "with": "value", "error": "failed"},
+		{"level": "ERROR", "msg": "error", "logger": "test", "with": "value"},
+	}, recs)
+}
+
+func TestSlogLogger(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var logs bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc, seekable.WithWSlogLogger(l))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	recs := records(t, &logs)
+	require.NotEmpty(t, recs)
+	assert.Equal(t, "appending frame", recs[0]["msg"])
+	require.IsType(t, map[string]any{}, recs[0]["frame"])
+	frame := recs[0]["frame"].(map[string]any)
+	for _, key := range []string{"CompressedSize", "DecompressedSize", "Checksum"} {
+		assert.Contains(t, frame, key)
+	}
+	assert.Equal(t, 4.0, frame["DecompressedSize"])
+
+	logs.Reset()
+	r, err := seekable.NewReader(bytes.NewReader(b.Bytes()), dec, seekable.WithRSlogLogger(l))
+	require.NoError(t, err)
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "test", string(all))
+	require.NoError(t, r.Close())
+
+	msgs := map[string]map[string]any{}
+	for _, rec := range records(t, &logs) {
+		msgs[rec["msg"].(string)] = rec
+	}
+	require.Contains(t, msgs, "loaded")
+	assert.Contains(t, msgs["loaded"]["footer"], "NumberOfFrames")
+	require.Contains(t, msgs, "decompressed")
+	for _, key := range []string{"offsetWithinFrame", "end", "size", "lenDecompressed", "lenDst", "index"} {
+		assert.Contains(t, msgs["decompressed"], key)
+	}
+}
@@ -0,0 +1,104 @@
+package seekable
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/google/btree"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// mergedEnv reads frames of the virtual stream that is the concatenation of shards.
+type mergedEnv struct {
+	decoderEnv
+	shards []env.REnvironment
+	// compBases are offsets of shards in the virtual compressed stream.
+	compBases []uint64
+}
+
+func (m *mergedEnv) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	i := sort.Search(len(m.compBases), func(i int) bool { return m.compBases[i] > index.CompOffset }) - 1
+	if i < 0 {
+		return nil, fmt.Errorf("frame %d: offset %d is out of bounds", index.ID, index.CompOffset)
+	}
+	index.CompOffset -= m.compBases[i]
+	return m.shards[i].GetFrameByIndex(index)
+}
+
+// NewMergedReader returns the Reader of the virtual stream that is the concatenation of shards,
+// each of which is a seekable stream, e.g. the parts of a large file compressed independently.
+// Seek tables of shards are combined in memory, so that frame ids and offsets are the ones of
+// the shards concatenated in order, and frames are read from the shard they belong to.
+// The returned Reader also implements Decoder, whose Size is the total decompressed size of shards.
+//
+// Checksums are verified only if all shards have them.  Shards with hierarchical index are not supported.
+func NewMergedReader(shards []io.ReadSeeker, decoder ZSTDDecoder) (Reader, error) {
+	m := &mergedEnv{
+		shards:    make([]env.REnvironment, 0, len(shards)),
+		compBases: make([]uint64, 0, len(shards)),
+	}
+	tree := btree.NewG(8, env.Less)
+	var last *env.FrameOffsetEntry
+	var compBase, decompBase uint64
+	checksums := true
+	for i, shard := range shards {
+		sr, err := NewReader(shard, decoder)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: failed to open stream: %w", i, err)
+		}
+		r := sr.(*readerImpl)
+		if r.hierarchical {
+			_ = r.Close()
+			return nil, fmt.Errorf("shard %d: hierarchical index is not supported", i)
+		}
+		checksums = checksums && r.checksums
+
+		var nextID int64
+		if last != nil {
+			nextID = last.ID + 1
+		}
+		if nextID+r.numFrames > maxNumberOfFrames {
+			_ = r.Close()
+			return nil, fmt.Errorf("number of frames in merged stream: %d > %d",
+				nextID+r.numFrames, maxNumberOfFrames)
+		}
+		r.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+			last = &env.FrameOffsetEntry{
+				ID:           nextID + index.ID,
+				CompOffset:   compBase + index.CompOffset,
+				DecompOffset: decompBase + index.DecompOffset,
+				CompSize:     index.CompSize,
+				DecompSize:   index.DecompSize,
+				Checksum:     index.Checksum,
+			}
+			tree.ReplaceOrInsert(last)
+			return true
+		})
+		decompBase += uint64(r.Size())
+		_ = r.Close()
+
+		size, err := shard.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: failed to get size: %w", i, err)
+		}
+		m.shards = append(m.shards, NewReadSeekerEnv(shard))
+		m.compBases = append(m.compBases, compBase)
+		compBase += uint64(size)
+	}
+
+	seekTable, err := marshalSeekTable(nil, checksums)
+	if err != nil {
+		return nil, err
+	}
+	m.seekTable = seekTable
+
+	sr, err := NewReader(nil, decoder, WithREnvironment(m))
+	if err != nil {
+		return nil, err
+	}
+	r := sr.(*readerImpl)
+	r.setIndex(tree, last)
+	return r, nil
+}
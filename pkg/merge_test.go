@@ -0,0 +1,91 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergedReader(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	const numFrames, frameSize = 9, 1000
+	rng := rand.New(rand.NewSource(1))
+	var src bytes.Buffer
+	w, err := NewWriter(&src, enc)
+	require.NoError(t, err)
+	var original []byte
+	for i := 0; i < numFrames; i++ {
+		frame := make([]byte, frameSize)
+		_, _ = rng.Read(frame[:frameSize/2])
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		original = append(original, frame...)
+	}
+	require.NoError(t, w.Close())
+
+	var segments []*bytes.Buffer
+	err = Split(bytes.NewReader(src.Bytes()), dec, enc, 3*frameSize, func(i int) io.Writer {
+		segments = append(segments, &bytes.Buffer{})
+		return segments[i]
+	})
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+
+	shards := make([]io.ReadSeeker, 0, len(segments))
+	for _, segment := range segments {
+		shards = append(shards, bytes.NewReader(segment.Bytes()))
+	}
+	r, err := NewMergedReader(shards, dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	d := r.(Decoder)
+	assert.Equal(t, int64(len(original)), d.Size())
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, original, all)
+
+	// Reads across shard boundaries.
+	buf := make([]byte, frameSize)
+	n, err := r.ReadAt(buf, 3*frameSize-frameSize/2)
+	require.NoError(t, err)
+	assert.Equal(t, frameSize, n)
+	assert.Equal(t, original[3*frameSize-frameSize/2:3*frameSize+frameSize/2], buf)
+
+	assert.Equal(t, int64(numFrames), d.NumFrames())
+	index := d.GetIndexByID(3)
+	require.NotNil(t, index)
+	assert.Equal(t, uint64(segments[0].Len()), index.CompOffset)
+	assert.Equal(t, uint64(3*frameSize), index.DecompOffset)
+
+	// Shards without checksums and empty shards.
+	var empty bytes.Buffer
+	ew, err := NewWriter(&empty, enc)
+	require.NoError(t, err)
+	require.NoError(t, ew.Close())
+	mixed, err := NewMergedReader([]io.ReadSeeker{
+		bytes.NewReader(checksum),
+		bytes.NewReader(empty.Bytes()),
+		bytes.NewReader(noChecksum),
+	}, dec)
+	require.NoError(t, err)
+	all, err = io.ReadAll(mixed)
+	require.NoError(t, err)
+	assert.Equal(t, sourceString+sourceString, string(all))
+	require.NoError(t, mixed.Close())
+
+	_, err = NewMergedReader([]io.ReadSeeker{shards[0], bytes.NewReader([]byte("not seekable"))}, dec)
+	assert.ErrorContains(t, err, "shard 1: failed to open stream")
+}
@@ -0,0 +1,168 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// frameMetadata is a key-value pair passed to WithFrameMetadata.
+type frameMetadata struct {
+	key   string
+	value []byte
+}
+
+/*
+marshalMetadata serializes a key-value pair into a metadata skippable frame (tagged with metadataTag):
+
+	|`Skippable_Magic_Number`|`Frame_Size`|`Key_Size`|`Key`   |`Value_Size`|`Value` |
+	|------------------------|------------|----------|--------|------------|--------|
+	| 4 bytes                | 4 bytes    | 4 bytes  | n bytes| 4 bytes    | m bytes|
+
+Metadata frames are written right before the seek table and have their own entries in it
+with `Decompressed_Size` of 0, so that offsets of the following frames stay correct.
+*/
+func marshalMetadata(m frameMetadata) ([]byte, error) {
+	if int64(len(m.key))+int64(len(m.value)) > maxChunkSize-2*metadataSizeFieldSize {
+		return nil, fmt.Errorf("metadata is too big: key: %d, value: %d", len(m.key), len(m.value))
+	}
+
+	payload := make([]byte, 0, 2*metadataSizeFieldSize+len(m.key)+len(m.value))
+	payload = binary.LittleEndian.AppendUint32(payload, uint32(len(m.key)))
+	payload = append(payload, m.key...)
+	payload = binary.LittleEndian.AppendUint32(payload, uint32(len(m.value)))
+	payload = append(payload, m.value...)
+	return createSkippableFrame(metadataTag, payload)
+}
+
+// unmarshalMetadata parses the metadata skippable frame created by marshalMetadata.
+func unmarshalMetadata(frame []byte) (frameMetadata, error) {
+	if len(frame) < skippableMagicNumberFieldSize+frameSizeFieldSize {
+		return frameMetadata{}, fmt.Errorf("metadata frame is too small: %d", len(frame))
+	}
+	if magic := binary.LittleEndian.Uint32(frame); magic != skippableFrameMagic+metadataTag {
+		return frameMetadata{}, fmt.Errorf("metadata magic mismatch: %d vs %d", magic, skippableFrameMagic+metadataTag)
+	}
+	payload := frame[skippableMagicNumberFieldSize+frameSizeFieldSize:]
+	if frameSize := binary.LittleEndian.Uint32(frame[skippableMagicNumberFieldSize:]); int(frameSize) != len(payload) {
+		return frameMetadata{}, fmt.Errorf("metadata frame size mismatch: expected: %d, actual: %d", len(payload), frameSize)
+	}
+
+	field := func() ([]byte, error) {
+		if len(payload) < metadataSizeFieldSize {
+			return nil, fmt.Errorf("metadata is truncated")
+		}
+		size := binary.LittleEndian.Uint32(payload)
+		payload = payload[metadataSizeFieldSize:]
+		if uint64(size) > uint64(len(payload)) {
+			return nil, fmt.Errorf("metadata is truncated")
+		}
+		f := payload[:size]
+		payload = payload[size:]
+		return f, nil
+	}
+
+	key, err := field()
+	if err != nil {
+		return frameMetadata{}, err
+	}
+	value, err := field()
+	if err != nil {
+		return frameMetadata{}, err
+	}
+	if len(payload) != 0 {
+		return frameMetadata{}, fmt.Errorf("metadata has %d trailing bytes", len(payload))
+	}
+	return frameMetadata{key: string(key), value: value}, nil
+}
+
+// writeMetadata writes metadata frames and adds their entries to the seek table.
+func (s *writerImpl) writeMetadata() error {
+	if len(s.metadata) == 0 {
+		return nil
+	}
+	if err := s.writeMagicPrefix(); err != nil {
+		return err
+	}
+
+	for _, m := range s.metadata {
+		frame, err := marshalMetadata(m)
+		if err != nil {
+			return err
+		}
+
+		n, err := s.env.WriteFrame(frame)
+		if err != nil {
+			return fmt.Errorf("failed to write metadata: %w", err)
+		}
+		if n != len(frame) {
+			return fmt.Errorf("partial write: %d out of %d", n, len(frame))
+		}
+
+		s.frameEntries = append(s.frameEntries, seekTableEntry{
+			CompressedSize: uint32(len(frame)),
+			Checksum:       uint32((xxhash.Sum64(nil) << 32) >> 32),
+		})
+	}
+	return nil
+}
+
+// GetFrameMetadata returns the value stored with WithFrameMetadata for the key.
+// Frames preceding the seek table are scanned backward until the key is found
+// or a frame with data is reached.
+func GetFrameMetadata(rs io.ReadSeeker, key string) ([]byte, error) {
+	r, err := NewReader(rs, nil, WithDefaultDecoder())
+	if err != nil {
+		return nil, err
+	}
+	sr := r.(*readerImpl)
+	defer sr.Close()
+
+	if sr.hierarchical {
+		return nil, fmt.Errorf("hierarchical index is not supported")
+	}
+
+	var value []byte
+	sr.index.Descend(func(index *env.FrameOffsetEntry) bool {
+		if index.DecompSize != 0 {
+			return false
+		}
+		if index.CompSize < skippableMagicNumberFieldSize+frameSizeFieldSize {
+			return true
+		}
+
+		var frame []byte
+		frame, err = sr.env.GetFrameByIndex(*index)
+		if err != nil {
+			err = fmt.Errorf("failed to read frame %d: %w", index.ID, err)
+			return false
+		}
+		// Other skippable frames are ignored.
+		if binary.LittleEndian.Uint32(frame) != skippableFrameMagic+metadataTag {
+			return true
+		}
+
+		var m frameMetadata
+		m, err = unmarshalMetadata(frame)
+		if err != nil {
+			err = fmt.Errorf("failed to parse frame %d: %w", index.ID, err)
+			return false
+		}
+		if m.key == key {
+			value = m.value
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, fmt.Errorf("metadata key not found: %q", key)
+	}
+	return value, nil
+}
@@ -0,0 +1,98 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameMetadata(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = NewWriter(io.Discard, enc, WithFrameMetadata("key", nil), WithFrameMetadata("key", nil))
+	require.ErrorContains(t, err, "duplicate metadata key: \"key\"")
+	_, err = NewWriter(io.Discard, enc, WithFrameMetadata("key", nil), WithHierarchicalIndex(2))
+	require.ErrorContains(t, err, "frame metadata can not be used with hierarchical index")
+
+	for _, opts := range [][]wOption{nil, {WithChunkedSeekTable(2)}, {WithVarintSeekTable()}, {WithCompressSeekTable()}} {
+		var b bytes.Buffer
+		w, err := NewWriter(&b, enc, append(opts,
+			WithFrameMetadata("name", []byte("test.txt")),
+			WithFrameMetadata("empty", []byte{}))...)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("test"))
+		require.NoError(t, err)
+		_, err = w.Write([]byte("test2"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		value, err := GetFrameMetadata(bytes.NewReader(b.Bytes()), "name")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("test.txt"), value)
+		value, err = GetFrameMetadata(bytes.NewReader(b.Bytes()), "empty")
+		require.NoError(t, err)
+		assert.Equal(t, []byte{}, value)
+		_, err = GetFrameMetadata(bytes.NewReader(b.Bytes()), "missing")
+		require.ErrorContains(t, err, "metadata key not found: \"missing\"")
+
+		// Metadata frames are skipped by seekable readers.
+		r, err := NewReader(bytes.NewReader(b.Bytes()), dec, WithSizeValidation())
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), r.(Decoder).NumFrames())
+		all, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(sourceString), all)
+		require.NoError(t, r.Close())
+
+		// And by regular ZSTD decoders.
+		require.NoError(t, dec.Reset(bytes.NewReader(b.Bytes())))
+		all, err = io.ReadAll(dec)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(sourceString), all)
+	}
+
+	// Streams without metadata.
+	_, err = GetFrameMetadata(bytes.NewReader(checksum), "name")
+	require.ErrorContains(t, err, "metadata key not found")
+}
+
+func TestMetadataMarshaling(t *testing.T) {
+	t.Parallel()
+
+	frame, err := marshalMetadata(frameMetadata{key: "key", value: []byte("value")})
+	require.NoError(t, err)
+	m, err := unmarshalMetadata(frame)
+	require.NoError(t, err)
+	assert.Equal(t, frameMetadata{key: "key", value: []byte("value")}, m)
+
+	_, err = unmarshalMetadata(frame[:4])
+	require.ErrorContains(t, err, "metadata frame is too small")
+	_, err = unmarshalMetadata(frame[:len(frame)-1])
+	require.ErrorContains(t, err, "metadata frame size mismatch")
+
+	truncated, err := createSkippableFrame(metadataTag, frame[8:len(frame)-1])
+	require.NoError(t, err)
+	_, err = unmarshalMetadata(truncated)
+	require.ErrorContains(t, err, "metadata is truncated")
+
+	trailing, err := createSkippableFrame(metadataTag, append(frame[8:], 0))
+	require.NoError(t, err)
+	_, err = unmarshalMetadata(trailing)
+	require.ErrorContains(t, err, "metadata has 1 trailing bytes")
+
+	other, err := createSkippableFrame(metadataTag-1, frame[8:])
+	require.NoError(t, err)
+	_, err = unmarshalMetadata(other)
+	require.ErrorContains(t, err, "metadata magic mismatch")
+}
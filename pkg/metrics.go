@@ -0,0 +1,23 @@
+package seekable
+
+// MetricsObserver receives events of readers and writers, e.g. for exporting them to a monitoring system,
+// see WithRMetrics and WithWMetrics.
+//
+// Methods are called synchronously, possibly from multiple goroutines,
+// so implementations must be goroutine-safe and should not block.
+type MetricsObserver interface {
+	// OnFrameRead is called each time the reader accesses the frame.  cacheHit is set if
+	// the frame was served from the frame cache (see WithCacheSize) instead of being decompressed.
+	OnFrameRead(frameID int64, cacheHit bool, compressedBytes, decompressedBytes uint64)
+	// OnFrameWritten is called when the compressed frame is added to the seek table.
+	OnFrameWritten(frameID int64, compressedBytes, decompressedBytes uint64)
+	// OnSeek is called by Reader.Seek with the offsets of the decompressed stream before and after the seek.
+	OnSeek(from, to int64)
+}
+
+// nopMetrics is the MetricsObserver that ignores all events.
+type nopMetrics struct{}
+
+func (nopMetrics) OnFrameRead(int64, bool, uint64, uint64) {}
+func (nopMetrics) OnFrameWritten(int64, uint64, uint64)    {}
+func (nopMetrics) OnSeek(int64, int64)                     {}
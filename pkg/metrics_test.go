@@ -0,0 +1,114 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type frameReadEvent struct {
+	frameID              int64
+	cacheHit             bool
+	compSize, decompSize uint64
+}
+
+type frameWrittenEvent struct {
+	frameID              int64
+	compSize, decompSize uint64
+}
+
+type seekEvent struct {
+	from, to int64
+}
+
+// recordingMetrics accumulates calls of MetricsObserver.
+type recordingMetrics struct {
+	mu     sync.Mutex
+	reads  []frameReadEvent
+	writes []frameWrittenEvent
+	seeks  []seekEvent
+}
+
+func (m *recordingMetrics) OnFrameRead(frameID int64, cacheHit bool, compressedBytes, decompressedBytes uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads = append(m.reads, frameReadEvent{frameID, cacheHit, compressedBytes, decompressedBytes})
+}
+
+func (m *recordingMetrics) OnFrameWritten(frameID int64, compressedBytes, decompressedBytes uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writes = append(m.writes, frameWrittenEvent{frameID, compressedBytes, decompressedBytes})
+}
+
+func (m *recordingMetrics) OnSeek(from, to int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seeks = append(m.seeks, seekEvent{from, to})
+}
+
+func TestReaderMetrics(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	m := &recordingMetrics{}
+	r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, dec, WithRMetrics(m))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	tmp := make([]byte, 3)
+	for _, step := range []func() error{
+		func() error { _, err := r.Read(tmp); return err },
+		func() error { _, err := r.Read(tmp); return err },
+		func() error { _, err := r.Seek(6, io.SeekStart); return err },
+		func() error { _, err := r.Read(tmp); return err },
+		func() error { _, err := r.Seek(-9, io.SeekCurrent); return err },
+		func() error { _, err := r.ReadAt(tmp, 0); return err },
+	} {
+		require.NoError(t, step())
+	}
+
+	assert.Equal(t, []frameReadEvent{
+		{frameID: 0, cacheHit: false, compSize: 17, decompSize: 4},
+		// Read stops at the frame boundary.
+		{frameID: 0, cacheHit: true, compSize: 17, decompSize: 4},
+		{frameID: 1, cacheHit: false, compSize: 18, decompSize: 5},
+		{frameID: 0, cacheHit: false, compSize: 17, decompSize: 4},
+	}, m.reads)
+	assert.Equal(t, []seekEvent{{from: 4, to: 6}, {from: 9, to: 0}}, m.seeks)
+	assert.Empty(t, m.writes)
+}
+
+func TestWriterMetrics(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	m := &recordingMetrics{}
+	w, err := NewWriter(&b, enc, WithWMetrics(m))
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test2"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, checksum, b.Bytes())
+
+	assert.Equal(t, []frameWrittenEvent{
+		{frameID: 0, compSize: 17, decompSize: 4},
+		{frameID: 1, compSize: 18, decompSize: 5},
+	}, m.writes)
+	assert.Empty(t, m.reads)
+	assert.Empty(t, m.seeks)
+}
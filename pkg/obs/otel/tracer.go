@@ -0,0 +1,67 @@
+// Package otel implements seekable.FrameTracer on top of OpenTelemetry,
+// so that time spent compressing and decompressing individual frames shows up in distributed traces.
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+const (
+	decompressFrameSpan = "seekable.decompress_frame"
+	compressFrameSpan   = "seekable.compress_frame"
+)
+
+// OTelTracer records frame reads and writes as spans named `seekable.decompress_frame` and
+// `seekable.compress_frame` with `frame.id`, `frame.comp_size` and `frame.decomp_size` attributes.
+// Frame reads additionally have the `cache.hit` attribute.
+//
+// Spans are children of the span in the context passed to the reader or writer method, e.g. WriteCtx,
+// WriteMany, EncodeBatch or Prefetch.  Methods without a context, e.g. Read and ReadAt, start root spans.
+type OTelTracer struct {
+	tracer trace.Tracer
+}
+
+var _ seekable.FrameTracer = (*OTelTracer)(nil)
+
+// NewOTelTracer creates spans with tracer, e.g. otel.Tracer("seekable").
+func NewOTelTracer(tracer trace.Tracer) *OTelTracer {
+	return &OTelTracer{tracer: tracer}
+}
+
+func (t *OTelTracer) StartFrameRead(ctx context.Context, index env.FrameOffsetEntry) func(bool, error) {
+	_, span := t.tracer.Start(ctx, decompressFrameSpan, trace.WithAttributes(
+		attribute.Int64("frame.id", index.ID),
+		attribute.Int64("frame.comp_size", int64(index.CompSize)),
+		attribute.Int64("frame.decomp_size", int64(index.DecompSize)),
+	))
+	return func(cacheHit bool, err error) {
+		span.SetAttributes(attribute.Bool("cache.hit", cacheHit))
+		end(span, err)
+	}
+}
+
+func (t *OTelTracer) StartFrameWrite(ctx context.Context, frameID int64, decompressedBytes uint64) func(uint64, error) {
+	_, span := t.tracer.Start(ctx, compressFrameSpan, trace.WithAttributes(
+		attribute.Int64("frame.id", frameID),
+		attribute.Int64("frame.decomp_size", int64(decompressedBytes)),
+	))
+	return func(compressedBytes uint64, err error) {
+		span.SetAttributes(attribute.Int64("frame.comp_size", int64(compressedBytes)))
+		end(span, err)
+	}
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,84 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+func TestOTelTracer(t *testing.T) {
+	t.Parallel()
+
+	sr := tracetest.NewSpanRecorder()
+	otelTracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+	tracer := NewOTelTracer(otelTracer)
+
+	// Writer spans are children of the span in the context.
+	ctx, parent := otelTracer.Start(context.Background(), "parent")
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc, seekable.WithWTracer(tracer))
+	require.NoError(t, err)
+	for _, s := range []string{"test", "test2"} {
+		_, err = w.WriteCtx(ctx, []byte(s))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	parent.End()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+	r, err := seekable.NewReader(bytes.NewReader(b.Bytes()), dec,
+		seekable.WithRTracer(tracer), seekable.WithCacheSize(2))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	tmp := make([]byte, 9)
+	_, err = r.ReadAt(tmp, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "testtest2", string(tmp))
+	_, err = r.ReadAt(tmp[:1], 0)
+	require.NoError(t, err)
+
+	type span struct {
+		name   string
+		parent bool
+		attrs  []attribute.KeyValue
+	}
+	var actual []span
+	for _, s := range sr.Ended() {
+		actual = append(actual, span{s.Name(), s.Parent().SpanID() == parent.SpanContext().SpanID(), s.Attributes()})
+	}
+	assert.Equal(t, []span{
+		{compressFrameSpan, true, []attribute.KeyValue{
+			attribute.Int64("frame.id", 0), attribute.Int64("frame.decomp_size", 4), attribute.Int64("frame.comp_size", 17),
+		}},
+		{compressFrameSpan, true, []attribute.KeyValue{
+			attribute.Int64("frame.id", 1), attribute.Int64("frame.decomp_size", 5), attribute.Int64("frame.comp_size", 18),
+		}},
+		{"parent", false, nil},
+		{decompressFrameSpan, false, []attribute.KeyValue{
+			attribute.Int64("frame.id", 0), attribute.Int64("frame.comp_size", 17), attribute.Int64("frame.decomp_size", 4),
+			attribute.Bool("cache.hit", false),
+		}},
+		{decompressFrameSpan, false, []attribute.KeyValue{
+			attribute.Int64("frame.id", 1), attribute.Int64("frame.comp_size", 18), attribute.Int64("frame.decomp_size", 5),
+			attribute.Bool("cache.hit", false),
+		}},
+		{decompressFrameSpan, false, []attribute.KeyValue{
+			attribute.Int64("frame.id", 0), attribute.Int64("frame.comp_size", 17), attribute.Int64("frame.decomp_size", 4),
+			attribute.Bool("cache.hit", true),
+		}},
+	}, actual)
+}
@@ -0,0 +1,112 @@
+// Package prometheus implements seekable.MetricsObserver on top of Prometheus counters and histograms,
+// so that frame cache efficiency, decompression throughput and seek patterns can be monitored.
+package prometheus
+
+import (
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+const subsystem = "zstd_seekable"
+
+// PrometheusObserver exports events of seekable readers and writers as Prometheus metrics.
+// It is a prometheus.Collector, so it needs to be registered, e.g. with prometheus.MustRegister.
+// The same observer can be shared between multiple readers and writers.
+type PrometheusObserver struct {
+	cacheHits             prom.Counter
+	cacheMisses           prom.Counter
+	compressedBytesRead   prom.Counter
+	decompressedBytesRead prom.Counter
+
+	framesWritten            prom.Counter
+	compressedBytesWritten   prom.Counter
+	decompressedBytesWritten prom.Counter
+
+	seekDistance prom.Histogram
+}
+
+var (
+	_ seekable.MetricsObserver = (*PrometheusObserver)(nil)
+	_ prom.Collector           = (*PrometheusObserver)(nil)
+)
+
+// NewPrometheusObserver creates metrics named `<namespace>_zstd_seekable_*`.
+func NewPrometheusObserver(namespace string) *PrometheusObserver {
+	counter := func(name, help string) prom.Counter {
+		return prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		})
+	}
+
+	return &PrometheusObserver{
+		cacheHits:   counter("frame_cache_hits_total", "Number of frame reads served from the frame cache."),
+		cacheMisses: counter("frame_cache_misses_total", "Number of frame reads that required decompression."),
+		compressedBytesRead: counter("compressed_bytes_read_total",
+			"Compressed size of frames read from the underlying storage."),
+		decompressedBytesRead: counter("decompressed_bytes_read_total",
+			"Decompressed size of frames read from the underlying storage."),
+
+		framesWritten: counter("frames_written_total", "Number of written frames."),
+		compressedBytesWritten: counter("compressed_bytes_written_total",
+			"Compressed size of written frames."),
+		decompressedBytesWritten: counter("decompressed_bytes_written_total",
+			"Decompressed size of written frames."),
+
+		seekDistance: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "seek_distance_bytes",
+			Help:      "Absolute distance of seeks within the decompressed stream.",
+			Buckets:   prom.ExponentialBuckets(1, 8, 12),
+		}),
+	}
+}
+
+func (o *PrometheusObserver) collectors() []prom.Collector {
+	return []prom.Collector{
+		o.cacheHits, o.cacheMisses, o.compressedBytesRead, o.decompressedBytesRead,
+		o.framesWritten, o.compressedBytesWritten, o.decompressedBytesWritten,
+		o.seekDistance,
+	}
+}
+
+func (o *PrometheusObserver) Describe(ch chan<- *prom.Desc) {
+	for _, c := range o.collectors() {
+		c.Describe(ch)
+	}
+}
+
+func (o *PrometheusObserver) Collect(ch chan<- prom.Metric) {
+	for _, c := range o.collectors() {
+		c.Collect(ch)
+	}
+}
+
+// OnFrameRead counts bytes only for cache misses, since cache hits do not touch the underlying storage.
+func (o *PrometheusObserver) OnFrameRead(_ int64, cacheHit bool, compressedBytes, decompressedBytes uint64) {
+	if cacheHit {
+		o.cacheHits.Inc()
+		return
+	}
+	o.cacheMisses.Inc()
+	o.compressedBytesRead.Add(float64(compressedBytes))
+	o.decompressedBytesRead.Add(float64(decompressedBytes))
+}
+
+func (o *PrometheusObserver) OnFrameWritten(_ int64, compressedBytes, decompressedBytes uint64) {
+	o.framesWritten.Inc()
+	o.compressedBytesWritten.Add(float64(compressedBytes))
+	o.decompressedBytesWritten.Add(float64(decompressedBytes))
+}
+
+func (o *PrometheusObserver) OnSeek(from, to int64) {
+	distance := to - from
+	if distance < 0 {
+		distance = -distance
+	}
+	o.seekDistance.Observe(float64(distance))
+}
@@ -0,0 +1,85 @@
+package prometheus
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	seekable "github.com/SaveTheRbtz/zstd-seekable-format-go/pkg"
+)
+
+func TestPrometheusObserver(t *testing.T) {
+	t.Parallel()
+
+	obs := NewPrometheusObserver("test")
+	reg := prom.NewPedanticRegistry()
+	require.NoError(t, reg.Register(obs))
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	var b bytes.Buffer
+	w, err := seekable.NewWriter(&b, enc, seekable.WithWMetrics(obs))
+	require.NoError(t, err)
+	for _, s := range []string{"test", "test2"} {
+		_, err = w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+	r, err := seekable.NewReader(bytes.NewReader(b.Bytes()), dec, seekable.WithRMetrics(obs))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	tmp := make([]byte, 2)
+	// Frame 0 is decompressed once and then served from the cache.
+	for i := 0; i < 2; i++ {
+		_, err = r.Read(tmp)
+		require.NoError(t, err)
+	}
+	_, err = r.Seek(8, io.SeekStart)
+	require.NoError(t, err)
+	_, err = r.Read(tmp[:1])
+	require.NoError(t, err)
+	_, err = r.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(obs.cacheHits))
+	assert.Equal(t, 2.0, testutil.ToFloat64(obs.cacheMisses))
+	assert.Equal(t, 35.0, testutil.ToFloat64(obs.compressedBytesRead))
+	assert.Equal(t, 9.0, testutil.ToFloat64(obs.decompressedBytesRead))
+	assert.Equal(t, 2.0, testutil.ToFloat64(obs.framesWritten))
+	assert.Equal(t, 35.0, testutil.ToFloat64(obs.compressedBytesWritten))
+	assert.Equal(t, 9.0, testutil.ToFloat64(obs.decompressedBytesWritten))
+
+	n, err := testutil.GatherAndCount(reg)
+	require.NoError(t, err)
+	assert.Equal(t, 8, n)
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+# HELP test_zstd_seekable_seek_distance_bytes Absolute distance of seeks within the decompressed stream.
+# TYPE test_zstd_seekable_seek_distance_bytes histogram
+test_zstd_seekable_seek_distance_bytes_bucket{le="1"} 0
+test_zstd_seekable_seek_distance_bytes_bucket{le="8"} 1
+test_zstd_seekable_seek_distance_bytes_bucket{le="64"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="512"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="4096"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="32768"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="262144"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="2.097152e+06"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="1.6777216e+07"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="1.34217728e+08"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="1.073741824e+09"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="8.589934592e+09"} 2
+test_zstd_seekable_seek_distance_bytes_bucket{le="+Inf"} 2
+test_zstd_seekable_seek_distance_bytes_sum 13
+test_zstd_seekable_seek_distance_bytes_count 2
+`), "test_zstd_seekable_seek_distance_bytes"))
+}
@@ -1,49 +1,42 @@
 package seekable
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"sync"
+	"time"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/google/btree"
+	"github.com/klauspost/compress/zstd"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
 )
 
-type cachedFrame struct {
-	m sync.Mutex
-
-	offset uint64
-	data   []byte
-}
-
-func (f *cachedFrame) replace(offset uint64, data []byte) {
-	f.m.Lock()
-	defer f.m.Unlock()
-
-	f.offset = offset
-	f.data = data
+// ReadSeekerEnv is the environment implementation for the io.ReadSeeker.
+// This is what NewReader uses when no environment is passed.  It can be used as
+// a fallback by custom environments, e.g. the ones that add caching.
+type ReadSeekerEnv struct {
+	rs io.ReadSeeker
 }
 
-func (f *cachedFrame) get() (uint64, []byte) {
-	f.m.Lock()
-	defer f.m.Unlock()
-
-	return f.offset, f.data
-}
+var _ env.REnvironment = (*ReadSeekerEnv)(nil)
 
-// readSeekerEnvImpl is the environment implementation for the io.ReadSeeker.
-type readSeekerEnvImpl struct {
-	rs io.ReadSeeker
+// NewReadSeekerEnv returns the environment that reads frames from the io.ReadSeeker.
+// Passed io.ReadSeeker should ideally implement io.ReaderAt interface.
+func NewReadSeekerEnv(rs io.ReadSeeker) env.REnvironment {
+	return &ReadSeekerEnv{rs: rs}
 }
 
-func (rs *readSeekerEnvImpl) GetFrameByIndex(index env.FrameOffsetEntry) (p []byte, err error) {
+func (rs *ReadSeekerEnv) GetFrameByIndex(index env.FrameOffsetEntry) (p []byte, err error) {
 	p = make([]byte, index.CompSize)
 	off := int64(index.CompOffset)
 
@@ -64,7 +57,7 @@ func (rs *readSeekerEnvImpl) GetFrameByIndex(index env.FrameOffsetEntry) (p []by
 	return
 }
 
-func (rs *readSeekerEnvImpl) ReadFooter() ([]byte, error) {
+func (rs *ReadSeekerEnv) ReadFooter() ([]byte, error) {
 	n, err := rs.rs.Seek(-seekTableFooterOffset, io.SeekEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to seek to: %d: %w", -seekTableFooterOffset, err)
@@ -79,7 +72,7 @@ func (rs *readSeekerEnvImpl) ReadFooter() ([]byte, error) {
 	return buf, nil
 }
 
-func (rs *readSeekerEnvImpl) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
+func (rs *ReadSeekerEnv) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
 	n, err := rs.rs.Seek(-skippableFrameOffset, io.SeekEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to seek to: %d: %w", -skippableFrameOffset, err)
@@ -93,11 +86,27 @@ func (rs *readSeekerEnvImpl) ReadSkipFrame(skippableFrameOffset int64) ([]byte,
 	return buf, nil
 }
 
+// seekTableBytesEnv reads frames from the underlying environment
+// and the seek table from the bytes passed out-of-band.
+type seekTableBytesEnv struct {
+	env.REnvironment
+	seekTable decoderEnv
+}
+
+func (e *seekTableBytesEnv) ReadFooter() ([]byte, error) {
+	return e.seekTable.ReadFooter()
+}
+
+func (e *seekTableBytesEnv) ReadSkipFrame(skippableFrameOffset int64) ([]byte, error) {
+	return e.seekTable.ReadSkipFrame(skippableFrameOffset)
+}
+
 type readerImpl struct {
 	dec   ZSTDDecoder
 	index *btree.BTreeG[*env.FrameOffsetEntry]
 
-	checksums bool
+	checksums      bool
+	forceChecksums bool
 
 	offset int64
 
@@ -109,26 +118,91 @@ type readerImpl struct {
 
 	closed atomic.Bool
 
-	// TODO: Add simple LRU cache.
-	cachedFrame cachedFrame
+	// defaultDecoder requests creation of ownDec if no decoder was passed.
+	defaultDecoder bool
+	// hmacKey requires the seek table to be signed, see WithRHMAC.
+	hmacKey []byte
+	// dict requests creation of ownDec with the dictionary, replacing the passed decoder.
+	dict []byte
+	// encryptionKey requires the stream to be encrypted, see WithREncryption.
+	encryptionKey []byte
+	// cipher decrypts frames, it is set once the encrypted seek table is read.
+	cipher *frameCipher
+	// ownDec is the decoder created by the reader itself and released on Close.
+	ownDec *zstd.Decoder
+
+	decompressionTimeout time.Duration
+
+	// maxFrameSize limits the compressed size of frames, see WithMaxFrameSize.
+	maxFrameSize int64
+
+	hooks   TelemetryHooks
+	metrics MetricsObserver
+	tracer  FrameTracer
+	stats   readerStats
+
+	sizeValidation bool
+	// strict rejects unexpected skippable frames, see WithStrictMode.
+	strict bool
+	// seekTableSize is the size of the seek table skippable frame.
+	seekTableSize int64
+
+	cacheSize         int
+	cacheByteCapacity int64
+	cache             *frameCache
+
+	// magicPrefix precedes the first frame.
+	magicPrefix []byte
+
+	// seekTableBytes is the seek table passed out-of-band, see WithSeekTableBytes.
+	seekTableBytes []byte
+
+	// compIndex is the secondary index of frames by CompOffset, see WithCompressedOffsetIndex.
+	compIndexEnabled bool
+	compIndex        *btree.BTreeG[*env.FrameOffsetEntry]
+
+	// hierarchical is set if index entries are spans of frames with their own fine indexes.
+	hierarchical bool
+	fine         fineIndexCache
+
+	// parallelReadAt is the number of frames ReadAt decompresses concurrently, see WithParallelReadAt.
+	parallelReadAt int
+
+	// readAheadFrames is the number of frames prefetched by Read, see WithReadAheadFrames.
+	readAheadFrames int
+	// readAheadNext is the ID of the first frame that was not scheduled for prefetching yet.
+	readAheadNext   int64
+	readAheadCtx    context.Context
+	readAheadCancel context.CancelFunc
+	readAheadWg     sync.WaitGroup
+	// readAheadPending holds channels closed once prefetching of the frame is done.
+	readAheadMu      sync.Mutex
+	readAheadPending map[int64]chan struct{}
 }
 
 var (
 	_ io.Seeker   = (*readerImpl)(nil)
 	_ io.Reader   = (*readerImpl)(nil)
 	_ io.ReaderAt = (*readerImpl)(nil)
+	_ io.WriterTo = (*readerImpl)(nil)
 	_ io.Closer   = (*readerImpl)(nil)
 )
 
 type Reader interface {
 	// Seek implements io.Seeker interface to randomly access data.
 	// This method is NOT goroutine-safe and CAN NOT be called
-	// concurrently since it modifies the underlying offset.
+	// concurrently since it modifies the underlying offset, see NewSyncReader.
 	Seek(offset int64, whence int) (int64, error)
 
+	// SeekToFrame sets the offset to the start of the frame with the given id and returns it.
+	// Returns an error if id is not in [0, NumFrames()) or the stream has hierarchical index.
+	// This method is NOT goroutine-safe and CAN NOT be called
+	// concurrently since it modifies the underlying offset, see NewSyncReader.
+	SeekToFrame(id int64) (decompOffset int64, err error)
+
 	// Read implements io.Reader interface to sequentially access data.
 	// This method is NOT goroutine-safe and CAN NOT be called
-	// concurrently since it modifies the underlying offset.
+	// concurrently since it modifies the underlying offset, see NewSyncReader.
 	Read(p []byte) (n int, err error)
 
 	// ReadAt implements io.ReaderAt interface to randomly access data.
@@ -136,8 +210,21 @@ type Reader interface {
 	// the underlying reader supports io.ReaderAt interface.
 	ReadAt(p []byte, off int64) (n int, err error)
 
+	// BulkReadAt reads multiple ranges of the decompressed stream, decompressing each frame
+	// overlapping them only once.  Results are returned in the same order as ranges.
+	// This method is goroutine-safe under the same conditions as ReadAt.
+	BulkReadAt(ranges []ReadRange) ([][]byte, error)
+
+	// ReadAtMissing is like ReadAt, but fills data of frames that can not be read with fill byte,
+	// reporting their IDs via *FramesMissingError.
+	ReadAtMissing(p []byte, off int64, fill byte) (n int, err error)
+
 	// Close implements io.Closer interface free up any resources.
 	Close() error
+
+	// Stats returns cumulative statistics of reads and seeks.
+	// This method is goroutine-safe and can be called concurrently with reads.
+	Stats() Stats
 }
 
 // ZSTDDecoder is the decompressor.  Tested with github.com/klauspost/compress/zstd.
@@ -149,10 +236,13 @@ type ZSTDDecoder interface {
 // Ideally, passed io.ReadSeeker should implement io.ReaderAt interface.
 func NewReader(rs io.ReadSeeker, decoder ZSTDDecoder, opts ...rOption) (Reader, error) {
 	sr := readerImpl{
-		dec: decoder,
+		dec:          decoder,
+		maxFrameSize: maxDecoderFrameSize,
 	}
 
 	sr.logger = zap.NewNop()
+	sr.metrics = nopMetrics{}
+	sr.tracer = nopTracer{}
 	for _, o := range opts {
 		err := o(&sr)
 		if err != nil {
@@ -161,36 +251,144 @@ func NewReader(rs io.ReadSeeker, decoder ZSTDDecoder, opts ...rOption) (Reader,
 	}
 
 	if sr.env == nil {
-		sr.env = &readSeekerEnvImpl{
+		sr.env = &ReadSeekerEnv{
 			rs: rs,
 		}
 	}
+	if sr.seekTableBytes != nil {
+		sr.env = &seekTableBytesEnv{
+			REnvironment: sr.env,
+			seekTable:    decoderEnv{seekTable: sr.seekTableBytes},
+		}
+	}
+
+	if sr.cacheSize == 0 {
+		sr.cacheSize = 1
+		if sr.cacheByteCapacity > 0 {
+			sr.cacheSize = math.MaxInt
+		} else if sr.readAheadFrames > 0 {
+			// Keep the current frame along with the prefetched ones.
+			sr.cacheSize = sr.readAheadFrames + 1
+		}
+	}
+	sr.cache = newFrameCache(sr.cacheSize, sr.cacheByteCapacity)
+
+	if sr.dict != nil {
+		dec, err := zstd.NewReader(nil, zstd.WithDecoderDicts(sr.dict))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dictionary decoder: %w", err)
+		}
+		sr.dec = dec
+		sr.ownDec = dec
+	} else if sr.dec == nil && sr.defaultDecoder {
+		dec, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create default decoder: %w", err)
+		}
+		sr.dec = dec
+		sr.ownDec = dec
+	}
 
 	tree, last, err := sr.indexFooter()
 	if err != nil {
+		if sr.ownDec != nil {
+			sr.ownDec.Close()
+		}
 		return nil, err
 	}
 
-	sr.index = tree
-	if last != nil {
-		sr.endOffset = int64(last.DecompOffset) + int64(last.DecompSize)
-		sr.numFrames = last.ID + 1
-	} else {
-		sr.endOffset = 0
-		sr.numFrames = 0
+	sr.setIndex(tree, last)
+
+	if sr.hmacKey != nil {
+		if err = sr.verifyHMAC(); err != nil {
+			if sr.ownDec != nil {
+				sr.ownDec.Close()
+			}
+			return nil, err
+		}
+	}
+
+	if err = sr.checkMagicPrefix(); err != nil {
+		if sr.ownDec != nil {
+			sr.ownDec.Close()
+		}
+		return nil, err
+	}
+
+	if err = sr.checkSkippableFrames(); err != nil {
+		if sr.ownDec != nil {
+			sr.ownDec.Close()
+		}
+		return nil, err
+	}
+
+	if sr.sizeValidation || sr.strict {
+		if err = sr.validateSize(rs); err != nil {
+			if sr.ownDec != nil {
+				sr.ownDec.Close()
+			}
+			return nil, err
+		}
+	}
+
+	if sr.readAheadFrames > 0 {
+		sr.readAheadCtx, sr.readAheadCancel = context.WithCancel(context.Background())
+		sr.readAheadPending = make(map[int64]chan struct{})
 	}
 
 	return &sr, nil
 }
 
 func (r *readerImpl) ReadAt(p []byte, off int64) (n int, err error) {
+	if r.parallelReadAt > 1 {
+		return r.readAtParallel(p, off)
+	}
+
 	for m := 0; n < len(p) && err == nil; n += m {
 		_, m, err = r.read(p[n:], off+int64(n))
 	}
 	return
 }
 
+// readAtParallel is ReadAt that decompresses overlapping frames concurrently.
+func (r *readerImpl) readAtParallel(p []byte, off int64) (n int, err error) {
+	// Frames are collected upfront, so that errors are reported in the same order as by the sequential ReadAt.
+	var indexes []*env.FrameOffsetEntry
+	var indexErr error
+	for end := off; end < off+int64(len(p)); {
+		index, err := r.frameByDecompOffset(end)
+		if err != nil {
+			indexErr = err
+			break
+		}
+		indexes = append(indexes, index)
+		end = int64(index.DecompOffset) + int64(index.DecompSize)
+	}
+
+	frames := make([][]byte, len(indexes))
+	errs := make([]error, len(indexes))
+	var g errgroup.Group
+	g.SetLimit(r.parallelReadAt)
+	for i, index := range indexes {
+		g.Go(func() error {
+			frames[i], errs[i] = r.frame(index)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	for i, index := range indexes {
+		if errs[i] != nil {
+			return n, errs[i]
+		}
+		n += copy(p[n:], frames[i][uint64(off+int64(n))-index.DecompOffset:])
+	}
+	return n, indexErr
+}
+
 func (r *readerImpl) Read(p []byte) (n int, err error) {
+	r.readAhead()
+
 	offset, n, err := r.read(p, r.offset)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
@@ -202,89 +400,383 @@ func (r *readerImpl) Read(p []byte) (n int, err error) {
 	return
 }
 
+// WriteTo implements io.WriterTo interface, so that io.Copy writes decompressed frames
+// directly to w instead of copying them through an intermediate buffer.
+// Like Read, it starts at the current offset and advances it.
+// This method is NOT goroutine-safe.
+func (r *readerImpl) WriteTo(w io.Writer) (n int64, err error) {
+	for {
+		r.readAhead()
+
+		index, err := r.frameByDecompOffset(r.offset)
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		decompressed, err := r.frame(index)
+		if err != nil {
+			return n, err
+		}
+
+		m, err := w.Write(decompressed[uint64(r.offset)-index.DecompOffset:])
+		n += int64(m)
+		r.offset += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
 func (r *readerImpl) Close() error {
 	if r.closed.CompareAndSwap(false, true) {
-		r.cachedFrame.replace(math.MaxUint64, nil)
+		if r.readAheadCancel != nil {
+			r.readAheadCancel()
+			r.readAheadWg.Wait()
+		}
+		r.cache.clear()
 		r.index = nil
+		r.compIndex = nil
+		r.fine = fineIndexCache{}
+		if r.ownDec != nil {
+			r.ownDec.Close()
+		}
 	}
 	return nil
 }
 
 func (r *readerImpl) read(dst []byte, off int64) (int64, int, error) {
+	index, err := r.frameByDecompOffset(off)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	decompressed, err := r.frame(index)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	offsetWithinFrame := uint64(off) - index.DecompOffset
+
+	size := uint64(len(decompressed)) - offsetWithinFrame
+	if size > uint64(len(dst)) {
+		size = uint64(len(dst))
+	}
+
+	r.logger.Debug("decompressed", zap.Uint64("offsetWithinFrame", offsetWithinFrame), zap.Uint64("end", offsetWithinFrame+size),
+		zap.Uint64("size", size), zap.Int("lenDecompressed", len(decompressed)), zap.Int("lenDst", len(dst)), zap.Object("index", index))
+	copy(dst, decompressed[offsetWithinFrame:offsetWithinFrame+size])
+
+	return off + int64(size), int(size), nil
+}
+
+// readAhead starts decompressing frames following the one at the current offset
+// into the frame cache in background, so that sequential reads do not wait for them.
+// Frames that fail to prefetch are ignored here: Read reports the error once it gets to them.
+func (r *readerImpl) readAhead() {
+	if r.readAheadFrames == 0 || r.hierarchical || r.closed.Load() {
+		return
+	}
+
+	index := r.GetIndexByDecompOffset(uint64(r.offset))
+	if index == nil {
+		return
+	}
+
+	next := r.readAheadNext
+	if next <= index.ID || next > index.ID+1+int64(r.readAheadFrames) {
+		// Reader was seeked.
+		next = index.ID + 1
+	}
+	last := min(index.ID+int64(r.readAheadFrames), r.numFrames-1)
+	r.readAheadNext = max(next, last+1)
+
+	for id := next; id <= last; id++ {
+		if r.cache.has(id) {
+			continue
+		}
+		entry := r.GetIndexByID(id)
+		if entry == nil {
+			return
+		}
+
+		done := make(chan struct{})
+		r.readAheadMu.Lock()
+		if _, ok := r.readAheadPending[id]; ok {
+			// Still being decompressed since before the reader was seeked.
+			r.readAheadMu.Unlock()
+			continue
+		}
+		r.readAheadPending[id] = done
+		r.readAheadMu.Unlock()
+
+		r.readAheadWg.Add(1)
+		go func() {
+			defer r.readAheadWg.Done()
+			defer func() {
+				r.readAheadMu.Lock()
+				if r.readAheadPending[entry.ID] == done {
+					delete(r.readAheadPending, entry.ID)
+				}
+				r.readAheadMu.Unlock()
+				close(done)
+			}()
+
+			if r.readAheadCtx.Err() != nil {
+				return
+			}
+			decompressed, err := r.decompressFrame(r.env, r.dec, entry)
+			if err != nil {
+				r.logger.Debug("read-ahead failed", zap.Object("index", entry), zap.Error(err))
+				return
+			}
+			r.cache.put(entry.ID, decompressed)
+		}()
+	}
+}
+
+// waitReadAhead waits for the frame being prefetched by readAhead, if any.
+func (r *readerImpl) waitReadAhead(id int64) {
+	if r.readAheadFrames == 0 {
+		return
+	}
+
+	r.readAheadMu.Lock()
+	done := r.readAheadPending[id]
+	r.readAheadMu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// frameByDecompOffset returns the frame containing the offset of the decompressed stream.
+func (r *readerImpl) frameByDecompOffset(off int64) (*env.FrameOffsetEntry, error) {
 	if r.closed.Load() {
-		return 0, 0, fmt.Errorf("reader is closed")
+		return nil, fmt.Errorf("reader is closed")
 	}
 
 	if off >= r.endOffset {
-		return 0, 0, io.EOF
+		return nil, io.EOF
 	}
 	if off < 0 {
-		return 0, 0, fmt.Errorf("offset before the start of the file: %d", off)
+		return nil, fmt.Errorf("offset before the start of the file: %d", off)
 	}
 
-	index := r.GetIndexByDecompOffset(uint64(off))
+	index := r.indexByDecompOffset(uint64(off))
 	if index == nil {
-		return 0, 0, fmt.Errorf("failed to get index by offset: %d", off)
+		return nil, fmt.Errorf("failed to get index by offset: %d", off)
+	}
+	if r.hierarchical {
+		var err error
+		index, err = r.fineIndexByDecompOffset(index, uint64(off))
+		if err != nil {
+			return nil, err
+		}
 	}
 	if off < int64(index.DecompOffset) || off > int64(index.DecompOffset)+int64(index.DecompSize) {
-		return 0, 0, fmt.Errorf("offset outside of index bounds: %d: min: %d, max: %d",
+		return nil, fmt.Errorf("offset outside of index bounds: %d: min: %d, max: %d",
 			off, int64(index.DecompOffset), int64(index.DecompOffset)+int64(index.DecompSize))
 	}
+	return index, nil
+}
 
-	var decompressed []byte
+// frame returns decompressed data of the frame, either from the cache or from the environment.
+func (r *readerImpl) frame(index *env.FrameOffsetEntry) ([]byte, error) {
+	return r.frameFrom(r.env, index)
+}
 
-	cachedOffset, cachedData := r.cachedFrame.get()
-	if cachedOffset == index.DecompOffset && cachedData != nil {
-		// fastpath
-		decompressed = cachedData
-	} else {
+// frameFrom is like frame, but fetches frames that are not cached from e.
+func (r *readerImpl) frameFrom(e env.REnvironment, index *env.FrameOffsetEntry) (decompressed []byte, err error) {
+	var ok bool
+	end := r.tracer.StartFrameRead(context.Background(), *index)
+	defer func() { end(ok, err) }()
+
+	decompressed, ok = r.cache.get(index.ID)
+	if !ok {
+		r.waitReadAhead(index.ID)
+		decompressed, ok = r.cache.get(index.ID)
+	}
+	if !ok {
 		// slowpath
-		if index.CompSize > maxDecoderFrameSize {
-			return 0, 0, fmt.Errorf("index.CompSize is too big: %d > %d",
-				index.CompSize, maxDecoderFrameSize)
+		decompressed, err = r.decompressFrame(e, r.dec, index)
+		if err != nil {
+			return nil, err
 		}
+		r.cache.put(index.ID, decompressed)
+	}
+	r.stats.framesRead.Inc()
+	if ok {
+		r.stats.cacheHits.Inc()
+	} else {
+		r.stats.cacheMisses.Inc()
+	}
+	r.metrics.OnFrameRead(index.ID, ok, uint64(index.CompSize), uint64(len(decompressed)))
+
+	if len(decompressed) != int(index.DecompSize) {
+		return nil, fmt.Errorf("index corruption: len: %d, expected: %d", len(decompressed), int(index.DecompSize))
+	}
+	return decompressed, nil
+}
 
-		src, err := r.env.GetFrameByIndex(*index)
+// ReadAtMissing is like ReadAt, but frames that can not be read or decompressed
+// are replaced by fill bytes instead of failing the whole read.
+// Returns n < len(p) only if the end of the stream is reached, in which case err is io.EOF
+// unless some frames are missing.  IDs of missing frames are reported via *FramesMissingError.
+//
+// This is useful for partial recovery of damaged streams.
+func (r *readerImpl) ReadAtMissing(p []byte, off int64, fill byte) (n int, err error) {
+	var missing []int64
+	for n < len(p) {
+		index, err := r.frameByDecompOffset(off + int64(n))
+		if errors.Is(err, io.EOF) {
+			break
+		}
 		if err != nil {
-			return 0, 0, fmt.Errorf("failed to read compressed data at: %d, %w", index.CompOffset, err)
+			return n, err
 		}
 
-		if len(src) != int(index.CompSize) {
-			return 0, 0, fmt.Errorf("compressed size does not match index at: %d: expected: %d, index: %+v",
-				off, len(src), index)
-		}
+		offsetWithinFrame := uint64(off+int64(n)) - index.DecompOffset
+		size := min(uint64(index.DecompSize)-offsetWithinFrame, uint64(len(p)-n))
+		dst := p[n : n+int(size)]
 
-		decompressed, err = r.dec.DecodeAll(src, nil)
+		decompressed, err := r.frame(index)
 		if err != nil {
-			return 0, 0, fmt.Errorf("failed to decompress data data at: %d, %w", index.CompOffset, err)
+			r.logger.Warn("frame is missing", zap.Object("index", index), zap.Error(err))
+			missing = append(missing, index.ID)
+			for i := range dst {
+				dst[i] = fill
+			}
+		} else {
+			copy(dst, decompressed[offsetWithinFrame:])
 		}
+		n += int(size)
+	}
 
-		if r.checksums {
-			checksum := uint32((xxhash.Sum64(decompressed) << 32) >> 32)
-			if index.Checksum != checksum {
-				return 0, 0, fmt.Errorf("checksum verification failed at: %d: expected: %d, actual: %d",
-					index.CompOffset, index.Checksum, checksum)
-			}
+	if len(missing) > 0 {
+		return n, &FramesMissingError{FrameIDs: missing}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// FramesMissingError is returned by ReadAtMissing when some frames were replaced by fill bytes.
+type FramesMissingError struct {
+	FrameIDs []int64
+}
+
+func (e *FramesMissingError) Error() string {
+	return fmt.Sprintf("%d frames are missing: %v", len(e.FrameIDs), e.FrameIDs)
+}
+
+// decompressFrame fetches the frame described by index from the environment,
+// decompresses it and verifies its checksum (if present).
+func (r *readerImpl) decompressFrame(e env.REnvironment, dec ZSTDDecoder, index *env.FrameOffsetEntry) ([]byte, error) {
+	if int64(index.CompSize) > r.maxFrameSize {
+		return nil, fmt.Errorf("index.CompSize is too big: %d > %d",
+			index.CompSize, r.maxFrameSize)
+	}
+
+	src, err := e.GetFrameByIndex(*index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compressed data at: %d, %w", index.CompOffset, err)
+	}
+	r.stats.bytesReadCompressed.Add(uint64(len(src)))
+
+	if len(src) != int(index.CompSize) {
+		return nil, fmt.Errorf("compressed size does not match index at: %d: expected: %d, index: %+v",
+			index.CompOffset, len(src), index)
+	}
+
+	if src, err = r.resolveReference(e, index, src); err != nil {
+		return nil, err
+	}
+
+	if r.cipher != nil {
+		if src, err = r.cipher.openFrame(index.ID, src); err != nil {
+			return nil, err
 		}
-		r.cachedFrame.replace(index.DecompOffset, decompressed)
 	}
 
-	if len(decompressed) != int(index.DecompSize) {
-		return 0, 0, fmt.Errorf("index corruption: len: %d, expected: %d", len(decompressed), int(index.DecompSize))
+	if err = checkFrameMagic(index, src); err != nil {
+		return nil, err
 	}
 
-	offsetWithinFrame := uint64(off) - index.DecompOffset
+	decompressed, err := r.decodeAll(dec, index, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data data at: %d, %w", index.CompOffset, err)
+	}
+	r.stats.bytesDecompressed.Add(uint64(len(decompressed)))
 
-	size := uint64(len(decompressed)) - offsetWithinFrame
-	if size > uint64(len(dst)) {
-		size = uint64(len(dst))
+	if r.checksums {
+		checksum := uint32((xxhash.Sum64(decompressed) << 32) >> 32)
+		if index.Checksum != checksum {
+			return nil, fmt.Errorf("checksum verification failed at: %d: expected: %d, actual: %d",
+				index.CompOffset, index.Checksum, checksum)
+		}
 	}
 
-	r.logger.Debug("decompressed", zap.Uint64("offsetWithinFrame", offsetWithinFrame), zap.Uint64("end", offsetWithinFrame+size),
-		zap.Uint64("size", size), zap.Int("lenDecompressed", len(decompressed)), zap.Int("lenDst", len(dst)), zap.Object("index", index))
-	copy(dst, decompressed[offsetWithinFrame:offsetWithinFrame+size])
+	return decompressed, nil
+}
 
-	return off + int64(size), int(size), nil
+// DecompressionTimeoutError is returned when a frame could not be decompressed
+// within the limit set by WithDecompressionTimeout.
+type DecompressionTimeoutError struct {
+	FrameID  int64
+	Deadline time.Time
+}
+
+func (e *DecompressionTimeoutError) Error() string {
+	return fmt.Sprintf("frame %d: decompression did not finish by %s", e.FrameID, e.Deadline)
+}
+
+// decodeAll decompresses src, giving up after r.decompressionTimeout (if set).
+func (r *readerImpl) decodeAll(dec ZSTDDecoder, index *env.FrameOffsetEntry, src []byte) ([]byte, error) {
+	if r.decompressionTimeout == 0 {
+		return dec.DecodeAll(src, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.decompressionTimeout)
+	defer cancel()
+
+	type result struct {
+		buf []byte
+		err error
+	}
+	// Buffered, so that the goroutine can exit even if nobody waits for it anymore.
+	ch := make(chan result, 1)
+	go func() {
+		buf, err := dec.DecodeAll(src, nil)
+		ch <- result{buf, err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.buf, res.err
+	case <-ctx.Done():
+		deadline, _ := ctx.Deadline()
+		return nil, &DecompressionTimeoutError{FrameID: index.ID, Deadline: deadline}
+	}
+}
+
+// checkFrameMagic verifies that src starts with a ZSTD frame magic number,
+// so that corrupted (e.g. misaligned) data is attributed to a specific frame
+// instead of surfacing as an opaque decoder error.
+func checkFrameMagic(index *env.FrameOffsetEntry, src []byte) error {
+	if len(src) < 4 {
+		return fmt.Errorf("frame %d: too short for ZSTD magic: %d bytes at offset %#x",
+			index.ID, len(src), index.CompOffset)
+	}
+
+	magic := binary.LittleEndian.Uint32(src[0:4])
+	if magic != zstdFrameMagic {
+		return fmt.Errorf("frame %d: expected ZSTD magic 0x%08X, got 0x%08X at offset %#x",
+			index.ID, zstdFrameMagic, magic, index.CompOffset)
+	}
+	return nil
 }
 
 func (r *readerImpl) Seek(offset int64, whence int) (int64, error) {
@@ -305,16 +797,170 @@ func (r *readerImpl) Seek(offset int64, whence int) (int64, error) {
 			newOffset, r.offset, offset)
 	}
 
+	r.stats.seeksIssued.Inc()
+	r.metrics.OnSeek(r.offset, newOffset)
 	r.offset = newOffset
 	return r.offset, nil
 }
 
+func (r *readerImpl) SeekToFrame(id int64) (int64, error) {
+	if r.hierarchical {
+		return 0, fmt.Errorf("seeking to frame is not supported for hierarchical index")
+	}
+	index := r.GetIndexByID(id)
+	if index == nil {
+		return 0, fmt.Errorf("frame id is out of range: %d not in [0, %d)", id, r.numFrames)
+	}
+	return r.Seek(int64(index.DecompOffset), io.SeekStart)
+}
+
+// checkMagicPrefix verifies that the stream starts with the magic prefix.
+func (r *readerImpl) checkMagicPrefix() error {
+	if len(r.magicPrefix) == 0 {
+		return nil
+	}
+	if _, ok := r.env.(*decoderEnv); ok {
+		return nil
+	}
+
+	prefix, err := r.env.GetFrameByIndex(env.FrameOffsetEntry{CompSize: uint32(len(r.magicPrefix))})
+	if err != nil {
+		return fmt.Errorf("failed to read magic prefix: %w", err)
+	}
+	if !bytes.Equal(prefix, r.magicPrefix) {
+		return fmt.Errorf("magic prefix mismatch: expected: %x, actual: %x", r.magicPrefix, prefix)
+	}
+	return nil
+}
+
+// checkSkippableFrames verifies that neither frames listed in the seek table nor the ones
+// between them and the seek table are skippable frames other than the expected ones, see WithStrictMode.
+func (r *readerImpl) checkSkippableFrames() error {
+	if !r.strict {
+		return nil
+	}
+	if err := r.checkUnlistedFrames(); err != nil {
+		return err
+	}
+	if _, ok := r.env.(*decoderEnv); ok {
+		return nil
+	}
+
+	var err error
+	r.index.Descend(func(index *env.FrameOffsetEntry) bool {
+		// Skippable frames have no decompressed data.
+		if index.DecompSize != 0 || index.CompSize < skippableMagicNumberFieldSize {
+			return true
+		}
+
+		var buf []byte
+		buf, err = r.env.GetFrameByIndex(env.FrameOffsetEntry{
+			ID:         index.ID,
+			CompOffset: index.CompOffset,
+			CompSize:   skippableMagicNumberFieldSize,
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to read frame %d: %w", index.ID, err)
+			return false
+		}
+		if len(buf) < skippableMagicNumberFieldSize {
+			err = fmt.Errorf("frame %d: too short for magic: %d", index.ID, len(buf))
+			return false
+		}
+
+		magic := binary.LittleEndian.Uint32(buf)
+		if magic&^0xF != skippableFrameMagic {
+			return true
+		}
+		tag := magic & 0xF
+		if tag == seekableTag || (tag == hmacTag && r.hmacKey != nil) {
+			return true
+		}
+		err = fmt.Errorf("strict mode: frame %d is a skippable frame with unexpected tag %#x at offset %#x",
+			index.ID, tag, index.CompOffset)
+		return false
+	})
+	return err
+}
+
+// checkUnlistedFrames verifies that the seek table immediately follows the last frame listed in it.
+// Frames in between are not known to the index and skippable frames can not be parsed from their end,
+// so the first of them is found after the listed frames.  Seek table passed separately from the stream,
+// e.g. to NewDecoder, is checked for the skippable frames passed along with it instead.
+func (r *readerImpl) checkUnlistedFrames() error {
+	read := r.env.GetFrameByIndex
+	off := uint64(len(r.magicPrefix))
+	if last, ok := r.index.Max(); ok {
+		off = last.CompOffset + uint64(last.CompSize)
+	}
+
+	var seekTable []byte
+	switch e := r.env.(type) {
+	case *decoderEnv:
+		seekTable = e.seekTable
+	case *seekTableBytesEnv:
+		seekTable = e.seekTable.seekTable
+	}
+	if seekTable != nil {
+		off = 0
+		read = func(index env.FrameOffsetEntry) ([]byte, error) {
+			if index.CompOffset+uint64(index.CompSize) > uint64(len(seekTable)) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return seekTable[index.CompOffset : index.CompOffset+uint64(index.CompSize)], nil
+		}
+	}
+
+	buf, err := read(env.FrameOffsetEntry{
+		ID:         r.numFrames,
+		CompOffset: off,
+		CompSize:   skippableMagicNumberFieldSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read frame after the last one at offset %#x: %w", off, err)
+	}
+	if len(buf) < skippableMagicNumberFieldSize {
+		return fmt.Errorf("frame after the last one at offset %#x: too short for magic: %d", off, len(buf))
+	}
+
+	magic := binary.LittleEndian.Uint32(buf)
+	if magic&^0xF != skippableFrameMagic {
+		return fmt.Errorf("strict mode: unexpected data after the last frame at offset %#x", off)
+	}
+	if tag := magic & 0xF; tag != seekableTag {
+		return fmt.Errorf("strict mode: unlisted skippable frame with unexpected tag %#x at offset %#x", tag, off)
+	}
+	return nil
+}
+
+// setIndex replaces the index with the tree and updates the stream bounds from its last entry.
+func (r *readerImpl) setIndex(tree *btree.BTreeG[*env.FrameOffsetEntry], last *env.FrameOffsetEntry) {
+	r.index = tree
+	if r.compIndexEnabled {
+		r.compIndex = btree.NewG(8, env.CompLess)
+		tree.Ascend(func(index *env.FrameOffsetEntry) bool {
+			r.compIndex.ReplaceOrInsert(index)
+			return true
+		})
+	}
+	if last != nil {
+		r.endOffset = int64(last.DecompOffset) + int64(last.DecompSize)
+		r.numFrames = last.ID + 1
+	} else {
+		r.endOffset = 0
+		r.numFrames = 0
+	}
+}
+
 func (r *readerImpl) indexFooter() (*btree.BTreeG[*env.FrameOffsetEntry], *env.FrameOffsetEntry, error) {
 	// read seekTableFooter
 	buf, err := r.env.ReadFooter()
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read footer: %w", err)
 	}
+	if r.hooks.OnFooterRead != nil {
+		r.hooks.OnFooterRead(buf)
+	}
 	if len(buf) < seekTableFooterOffset {
 		return nil, nil, fmt.Errorf("footer is too small: %d", len(buf))
 	}
@@ -328,6 +974,13 @@ func (r *readerImpl) indexFooter() (*btree.BTreeG[*env.FrameOffsetEntry], *env.F
 	r.logger.Debug("loaded", zap.Object("footer", &footer))
 
 	r.checksums = footer.SeekTableDescriptor.ChecksumFlag
+	if r.forceChecksums && !r.checksums {
+		return nil, nil, fmt.Errorf("checksum verification failed: seek table has no checksums")
+	}
+	r.hierarchical = footer.SeekTableDescriptor.HierarchicalFlag
+	if r.encryptionKey != nil && !footer.SeekTableDescriptor.EncryptedFlag {
+		return nil, nil, fmt.Errorf("stream is not encrypted")
+	}
 
 	// read SeekTableEntries
 	seekTableEntrySize := int64(8)
@@ -335,6 +988,19 @@ func (r *readerImpl) indexFooter() (*btree.BTreeG[*env.FrameOffsetEntry], *env.F
 		seekTableEntrySize += 4
 	}
 
+	if footer.SeekTableDescriptor.EncryptedFlag {
+		return r.indexEncryptedSeekTable(&footer, seekTableEntrySize)
+	}
+	if footer.SeekTableDescriptor.ChunkedFlag {
+		return r.indexChunkedSeekTable(&footer, seekTableEntrySize)
+	}
+	if footer.SeekTableDescriptor.CompressedFlag {
+		return r.indexCompressedSeekTable(&footer, seekTableEntrySize)
+	}
+	if footer.SeekTableDescriptor.VarintFlag {
+		return r.indexVarintSeekTable(&footer, seekTableEntrySize)
+	}
+
 	skippableFrameOffset := seekTableFooterOffset + seekTableEntrySize*int64(footer.NumberOfFrames)
 	skippableFrameOffset += frameSizeFieldSize
 	skippableFrameOffset += skippableMagicNumberFieldSize
@@ -344,14 +1010,23 @@ func (r *readerImpl) indexFooter() (*btree.BTreeG[*env.FrameOffsetEntry], *env.F
 			skippableFrameOffset, maxDecoderFrameSize)
 	}
 
+	r.seekTableSize = skippableFrameOffset
+
 	buf, err = r.env.ReadSkipFrame(skippableFrameOffset)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read footer: %w", err)
 	}
+	if r.hooks.OnSkipFrameRead != nil {
+		r.hooks.OnSkipFrameRead(buf)
+	}
 
 	if len(buf) < frameSizeFieldSize+skippableMagicNumberFieldSize+seekTableFooterOffset {
 		return nil, nil, fmt.Errorf("skip frame is too small: %d", len(buf))
 	}
+	// Seek table passed to NewDecoder may be preceded by other skippable frames.
+	if int64(len(buf)) > skippableFrameOffset {
+		buf = buf[int64(len(buf))-skippableFrameOffset:]
+	}
 
 	// parse SeekTableEntries
 	magic := binary.LittleEndian.Uint32(buf[0:4])
@@ -374,8 +1049,294 @@ func (r *readerImpl) indexFooter() (*btree.BTreeG[*env.FrameOffsetEntry], *env.F
 	return r.indexSeekTableEntries(buf[8:len(buf)-seekTableFooterOffset], uint64(seekTableEntrySize))
 }
 
+// indexChunkedSeekTable reads and indexes a seek table split across multiple skippable frames.
+// See marshalChunkedSeekTable for the layout.
+func (r *readerImpl) indexChunkedSeekTable(footer *seekTableFooter, entrySize int64) (
+	*btree.BTreeG[*env.FrameOffsetEntry], *env.FrameOffsetEntry, error,
+) {
+	buf, err := r.env.ReadSkipFrame(chunkedSeekTableTrailerSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read chunk entries: %w", err)
+	}
+	if len(buf) < chunkedSeekTableTrailerSize {
+		return nil, nil, fmt.Errorf("chunked seek table trailer is too small: %d", len(buf))
+	}
+	chunkEntries := int64(binary.LittleEndian.Uint32(buf[len(buf)-chunkedSeekTableTrailerSize:]))
+	if chunkEntries == 0 {
+		return nil, nil, fmt.Errorf("chunked seek table has zero entries per chunk")
+	}
+
+	numFrames := int64(footer.NumberOfFrames)
+	numChunks := (numFrames + chunkEntries - 1) / chunkEntries
+	if numChunks == 0 {
+		numChunks = 1
+	}
+	lastEntries := numFrames - (numChunks-1)*chunkEntries
+
+	chunkSize := frameSizeFieldSize + skippableMagicNumberFieldSize + chunkEntries*entrySize
+	lastChunkSize := frameSizeFieldSize + skippableMagicNumberFieldSize +
+		lastEntries*entrySize + chunkedSeekTableTrailerSize
+	if chunkSize > maxDecoderFrameSize || lastChunkSize > maxDecoderFrameSize {
+		return nil, nil, fmt.Errorf("seek table chunk is too big: %d > %d",
+			max(chunkSize, lastChunkSize), maxDecoderFrameSize)
+	}
+
+	skippableFrameOffset := (numChunks-1)*chunkSize + lastChunkSize
+	r.seekTableSize = skippableFrameOffset
+
+	buf, err = r.env.ReadSkipFrame(skippableFrameOffset)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read chunked seek table: %w", err)
+	}
+	if r.hooks.OnSkipFrameRead != nil {
+		r.hooks.OnSkipFrameRead(buf)
+	}
+	if int64(len(buf)) < skippableFrameOffset {
+		return nil, nil, fmt.Errorf("chunked seek table is too small: %d < %d", len(buf), skippableFrameOffset)
+	}
+	buf = buf[int64(len(buf))-skippableFrameOffset:]
+
+	p := make([]byte, 0, numFrames*entrySize)
+	for i := int64(0); i < numChunks; i++ {
+		size := chunkSize
+		if i == numChunks-1 {
+			size = lastChunkSize
+		}
+
+		chunk := buf[:size]
+		buf = buf[size:]
+
+		magic := binary.LittleEndian.Uint32(chunk[0:4])
+		if magic != skippableFrameMagic+seekableTag {
+			return nil, nil, fmt.Errorf("chunk %d: skippable frame magic mismatch %d vs %d",
+				i, magic, skippableFrameMagic+seekableTag)
+		}
+
+		frameSize := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		if frameSize != size-frameSizeFieldSize-skippableMagicNumberFieldSize {
+			return nil, nil, fmt.Errorf("chunk %d: skippable frame size mismatch: expected: %d, actual: %d",
+				i, size-frameSizeFieldSize-skippableMagicNumberFieldSize, frameSize)
+		}
+
+		entries := chunk[8:]
+		if i == numChunks-1 {
+			entries = entries[:len(entries)-chunkedSeekTableTrailerSize]
+		}
+		p = append(p, entries...)
+	}
+
+	return r.indexSeekTableEntries(p, uint64(entrySize))
+}
+
+func (r *readerImpl) indexCompressedSeekTable(footer *seekTableFooter, entrySize int64) (
+	*btree.BTreeG[*env.FrameOffsetEntry], *env.FrameOffsetEntry, error,
+) {
+	if r.dec == nil {
+		return nil, nil, fmt.Errorf("decoder is required to read compressed seek table")
+	}
+
+	decompressedSize := int64(footer.NumberOfFrames) * entrySize
+	if decompressedSize > maxDecoderFrameSize {
+		return nil, nil, fmt.Errorf("seek table is too big: %d > %d", decompressedSize, maxDecoderFrameSize)
+	}
+
+	payload, err := r.readSeekTablePayload()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read compressed seek table: %w", err)
+	}
+
+	p, err := r.dec.DecodeAll(payload, make([]byte, 0, decompressedSize))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decompress seek table: %w", err)
+	}
+	if int64(len(p)) != decompressedSize {
+		return nil, nil, fmt.Errorf("decompressed seek table size mismatch: expected: %d, actual: %d",
+			decompressedSize, len(p))
+	}
+
+	return r.indexSeekTableEntries(p, uint64(entrySize))
+}
+
+func (r *readerImpl) indexVarintSeekTable(footer *seekTableFooter, entrySize int64) (
+	*btree.BTreeG[*env.FrameOffsetEntry], *env.FrameOffsetEntry, error,
+) {
+	size := int64(footer.NumberOfFrames) * entrySize
+	if size > maxDecoderFrameSize {
+		return nil, nil, fmt.Errorf("seek table is too big: %d > %d", size, maxDecoderFrameSize)
+	}
+
+	payload, err := r.readSeekTablePayload()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read varint seek table: %w", err)
+	}
+
+	// Entries are expanded to the fixed size representation.
+	p := make([]byte, size)
+	var prev seekTableEntry
+	for i := int64(0); i < int64(footer.NumberOfFrames); i++ {
+		e := seekTableEntry{}
+		for _, field := range []struct {
+			prev uint32
+			dst  *uint32
+		}{
+			{prev.CompressedSize, &e.CompressedSize},
+			{prev.DecompressedSize, &e.DecompressedSize},
+		} {
+			delta, n := binary.Varint(payload)
+			if n <= 0 {
+				return nil, nil, fmt.Errorf("failed to parse varint of entry %d", i)
+			}
+			payload = payload[n:]
+
+			v := int64(field.prev) + delta
+			if v < 0 || v > math.MaxUint32 {
+				return nil, nil, fmt.Errorf("size of entry %d is out of range: %d", i, v)
+			}
+			*field.dst = uint32(v)
+		}
+		if footer.SeekTableDescriptor.ChecksumFlag {
+			if len(payload) < 4 {
+				return nil, nil, fmt.Errorf("failed to parse checksum of entry %d", i)
+			}
+			e.Checksum = binary.LittleEndian.Uint32(payload)
+			payload = payload[4:]
+		}
+
+		e.marshalBinaryInline(p[i*entrySize : (i+1)*entrySize])
+		prev = e
+	}
+	if len(payload) != 0 {
+		return nil, nil, fmt.Errorf("varint seek table has %d trailing bytes", len(payload))
+	}
+
+	return r.indexSeekTableEntries(p, uint64(entrySize))
+}
+
+// readSeekTablePayload reads the seek table skippable frame that stores the size of
+// its payload before the footer, i.e. the compressed or the varint one, and returns the payload.
+func (r *readerImpl) readSeekTablePayload() ([]byte, error) {
+	buf, err := r.env.ReadSkipFrame(payloadSeekTableTrailerSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload size: %w", err)
+	}
+	if len(buf) < payloadSeekTableTrailerSize {
+		return nil, fmt.Errorf("seek table trailer is too small: %d", len(buf))
+	}
+	payloadSize := int64(binary.LittleEndian.Uint32(buf[len(buf)-payloadSeekTableTrailerSize:]))
+
+	skippableFrameOffset := frameSizeFieldSize + skippableMagicNumberFieldSize +
+		payloadSize + payloadSeekTableTrailerSize
+	if skippableFrameOffset > maxDecoderFrameSize {
+		return nil, fmt.Errorf("frame offset is too big: %d > %d",
+			skippableFrameOffset, maxDecoderFrameSize)
+	}
+	r.seekTableSize = skippableFrameOffset
+
+	buf, err = r.env.ReadSkipFrame(skippableFrameOffset)
+	if err != nil {
+		return nil, err
+	}
+	if r.hooks.OnSkipFrameRead != nil {
+		r.hooks.OnSkipFrameRead(buf)
+	}
+	if int64(len(buf)) < skippableFrameOffset {
+		return nil, fmt.Errorf("seek table is too small: %d < %d", len(buf), skippableFrameOffset)
+	}
+	buf = buf[int64(len(buf))-skippableFrameOffset:]
+
+	magic := binary.LittleEndian.Uint32(buf[0:4])
+	if magic != skippableFrameMagic+seekableTag {
+		return nil, fmt.Errorf("skippable frame magic mismatch %d vs %d",
+			magic, skippableFrameMagic+seekableTag)
+	}
+	frameSize := int64(binary.LittleEndian.Uint32(buf[4:8]))
+	if frameSize != skippableFrameOffset-frameSizeFieldSize-skippableMagicNumberFieldSize {
+		return nil, fmt.Errorf("skippable frame size mismatch: expected: %d, actual: %d",
+			skippableFrameOffset-frameSizeFieldSize-skippableMagicNumberFieldSize, frameSize)
+	}
+
+	return buf[8 : 8+payloadSize], nil
+}
+
+// DetectSeekable reports whether the stream ends with a seek table,
+// i.e. whether it can be opened with NewReader.
+// Only the magic number of the seek table footer is checked.
+func DetectSeekable(rs io.ReadSeeker) (bool, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, fmt.Errorf("failed to get stream size: %w", err)
+	}
+	if size < seekTableFooterOffset {
+		return false, nil
+	}
+
+	buf, err := (&ReadSeekerEnv{rs: rs}).ReadFooter()
+	if err != nil {
+		return false, err
+	}
+
+	magic := binary.LittleEndian.Uint32(buf[seekTableFooterOffset-4:])
+	return magic == seekableMagicNumber, nil
+}
+
+// validateSize checks that the index is consistent with the stream size.
+func (r *readerImpl) validateSize(rs io.ReadSeeker) error {
+	var compSize, decompSize uint64
+	r.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		compSize += uint64(index.CompSize)
+		decompSize += uint64(index.DecompSize)
+		return true
+	})
+
+	if decompSize != uint64(r.endOffset) {
+		return fmt.Errorf("decompressed size mismatch: seek table: %d, stream: %d",
+			decompSize, r.endOffset)
+	}
+
+	if rs == nil {
+		return nil
+	}
+
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to get stream size: %w", err)
+	}
+
+	seekTableSize := r.seekTableSize
+	if r.seekTableBytes != nil {
+		// Seek table is not a part of the stream.
+		seekTableSize = 0
+	}
+	expected := int64(len(r.magicPrefix)) + int64(compSize) + seekTableSize
+	if size != expected {
+		return fmt.Errorf("compressed size mismatch: expected: %d (prefix: %d, frames: %d, seek table: %d), actual: %d",
+			expected, len(r.magicPrefix), compSize, seekTableSize, size)
+	}
+	return nil
+}
+
 func (r *readerImpl) indexSeekTableEntries(p []byte, entrySize uint64) (
 	*btree.BTreeG[*env.FrameOffsetEntry], *env.FrameOffsetEntry, error,
+) {
+	t, last, err := r.indexSeekTableEntriesAt(p, entrySize, env.FrameOffsetEntry{CompOffset: uint64(len(r.magicPrefix))})
+	if err != nil || !r.sizeValidation {
+		return t, last, err
+	}
+
+	entries := make([]env.FrameOffsetEntry, 0, t.Len())
+	t.Ascend(func(index *env.FrameOffsetEntry) bool {
+		entries = append(entries, *index)
+		return true
+	})
+	if err = VerifyIndex(entries); err != nil {
+		return nil, nil, fmt.Errorf("failed to verify seek table: %w", err)
+	}
+	return t, last, nil
+}
+
+// indexSeekTableEntriesAt parses entries of frames starting at the ID and offsets of base.
+func (r *readerImpl) indexSeekTableEntriesAt(p []byte, entrySize uint64, base env.FrameOffsetEntry) (
+	*btree.BTreeG[*env.FrameOffsetEntry], *env.FrameOffsetEntry, error,
 ) {
 	if uint64(len(p))%entrySize != 0 {
 		return nil, nil, fmt.Errorf("seek table size is not multiple of %d", entrySize)
@@ -384,10 +1345,10 @@ func (r *readerImpl) indexSeekTableEntries(p []byte, entrySize uint64) (
 	// TODO: make fan-out tunable?
 	t := btree.NewG(8, env.Less)
 	entry := seekTableEntry{}
-	var compOffset, decompOffset uint64
+	compOffset, decompOffset := base.CompOffset, base.DecompOffset
 
 	var last *env.FrameOffsetEntry
-	var i int64
+	i := base.ID
 	for indexOffset := uint64(0); indexOffset < uint64(len(p)); indexOffset += entrySize {
 		err := entry.UnmarshalBinary(p[indexOffset : indexOffset+entrySize])
 		if err != nil {
@@ -404,6 +1365,9 @@ func (r *readerImpl) indexSeekTableEntries(p []byte, entrySize uint64) (
 			Checksum:     entry.Checksum,
 		}
 		t.ReplaceOrInsert(last)
+		if r.hooks.OnEntryParsed != nil {
+			r.hooks.OnEntryParsed(*last)
+		}
 		compOffset += uint64(entry.CompressedSize)
 		decompOffset += uint64(entry.DecompressedSize)
 		i++
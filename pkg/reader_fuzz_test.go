@@ -4,13 +4,18 @@
 package seekable
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
 )
 
 func FuzzReader(f *testing.F) {
@@ -93,3 +98,94 @@ func FuzzReaderConst(f *testing.F) {
 		}
 	})
 }
+
+var errInjected = errors.New("injected I/O error")
+
+// flakyReadEnvironment fails GetFrameByIndex for failID and, randomly, at the given rate (out of 256).
+type flakyReadEnvironment struct {
+	env.REnvironment
+	rng     *rand.Rand
+	rate    uint8
+	failID  int64
+	enabled bool
+}
+
+func (e *flakyReadEnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	if e.enabled && (index.ID == e.failID || e.rng.Intn(256) < int(e.rate)) {
+		return nil, errInjected
+	}
+	return e.REnvironment.GetFrameByIndex(index)
+}
+
+func FuzzReaderIOError(f *testing.F) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(f, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(f, err)
+	defer dec.Close()
+
+	frames := []string{"test", "test2", "test3"}
+	src := strings.Join(frames, "")
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(f, err)
+	for _, frame := range frames {
+		_, err = w.Write([]byte(frame))
+		require.NoError(f, err)
+	}
+	require.NoError(f, w.Close())
+	stream := b.Bytes()
+
+	// Errors in the first, the middle and the last frame.
+	f.Add(int64(0), uint8(0), int8(0), int64(0), uint8(len(src)))
+	f.Add(int64(0), uint8(0), int8(1), int64(2), uint8(8))
+	f.Add(int64(0), uint8(0), int8(2), int64(9), uint8(5))
+	// Random errors.
+	f.Add(int64(1), uint8(128), int8(-1), int64(0), uint8(len(src)))
+
+	f.Fuzz(func(t *testing.T, seed int64, rate uint8, failID int8, off int64, l uint8) {
+		e := &flakyReadEnvironment{
+			REnvironment: NewReadSeekerEnv(bytes.NewReader(stream)),
+			rng:          rand.New(rand.NewSource(seed)),
+			rate:         rate,
+			failID:       int64(failID),
+			enabled:      true,
+		}
+		r, err := NewReader(nil, dec, WithREnvironment(e))
+		require.NoError(t, err)
+		defer func() { require.NoError(t, r.Close()) }()
+
+		// Errors are propagated.
+		buf := make([]byte, l)
+		_, err = r.ReadAt(buf, off)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, errInjected) {
+			require.ErrorContains(t, err, "offset before the start of the file")
+		}
+
+		i, err := r.Seek(off, io.SeekStart)
+		if err != nil {
+			return
+		}
+		_, err = r.Read(buf)
+		if err != nil && !errors.Is(err, io.EOF) {
+			require.ErrorIs(t, err, errInjected)
+		}
+
+		// Reader recovers once errors are gone.
+		e.enabled = false
+
+		n, err := r.ReadAt(buf, i)
+		if !errors.Is(err, io.EOF) {
+			require.NoError(t, err)
+		}
+		assert.Equal(t, src[min(i, int64(len(src))):min(i+int64(n), int64(len(src)))], string(buf[:n]))
+
+		_, err = r.Seek(i, io.SeekStart)
+		require.NoError(t, err)
+		sequential := make([]byte, n)
+		_, err = io.ReadFull(r, sequential)
+		require.NoError(t, err)
+		assert.Equal(t, buf[:n], sequential)
+	})
+}
@@ -1,9 +1,15 @@
 package seekable
 
 import (
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
 	"go.uber.org/zap"
 
 	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/logadapter"
 )
 
 type rOption func(*readerImpl) error
@@ -12,6 +18,238 @@ func WithRLogger(l *zap.Logger) rOption {
 	return func(r *readerImpl) error { r.logger = l; return nil }
 }
 
+// WithRSlogLogger is like WithRLogger, but logs to the standard library logger, see logadapter.
+func WithRSlogLogger(l *slog.Logger) rOption {
+	return func(r *readerImpl) error { r.logger = logadapter.NewZapLogger(l); return nil }
+}
+
 func WithREnvironment(e env.REnvironment) rOption {
 	return func(r *readerImpl) error { r.env = e; return nil }
 }
+
+// WithSeekTableBytes makes NewReader parse the seek table passed out-of-band
+// (e.g. obtained from the writer in pipe mode, see WithPipeMode)
+// instead of reading it from the end of the stream.  Frames are still read from the stream.
+func WithSeekTableBytes(seekTable []byte) rOption {
+	return func(r *readerImpl) error {
+		if len(seekTable) == 0 {
+			return fmt.Errorf("seek table is empty")
+		}
+		r.seekTableBytes = seekTable
+		return nil
+	}
+}
+
+// WithDefaultDecoder makes NewReader create its own ZSTD decoder when a nil
+// decoder is passed.  The decoder is released on Close.
+//
+// This is convenient for quick scripts and tests, but not recommended for
+// production use, where a single decoder should be shared between readers.
+func WithDefaultDecoder() rOption {
+	return func(r *readerImpl) error { r.defaultDecoder = true; return nil }
+}
+
+// WithRDictionary makes NewReader create its own ZSTD decoder that uses dict,
+// so that streams written with WithWDictionary can be read.  The decoder passed
+// to NewReader is not used and can be nil.  The decoder is released on Close.
+func WithRDictionary(dict []byte) rOption {
+	return func(r *readerImpl) error {
+		if len(dict) == 0 {
+			return fmt.Errorf("dictionary is empty")
+		}
+		r.dict = dict
+		return nil
+	}
+}
+
+// WithDecompressionTimeout limits the time a single frame decompression can take.
+// If the limit is exceeded read returns *DecompressionTimeoutError.
+//
+// Decompression is offloaded to a separate goroutine on each cache miss,
+// which has a measurable overhead for small frames.  Default is no timeout.
+func WithDecompressionTimeout(d time.Duration) rOption {
+	return func(r *readerImpl) error {
+		if d < 0 {
+			return fmt.Errorf("decompression timeout must not be negative: %s", d)
+		}
+		r.decompressionTimeout = d
+		return nil
+	}
+}
+
+// WithSizeValidation makes NewReader verify that the seek table is consistent
+// with the stream: the cumulative decompressed size has to match the stream size,
+// and, if the io.ReadSeeker is passed to NewReader, its length has to match
+// the cumulative compressed size plus the seek table size.
+// Parsed seek table entries are also checked with VerifyIndex.
+//
+// This catches truncated or extended files, e.g. due to partial uploads.
+// Note that streams with extra data after the last frame (e.g. custom skippable frames)
+// will fail this validation.
+func WithSizeValidation() rOption {
+	return func(r *readerImpl) error { r.sizeValidation = true; return nil }
+}
+
+// WithStrictMode with strict set to true makes NewReader reject streams with skippable frames
+// other than the seek table, e.g. frame metadata or frames of other tools, which some
+// security-sensitive consumers do not want to pass through.  The HMAC frame is allowed
+// if the reader is opened WithRHMAC.
+//
+// Skippable frames listed in the seek table without decompressed data are checked by their
+// magic numbers, so reference frames of WithDeduplication, which have the data of the frame
+// they refer to, are not affected.  The seek table must immediately follow the last listed frame,
+// so unlisted skippable frames inserted before it are rejected for any environment, including
+// the ones passed to NewDecoder along with the seek table.  Strict mode also implies WithSizeValidation.
+func WithStrictMode(strict bool) rOption {
+	return func(r *readerImpl) error { r.strict = strict; return nil }
+}
+
+// TelemetryHooks are called synchronously while NewReader loads the seek table.
+// Any of the hooks can be nil.
+type TelemetryHooks struct {
+	// OnFooterRead is called with the buffer returned by REnvironment.ReadFooter.
+	OnFooterRead func(bytes []byte)
+	// OnSkipFrameRead is called with the buffer returned by REnvironment.ReadSkipFrame.
+	OnSkipFrameRead func(bytes []byte)
+	// OnEntryParsed is called for each parsed seek table entry.
+	OnEntryParsed func(entry env.FrameOffsetEntry)
+}
+
+// WithTelemetryHooks sets hooks for observing the seek table loading, e.g. for profiling cold-start latency.
+func WithTelemetryHooks(h TelemetryHooks) rOption {
+	return func(r *readerImpl) error { r.hooks = h; return nil }
+}
+
+// WithRMetrics sets the observer of frame reads and seeks, see MetricsObserver.
+func WithRMetrics(m MetricsObserver) rOption {
+	return func(r *readerImpl) error { r.metrics = m; return nil }
+}
+
+// WithRTracer sets the tracer of frame reads, see FrameTracer.
+func WithRTracer(t FrameTracer) rOption {
+	return func(r *readerImpl) error { r.tracer = t; return nil }
+}
+
+// WithChecksumValidation with force set to true makes the reader require checksums
+// in the seek table: streams written without them are rejected instead of being read
+// unverified, so that a flipped checksum flag cannot silently disable verification.
+// Checksums can also be supplied out-of-band with WithSeekTableBytes.
+// When force is false (default), checksums are verified only if the seek table has them.
+func WithChecksumValidation(force bool) rOption {
+	return func(r *readerImpl) error { r.forceChecksums = force; return nil }
+}
+
+// WithRHMAC makes NewReader verify the HMAC of the seek table written with WithWHMAC and the same key.
+// Streams without the HMAC or with a mismatching one are rejected.
+func WithRHMAC(key []byte) rOption {
+	return func(r *readerImpl) error {
+		if len(key) == 0 {
+			return fmt.Errorf("HMAC key is empty")
+		}
+		r.hmacKey = key
+		return nil
+	}
+}
+
+// WithREncryption makes the reader decrypt the seek table and frames of a stream written with
+// WithWEncryption and the same 32-byte key.  Streams that are not encrypted are rejected,
+// and so are streams encrypted with a different key.
+func WithREncryption(key []byte) rOption {
+	return func(r *readerImpl) error {
+		if len(key) != encryptionKeySize {
+			return fmt.Errorf("encryption key must be %d bytes: %d", encryptionKeySize, len(key))
+		}
+		r.encryptionKey = key
+		return nil
+	}
+}
+
+// WithMaxFrameSize overrides the limit of the compressed frame size, 128MiB by default.
+// Frames are read into memory as a whole, so the limit protects from OOMs on untrusted input.
+func WithMaxFrameSize(n int64) rOption {
+	return func(r *readerImpl) error {
+		if n <= 0 || n > math.MaxUint32 {
+			return fmt.Errorf("max frame size must be in (0, %d]: %d", uint32(math.MaxUint32), n)
+		}
+		r.maxFrameSize = n
+		return nil
+	}
+}
+
+// WithParallelReadAt makes ReadAt fetch and decompress up to n frames overlapping
+// the requested range concurrently, which reduces latency of large reads from
+// environments with high per-request latency, e.g. HTTP or S3.
+//
+// As with ReadAt, the underlying reader should support io.ReaderAt interface.
+func WithParallelReadAt(n int) rOption {
+	return func(r *readerImpl) error {
+		if n < 1 {
+			return fmt.Errorf("parallel ReadAt frames must be positive: %d", n)
+		}
+		r.parallelReadAt = n
+		return nil
+	}
+}
+
+// WithCacheSize sets the number of decompressed frames kept in the LRU cache.
+// Default is 1, i.e. only the last accessed frame is cached, unless
+// WithCacheByteCapacity is set, in which case the number of frames is not limited.
+func WithCacheSize(n int) rOption {
+	return func(r *readerImpl) error {
+		if n < 1 {
+			return fmt.Errorf("cache size must be positive: %d", n)
+		}
+		r.cacheSize = n
+		return nil
+	}
+}
+
+// WithReadAheadFrames makes Read decompress up to n frames following the current one
+// in background, so that sequential reads, e.g. io.ReadAll, are pipelined.
+// Prefetched frames are stored in the frame cache, so unless WithCacheSize is set explicitly
+// the cache is sized to fit them.  Background decompression is stopped by Close.
+//
+// Frames are fetched concurrently, so, as with ReadAt, the underlying reader
+// should support io.ReaderAt interface.  Not supported with hierarchical index.
+func WithReadAheadFrames(n int) rOption {
+	return func(r *readerImpl) error {
+		if n < 1 {
+			return fmt.Errorf("read-ahead frames must be positive: %d", n)
+		}
+		r.readAheadFrames = n
+		return nil
+	}
+}
+
+// WithCacheByteCapacity limits the total decompressed size of frames kept in the LRU cache.
+// Frames larger than maxBytes are not cached at all.
+func WithCacheByteCapacity(maxBytes int64) rOption {
+	return func(r *readerImpl) error {
+		if maxBytes < 1 {
+			return fmt.Errorf("cache byte capacity must be positive: %d", maxBytes)
+		}
+		r.cacheByteCapacity = maxBytes
+		return nil
+	}
+}
+
+// WithRMagicPrefix makes the reader validate that the stream starts with the magic prefix
+// (see WithWMagicPrefix) and account for it when locating frames.
+//
+// The prefix can not be validated for the Decoder, since it has no access to the stream.
+func WithRMagicPrefix(magic []byte) rOption {
+	return func(r *readerImpl) error {
+		if int64(len(magic)) > maxDecoderFrameSize {
+			return fmt.Errorf("magic prefix is too big: %d > %d", len(magic), maxDecoderFrameSize)
+		}
+		r.magicPrefix = magic
+		return nil
+	}
+}
+
+// WithCompressedOffsetIndex makes the reader maintain a secondary index of frames
+// by their offsets in the compressed stream, so that GetIndexByCompOffset does
+// O(log N) lookups instead of a linear scan at the expense of extra memory.
+func WithCompressedOffsetIndex() rOption {
+	return func(r *readerImpl) error { r.compIndexEnabled = true; return nil }
+}
@@ -2,14 +2,22 @@ package seekable
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 
 	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
 )
@@ -162,8 +170,8 @@ func TestReader(t *testing.T) {
 
 		assert.Equal(t, int64(n), sr.offset)
 
-		offset1, data1 := sr.cachedFrame.get()
-		assert.Equal(t, uint64(0), offset1)
+		data1, ok := sr.cache.get(0)
+		assert.True(t, ok)
 		assert.Equal(t, bytes1, data1)
 
 		m, err := r.Read(tmp)
@@ -172,9 +180,11 @@ func TestReader(t *testing.T) {
 		assert.Equal(t, bytes2, tmp[:m])
 
 		assert.Equal(t, int64(n)+int64(m), sr.offset)
-		offset2, data2 := sr.cachedFrame.get()
-		assert.Equal(t, uint64(len(bytes1)), offset2)
+		data2, ok := sr.cache.get(1)
+		assert.True(t, ok)
 		assert.Equal(t, bytes2, data2)
+		_, ok = sr.cache.get(0)
+		assert.False(t, ok)
 
 		_, err = r.Read(tmp)
 		require.ErrorIs(t, err, io.EOF)
@@ -249,6 +259,56 @@ func TestReaderEdges(t *testing.T) {
 //
 // When ReadAt returns n < len(p), it returns a non-nil error explaining why more bytes were not returned.
 // In this respect, ReadAt is stricter than Read.
+func TestSeekToFrame(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	frames := []string{"frame0", "frame1", "", "frame3", "frame4"}
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	for _, f := range frames {
+		_, err = w.Write([]byte(f))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	newReaders := []func() (Reader, error){
+		func() (Reader, error) { return NewReader(bytes.NewReader(b.Bytes()), dec) },
+		func() (Reader, error) { return NewSyncReader(bytes.NewReader(b.Bytes()), dec) },
+	}
+	for _, newReader := range newReaders {
+		r, err := newReader()
+		require.NoError(t, err)
+
+		off, err := r.SeekToFrame(3)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len("frame0frame1")), off)
+		tmp := make([]byte, len(frames[3]))
+		_, err = io.ReadFull(r, tmp)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(frames[3]), tmp)
+
+		off, err = r.SeekToFrame(0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), off)
+		all, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(strings.Join(frames, "")), all)
+
+		for _, id := range []int64{-1, 5} {
+			_, err = r.SeekToFrame(id)
+			require.ErrorContains(t, err, "frame id is out of range")
+		}
+		require.NoError(t, r.Close())
+	}
+}
+
 func TestReaderAt(t *testing.T) {
 	t.Parallel()
 
@@ -308,6 +368,108 @@ func TestReaderAt(t *testing.T) {
 	}
 }
 
+func TestWriteTo(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	for _, b := range [][]byte{checksum, noChecksum} {
+		r, err := NewReader(&seekableBufferReaderAt{buf: b}, dec)
+		require.NoError(t, err)
+		sr := r.(*readerImpl)
+
+		_, err = r.Seek(2, io.SeekStart)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, r)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(sourceString)-2), n)
+		assert.Equal(t, sourceString[2:], buf.String())
+
+		// Offset is advanced and the last written frame is cached.
+		off, err := r.Seek(0, io.SeekCurrent)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(sourceString)), off)
+		_, ok := sr.cache.get(1)
+		assert.True(t, ok)
+
+		n, err = sr.WriteTo(&buf)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		// Write errors stop the copy.
+		_, err = r.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		n, err = sr.WriteTo(failingWriter{})
+		require.ErrorContains(t, err, "failed")
+		assert.Zero(t, n)
+		off, err = r.Seek(0, io.SeekCurrent)
+		require.NoError(t, err)
+		assert.Zero(t, off)
+
+		require.NoError(t, r.Close())
+		_, err = sr.WriteTo(&buf)
+		require.ErrorContains(t, err, "reader is closed")
+	}
+}
+
+func BenchmarkWriteTo(b *testing.B) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(b, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(b, err)
+	defer dec.Close()
+
+	const (
+		frameCount = 100
+		frameSize  = 64 * 1024
+	)
+	rng := rand.New(rand.NewSource(0))
+	var stream bytes.Buffer
+	w, err := NewWriter(&stream, enc)
+	require.NoError(b, err)
+	frame := make([]byte, frameSize)
+	for i := 0; i < frameCount; i++ {
+		for j := range frame {
+			frame[j] = byte(rng.Intn(16))
+		}
+		_, err = w.Write(frame)
+		require.NoError(b, err)
+	}
+	require.NoError(b, w.Close())
+
+	r, err := NewReader(bytes.NewReader(stream.Bytes()), dec)
+	require.NoError(b, err)
+	defer func() { require.NoError(b, r.Close()) }()
+
+	for _, tc := range []struct {
+		name string
+		src  func() io.Reader
+	}{
+		// Hides io.WriterTo, so io.Copy goes through an intermediate buffer.
+		{"Read", func() io.Reader { return struct{ io.Reader }{r} }},
+		{"WriteTo", func() io.Reader { return r }},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.SetBytes(frameCount * frameSize)
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := r.Seek(0, io.SeekStart); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := io.Copy(nullWriter{}, tc.src()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func TestReaderEdgesParallel(t *testing.T) {
 	dec, err := zstd.NewReader(nil)
 	require.NoError(t, err)
@@ -416,6 +578,271 @@ func TestReadEnvironment(t *testing.T) {
 	require.ErrorIs(t, err, io.EOF)
 }
 
+func TestDefaultDecoder(t *testing.T) {
+	t.Parallel()
+
+	sr := &seekableBufferReaderAt{buf: checksum}
+	r, err := NewReader(sr, nil, WithDefaultDecoder())
+	require.NoError(t, err)
+
+	sr2 := r.(*readerImpl)
+	assert.NotNil(t, sr2.ownDec)
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), all)
+
+	require.NoError(t, r.Close())
+	// double close
+	require.NoError(t, r.Close())
+
+	// Explicitly passed decoder takes precedence.
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	r, err = NewReader(&seekableBufferReaderAt{buf: checksum}, dec, WithDefaultDecoder())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	assert.Nil(t, r.(*readerImpl).ownDec)
+}
+
+// misalignedReadEnvironment returns frame 1 shifted by one byte.
+type misalignedReadEnvironment struct {
+	fakeReadEnvironment
+}
+
+func (s *misalignedReadEnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	if index.ID == 1 {
+		return checksum[18 : 18+18], nil
+	}
+	return s.fakeReadEnvironment.GetFrameByIndex(index)
+}
+
+func TestReadFrameMagic(t *testing.T) {
+	t.Parallel()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	r, err := NewReader(nil, dec, WithREnvironment(&misalignedReadEnvironment{}))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	tmp := make([]byte, 4)
+	_, err = r.ReadAt(tmp, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("test"), tmp)
+
+	_, err = r.ReadAt(tmp, 4)
+	require.EqualError(t, err, "frame 1: expected ZSTD magic 0xFD2FB528, got 0x04FD2FB5 at offset 0x11")
+}
+
+type slowDecoder struct {
+	ZSTDDecoder
+	delay time.Duration
+	wg    sync.WaitGroup
+}
+
+func (d *slowDecoder) DecodeAll(input, dst []byte) ([]byte, error) {
+	defer d.wg.Done()
+	time.Sleep(d.delay)
+	return d.ZSTDDecoder.DecodeAll(input, dst)
+}
+
+func TestDecompressionTimeout(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = NewReader(nil, dec, WithDecompressionTimeout(-time.Second))
+	require.ErrorContains(t, err, "decompression timeout must not be negative")
+
+	slow := &slowDecoder{ZSTDDecoder: dec, delay: 100 * time.Millisecond}
+	// The abandoned decode must finish before dec is closed.
+	slow.wg.Add(1)
+	defer slow.wg.Wait()
+	r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, slow,
+		WithDecompressionTimeout(time.Millisecond))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	tmp := make([]byte, 4)
+	_, err = r.ReadAt(tmp, 4)
+	var timeoutErr *DecompressionTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, int64(1), timeoutErr.FrameID)
+
+	r, err = NewReader(&seekableBufferReaderAt{buf: checksum}, dec,
+		WithDecompressionTimeout(time.Minute))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), all)
+}
+
+func TestSizeValidation(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	for _, b := range [][]byte{checksum, noChecksum} {
+		r, err := NewReader(&seekableBufferReaderAt{buf: b}, dec, WithSizeValidation())
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+
+		// Extra data in front of the stream.
+		extended := append([]byte{0x00}, b...)
+		_, err = NewReader(&seekableBufferReaderAt{buf: extended}, dec)
+		require.NoError(t, err)
+		_, err = NewReader(&seekableBufferReaderAt{buf: extended}, dec, WithSizeValidation())
+		require.ErrorContains(t, err, "compressed size mismatch")
+
+		// Truncated first frame.
+		truncated := b[1:]
+		_, err = NewReader(&seekableBufferReaderAt{buf: truncated}, dec, WithSizeValidation())
+		require.ErrorContains(t, err, "compressed size mismatch")
+	}
+
+	// Environments without a ReadSeeker only check the seek table.
+	r, err := NewReader(nil, dec, WithREnvironment(&fakeReadEnvironment{}), WithSizeValidation())
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+}
+
+func TestStrictMode(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	open := func(stream []byte, opts ...rOption) error {
+		r, err := NewReader(bytes.NewReader(stream), dec, opts...)
+		if err != nil {
+			return err
+		}
+		all, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, sourceString, string(all))
+		return r.Close()
+	}
+
+	require.NoError(t, open(checksum, WithStrictMode(true)))
+
+	extra, err := createSkippableFrame(0x0, []byte("extra"))
+	require.NoError(t, err)
+
+	// Frame inserted before the seek table.
+	inserted := bytes.Join([][]byte{checksum[:35], extra, checksum[35:]}, nil)
+	require.NoError(t, open(inserted, WithStrictMode(false)))
+	const unlisted = "strict mode: unlisted skippable frame with unexpected tag 0x0 at offset 0x23"
+	require.ErrorContains(t, open(inserted, WithStrictMode(true)), unlisted)
+
+	// Readers without the stream size check the frame following the listed ones.
+	_, err = NewReader(nil, dec, WithREnvironment(NewReadSeekerEnv(bytes.NewReader(inserted))), WithStrictMode(true))
+	require.ErrorContains(t, err, unlisted)
+	_, err = NewReader(bytes.NewReader(inserted[:35]), dec, WithSeekTableBytes(inserted[35:]), WithStrictMode(true))
+	require.ErrorContains(t, err, "unexpected tag 0x0 at offset 0x0")
+
+	// Frames passed to NewDecoder along with the seek table.
+	d, err := NewDecoder(inserted[35:], dec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), d.NumFrames())
+	require.NoError(t, d.Close())
+	_, err = NewDecoder(inserted[35:], dec, WithStrictMode(true))
+	require.ErrorContains(t, err, "unexpected tag 0x0 at offset 0x0")
+	d, err = NewDecoder(checksum[35:], dec, WithStrictMode(true))
+	require.NoError(t, err)
+	require.NoError(t, d.Close())
+
+	// Frame listed in the seek table.
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRaw(extra, 0, 0))
+	_, err = w.Write([]byte("test2"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, open(b.Bytes(), WithStrictMode(false)))
+	require.ErrorContains(t, open(b.Bytes(), WithStrictMode(true)),
+		"strict mode: frame 1 is a skippable frame with unexpected tag 0x0")
+
+	// Frame metadata is rejected too, while the expected HMAC frame is allowed.
+	for _, tc := range []struct {
+		wOpts []wOption
+		rOpts []rOption
+		err   string
+	}{
+		{[]wOption{WithFrameMetadata("key", []byte("value"))}, nil, "unexpected tag 0xc"},
+		{[]wOption{WithWHMAC([]byte("key"))}, nil, "unexpected tag 0xb"},
+		{[]wOption{WithWHMAC([]byte("key"))}, []rOption{WithRHMAC([]byte("key"))}, ""},
+	} {
+		b.Reset()
+		w, err = NewWriter(&b, enc, tc.wOpts...)
+		require.NoError(t, err)
+		for _, s := range []string{"test", "test2"} {
+			_, err = w.Write([]byte(s))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		err = open(b.Bytes(), append(tc.rOpts, WithStrictMode(true))...)
+		if tc.err == "" {
+			require.NoError(t, err)
+		} else {
+			require.ErrorContains(t, err, tc.err)
+		}
+	}
+}
+
+func TestTelemetryHooks(t *testing.T) {
+	t.Parallel()
+
+	var footers, skipFrames int
+	var entries []env.FrameOffsetEntry
+	hooks := TelemetryHooks{
+		OnFooterRead: func(bytes []byte) {
+			footers++
+			assert.Equal(t, checksum[len(checksum)-seekTableFooterOffset:], bytes)
+		},
+		OnSkipFrameRead: func(bytes []byte) {
+			skipFrames++
+			assert.Equal(t, checksum[17+18:], bytes)
+		},
+		OnEntryParsed: func(entry env.FrameOffsetEntry) {
+			entries = append(entries, entry)
+		},
+	}
+
+	r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, nil, WithTelemetryHooks(hooks))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	assert.Equal(t, 1, footers)
+	assert.Equal(t, 1, skipFrames)
+	require.Len(t, entries, 2)
+	assert.Equal(t, *r.(*readerImpl).GetIndexByID(0), entries[0])
+	assert.Equal(t, *r.(*readerImpl).GetIndexByID(1), entries[1])
+
+	// Partially set hooks.
+	r, err = NewReader(&seekableBufferReaderAt{buf: checksum}, nil,
+		WithTelemetryHooks(TelemetryHooks{OnFooterRead: hooks.OnFooterRead}))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	assert.Equal(t, 2, footers)
+}
+
 func TestNoReaderAt(t *testing.T) {
 	t.Parallel()
 
@@ -552,19 +979,56 @@ func TestSeekTableParsing(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	// Reserved bits.
+	// Chunked.
+	err = stf.UnmarshalBinary([]byte{
+		0x00, 0x00, 0x00, 0x00,
+		(1 << 7) + (1 << 5),
+		0xb1, 0xea, 0x92, 0x8f,
+	})
+	require.NoError(t, err)
+	assert.True(t, stf.SeekTableDescriptor.ChunkedFlag)
+
+	// Compressed.
+	err = stf.UnmarshalBinary([]byte{
+		0x00, 0x00, 0x00, 0x00,
+		(1 << 7) + (1 << 3),
+		0xb1, 0xea, 0x92, 0x8f,
+	})
+	require.NoError(t, err)
+	assert.True(t, stf.SeekTableDescriptor.CompressedFlag)
+
+	// Varint.
 	err = stf.UnmarshalBinary([]byte{
 		0x00, 0x00, 0x00, 0x00,
 		0x84,
 		0xb1, 0xea, 0x92, 0x8f,
 	})
-	require.ErrorContains(t, err, "footer reserved bits")
+	require.NoError(t, err)
+	assert.True(t, stf.SeekTableDescriptor.VarintFlag)
+
+	// Encrypted, the last of reserved bits.
 	err = stf.UnmarshalBinary([]byte{
 		0x00, 0x00, 0x00, 0x00,
 		0x80 + 0x40,
 		0xb1, 0xea, 0x92, 0x8f,
 	})
-	require.ErrorContains(t, err, "footer reserved bits")
+	require.NoError(t, err)
+	assert.True(t, stf.SeekTableDescriptor.EncryptedFlag)
+
+	// Conflicting flags.
+	for _, descriptor := range []byte{
+		0x80 + 0x40 + 0x10, // Encrypted and hierarchical.
+		0x80 + 0x20 + 0x08, // Chunked and compressed.
+		0x80 + 0x08 + 0x04, // Compressed and varint.
+		0x80 + 0x7c,        // All of them.
+	} {
+		err = stf.UnmarshalBinary([]byte{
+			0x00, 0x00, 0x00, 0x00,
+			descriptor,
+			0xb1, 0xea, 0x92, 0x8f,
+		})
+		require.ErrorContains(t, err, "footer sets conflicting seek table flags", "%#02x", descriptor)
+	}
 
 	// Size.
 	err = stf.UnmarshalBinary([]byte{
@@ -580,3 +1044,757 @@ func TestSeekTableParsing(t *testing.T) {
 	})
 	require.ErrorContains(t, err, "footer magic mismatch")
 }
+
+func BenchmarkDecompressionTimeout(b *testing.B) {
+	dec, err := zstd.NewReader(nil)
+	require.NoError(b, err)
+	defer dec.Close()
+
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, dec,
+			WithDecompressionTimeout(timeout))
+		require.NoError(b, err)
+
+		b.Run(timeout.String(), func(b *testing.B) {
+			tmp := make([]byte, 4)
+			for i := 0; i < b.N; i++ {
+				// Alternate between frames so that every read is a cache miss.
+				_, err = r.ReadAt(tmp, int64(i%2)*4)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		require.NoError(b, r.Close())
+	}
+}
+
+func BenchmarkReadAt(b *testing.B) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(b, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(b, err)
+	defer dec.Close()
+
+	sizes := []int64{4 * 1024, 64 * 1024, 1 * 1024 * 1024}
+	for _, frameCount := range []int64{10, 1000} {
+		for _, sz := range sizes {
+			// Keep the benchmark's memory usage reasonable.
+			if frameCount*sz > 64*1024*1024 {
+				continue
+			}
+
+			rng := rand.New(rand.NewSource(0))
+			var stream bytes.Buffer
+			w, err := NewWriter(&stream, enc)
+			require.NoError(b, err)
+			frame := make([]byte, sz)
+			for j := range frame {
+				frame[j] = byte(rng.Intn(16))
+			}
+			for i := int64(0); i < frameCount; i++ {
+				// Frames differ, but are generated once per size.
+				binary.LittleEndian.PutUint64(frame, uint64(i))
+				_, err = w.Write(frame)
+				require.NoError(b, err)
+			}
+			require.NoError(b, w.Close())
+
+			r, err := NewReader(bytes.NewReader(stream.Bytes()), dec)
+			require.NoError(b, err)
+
+			b.Run(fmt.Sprintf("%d/%d/warm", frameCount, sz), func(b *testing.B) {
+				b.SetBytes(sz)
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					// The same frame is served from the cache.
+					if _, err := r.ReadAt(frame, 0); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run(fmt.Sprintf("%d/%d/cold", frameCount, sz), func(b *testing.B) {
+				rng := rand.New(rand.NewSource(1))
+				b.SetBytes(sz)
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					// Frames are accessed in random order, so most reads are cache misses.
+					if _, err := r.ReadAt(frame, rng.Int63n(frameCount)*sz); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run(fmt.Sprintf("%d/%d/sequential", frameCount, sz), func(b *testing.B) {
+				b.SetBytes(frameCount * sz)
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					if _, err := r.Seek(0, io.SeekStart); err != nil {
+						b.Fatal(err)
+					}
+					if _, err := io.Copy(io.Discard, r); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+
+			require.NoError(b, r.Close())
+		}
+	}
+}
+
+func BenchmarkCacheSize(b *testing.B) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(b, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(b, err)
+	defer dec.Close()
+
+	const frameSize = 64 * 1024
+	rng := rand.New(rand.NewSource(0))
+	var stream bytes.Buffer
+	w, err := NewWriter(&stream, enc)
+	require.NoError(b, err)
+	frame := make([]byte, frameSize)
+	for i := 0; i < 2; i++ {
+		for j := range frame {
+			frame[j] = byte(rng.Intn(16))
+		}
+		_, err = w.Write(frame)
+		require.NoError(b, err)
+	}
+	require.NoError(b, w.Close())
+
+	for _, size := range []int{1, 2} {
+		r, err := NewReader(bytes.NewReader(stream.Bytes()), dec, WithCacheSize(size))
+		require.NoError(b, err)
+
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			// Read across the boundary of adjacent frames, alternating between them.
+			tmp := make([]byte, 1024)
+			b.SetBytes(int64(len(tmp)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err = r.ReadAt(tmp, frameSize-int64(len(tmp))+int64(i%2)*int64(len(tmp)))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		require.NoError(b, r.Close())
+	}
+}
+
+func BenchmarkReadAheadFrames(b *testing.B) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(b, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(b, err)
+	defer dec.Close()
+
+	const (
+		frameCount = 500
+		frameSize  = 64 * 1024
+	)
+	rng := rand.New(rand.NewSource(0))
+	var stream bytes.Buffer
+	w, err := NewWriter(&stream, enc)
+	require.NoError(b, err)
+	frame := make([]byte, frameSize)
+	for i := 0; i < frameCount; i++ {
+		for j := range frame {
+			frame[j] = byte(rng.Intn(16))
+		}
+		_, err = w.Write(frame)
+		require.NoError(b, err)
+	}
+	require.NoError(b, w.Close())
+
+	for _, frames := range []int{0, 4, 16} {
+		b.Run(strconv.Itoa(frames), func(b *testing.B) {
+			var opts []rOption
+			if frames > 0 {
+				opts = append(opts, WithReadAheadFrames(frames))
+			}
+			b.SetBytes(frameCount * frameSize)
+
+			for i := 0; i < b.N; i++ {
+				r, err := NewReader(bytes.NewReader(stream.Bytes()), dec, opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err = io.Copy(io.Discard, r); err != nil {
+					b.Fatal(err)
+				}
+				if err = r.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestMagicPrefix(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	magic := []byte("SEEK")
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc, WithWMagicPrefix(magic))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteMany(context.Background(), makeTestFrameSource([][]byte{[]byte("test2")})))
+	require.NoError(t, w.Close())
+	assert.Equal(t, magic, b.Bytes()[:len(magic)])
+
+	r, err := NewReader(bytes.NewReader(b.Bytes()), dec, WithRMagicPrefix(magic), WithSizeValidation())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	assert.Equal(t, uint64(len(magic)), r.(*readerImpl).GetIndexByID(0).CompOffset)
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), all)
+
+	// Prefix is not accounted for.
+	r, err = NewReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	_, err = io.ReadAll(r)
+	require.ErrorContains(t, err, "expected ZSTD magic")
+
+	_, err = NewReader(bytes.NewReader(b.Bytes()), dec, WithRMagicPrefix([]byte("ZEEK")))
+	require.ErrorContains(t, err, "magic prefix mismatch")
+
+	// Prefix is written even without frames.
+	b.Reset()
+	w, err = NewWriter(&b, enc, WithWMagicPrefix(magic))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, magic, b.Bytes()[:len(magic)])
+
+	r, err = NewReader(bytes.NewReader(b.Bytes()), dec, WithRMagicPrefix(magic), WithSizeValidation())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	all, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+func TestDetectSeekable(t *testing.T) {
+	t.Parallel()
+
+	ok, err := DetectSeekable(bytes.NewReader(checksum))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = DetectSeekable(bytes.NewReader(checksum[:17+18]))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = DetectSeekable(bytes.NewReader(checksum[:4]))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCacheByteCapacity(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = NewReader(&seekableBufferReaderAt{buf: checksum}, dec, WithCacheByteCapacity(0))
+	require.ErrorContains(t, err, "cache byte capacity must be positive")
+
+	// "test" and "test2" do not fit together.
+	r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, dec, WithCacheByteCapacity(8))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), all)
+
+	sr := r.(*readerImpl)
+	assert.Equal(t, 1, sr.cache.len())
+	_, ok := sr.cache.get(1)
+	assert.True(t, ok)
+
+	// Both fit.
+	r, err = NewReader(&seekableBufferReaderAt{buf: checksum}, dec, WithCacheByteCapacity(9))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.(*readerImpl).cache.len())
+}
+
+func TestCacheSizeConcurrent(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	var expected []byte
+	for i := 0; i < 8; i++ {
+		frame := makeTestFrame(t, i)
+		expected = append(expected, frame...)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(b.Bytes()), dec, WithCacheSize(3))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(g)))
+			tmp := make([]byte, 100)
+			for i := 0; i < 200; i++ {
+				off := rng.Int63n(int64(len(expected) - len(tmp)))
+				n, err := r.ReadAt(tmp, off)
+				if !assert.NoError(t, err) || !assert.Equal(t, expected[off:off+int64(n)], tmp[:n]) {
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, r.(*readerImpl).cache.len(), 3)
+}
+
+func TestReadAheadFrames(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = NewReader(nil, dec, WithReadAheadFrames(0))
+	require.ErrorContains(t, err, "read-ahead frames must be positive")
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	var expected []byte
+	for i := 0; i < 20; i++ {
+		frame := makeTestFrame(t, i)
+		expected = append(expected, frame...)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(b.Bytes()), dec, WithReadAheadFrames(3))
+	require.NoError(t, err)
+	sr := r.(*readerImpl)
+
+	// Frames following the current one are prefetched into the cache.
+	tmp := make([]byte, 10)
+	_, err = r.Read(tmp)
+	require.NoError(t, err)
+	sr.readAheadWg.Wait()
+	for id := int64(0); id <= 3; id++ {
+		_, ok := sr.cache.get(id)
+		assert.True(t, ok, "frame %d", id)
+	}
+
+	// Seeking restarts read-ahead from the new position.
+	off, err := r.Seek(int64(sr.GetIndexByID(10).DecompOffset), io.SeekStart)
+	require.NoError(t, err)
+	_, err = r.Read(tmp)
+	require.NoError(t, err)
+	sr.readAheadWg.Wait()
+	_, ok := sr.cache.get(13)
+	assert.True(t, ok)
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, expected[off+int64(len(tmp)):], all)
+
+	_, err = r.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	all, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, expected, all)
+
+	// Close stops read-ahead.
+	_, err = r.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	_, err = r.Read(tmp)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, 0, sr.cache.len())
+}
+
+// blockingReadEnvironment blocks reads of the frame with the given id until release is closed.
+type blockingReadEnvironment struct {
+	env.REnvironment
+	id      int64
+	started chan struct{}
+	release chan struct{}
+	calls   atomic.Int64
+}
+
+func (b *blockingReadEnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	if index.ID == b.id {
+		if b.calls.Inc() == 1 {
+			close(b.started)
+		}
+		<-b.release
+	}
+	return b.REnvironment.GetFrameByIndex(index)
+}
+
+func TestReadAheadPending(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	var expected []byte
+	for i := 0; i < 5; i++ {
+		frame := makeTestFrame(t, i)
+		expected = append(expected, frame...)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	e := &blockingReadEnvironment{
+		REnvironment: NewReadSeekerEnv(bytes.NewReader(b.Bytes())),
+		id:           1,
+		started:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	r, err := NewReader(nil, dec, WithREnvironment(e), WithReadAheadFrames(1))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	sr := r.(*readerImpl)
+
+	tmp := make([]byte, 10)
+	_, err = r.Read(tmp)
+	require.NoError(t, err)
+	<-e.started
+
+	// Seek away and back, so that read-ahead restarts while frame 1 is still being decompressed.
+	_, err = r.Seek(int64(sr.GetIndexByID(3).DecompOffset), io.SeekStart)
+	require.NoError(t, err)
+	_, err = r.Read(tmp)
+	require.NoError(t, err)
+	_, err = r.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	_, err = r.Read(tmp)
+	require.NoError(t, err)
+
+	close(e.release)
+	rest := make([]byte, len(expected)-len(tmp))
+	_, err = io.ReadFull(r, rest)
+	require.NoError(t, err)
+	assert.Equal(t, expected[len(tmp):], rest)
+	sr.readAheadWg.Wait()
+	assert.Equal(t, int64(1), e.calls.Load())
+}
+
+func TestMaxFrameSize(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	for _, n := range []int64{0, -1, math.MaxUint32 + 1} {
+		_, err = NewReader(&seekableBufferReaderAt{buf: checksum}, dec, WithMaxFrameSize(n))
+		require.ErrorContains(t, err, "max frame size must be in")
+	}
+
+	r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, dec, WithMaxFrameSize(17))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	tmp := make([]byte, 4)
+	_, err = r.ReadAt(tmp, 0)
+	require.NoError(t, err)
+	_, err = r.ReadAt(tmp, 4)
+	require.ErrorContains(t, err, "index.CompSize is too big: 18 > 17")
+
+	if testing.Short() {
+		t.Skip("skipping frame over the default limit in short mode")
+	}
+
+	// Frame of raw blocks that is just above the default limit.
+	const (
+		contentSize  = maxDecoderFrameSize
+		maxBlockSize = 128 << 10
+	)
+	frame := make([]byte, 0, contentSize+contentSize/maxBlockSize*3+9)
+	frame = binary.LittleEndian.AppendUint32(frame, zstdFrameMagic)
+	// Single_Segment_Flag with 4-byte Frame_Content_Size.
+	frame = append(frame, 0xa0)
+	frame = binary.LittleEndian.AppendUint32(frame, contentSize)
+	for off := 0; off < contentSize; off += maxBlockSize {
+		header := uint32(maxBlockSize) << 3
+		if off+maxBlockSize >= contentSize {
+			header |= 1
+		}
+		frame = append(frame, byte(header), byte(header>>8), byte(header>>16))
+		frame = append(frame, make([]byte, maxBlockSize)...)
+	}
+	require.Greater(t, len(frame), maxDecoderFrameSize)
+
+	b := NewSeekTableBuilder(false)
+	b.AddFrame(uint32(len(frame)), contentSize, 0)
+	seekTable, err := b.Bytes()
+	require.NoError(t, err)
+	stream := append(frame, seekTable...)
+
+	r, err = NewReader(bytes.NewReader(stream), dec)
+	require.NoError(t, err)
+	_, err = r.ReadAt(tmp, contentSize-4)
+	require.ErrorContains(t, err, "index.CompSize is too big")
+	require.NoError(t, r.Close())
+
+	r, err = NewReader(bytes.NewReader(stream), dec, WithMaxFrameSize(256<<20))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	tmp[0] = 1
+	_, err = r.ReadAt(tmp, contentSize-4)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 4), tmp)
+}
+
+func TestChecksumValidation(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	r, err := NewReader(bytes.NewReader(noChecksum), dec, WithChecksumValidation(false))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	_, err = NewReader(bytes.NewReader(noChecksum), dec, WithChecksumValidation(true))
+	require.ErrorContains(t, err, "checksum verification failed: seek table has no checksums")
+
+	// Checksums supplied out-of-band.
+	r, err = NewReader(bytes.NewReader(noChecksum), dec,
+		WithChecksumValidation(true), WithSeekTableBytes(checksum[17+18:]))
+	require.NoError(t, err)
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), all)
+	require.ErrorContains(t, r.(Decoder).UnmarshalBinary(noChecksum[17+18:]), "seek table has no checksums")
+	require.NoError(t, r.Close())
+
+	corrupted := bytes.Clone(checksum)
+	corrupted[51] ^= 0xff
+	r, err = NewReader(bytes.NewReader(corrupted), dec, WithChecksumValidation(true))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	_, err = io.ReadAll(r)
+	require.ErrorContains(t, err, "checksum verification failed at: 0")
+}
+
+func TestReadSeekerEnv(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	e := &countingReadEnvironment{REnvironment: NewReadSeekerEnv(bytes.NewReader(checksum))}
+	r, err := NewReader(nil, dec, WithREnvironment(e))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), all)
+	assert.Equal(t, int64(2), e.calls.Load())
+}
+
+func TestReadAtMissing(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	// Intact stream.
+	r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	p := make([]byte, 9)
+	n, err := r.ReadAtMissing(p, 0, '?')
+	require.NoError(t, err)
+	assert.Equal(t, 9, n)
+	assert.Equal(t, []byte(sourceString), p)
+
+	n, err = r.ReadAtMissing(make([]byte, 10), 5, '?')
+	require.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 4, n)
+
+	// First frame is corrupted.
+	corrupted := bytes.Clone(checksum)
+	corrupted[12] ^= 0xff
+	r, err = NewReader(&seekableBufferReaderAt{buf: corrupted}, dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	_, err = r.ReadAt(p, 0)
+	require.Error(t, err)
+
+	p = make([]byte, 9)
+	n, err = r.ReadAtMissing(p, 1, '?')
+	var missing *FramesMissingError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, []int64{0}, missing.FrameIDs)
+	assert.Equal(t, 8, n)
+	assert.Equal(t, []byte("???test2\x00"), p)
+
+	// Other frames are still readable.
+	n, err = r.ReadAtMissing(p[:5], 4, '?')
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, []byte("test2"), p[:5])
+
+	_, err = r.ReadAtMissing(p, -1, '?')
+	require.ErrorContains(t, err, "offset before the start of the file")
+}
+
+// latencyReadEnvironment delays reading of frames, e.g. like an object storage.
+type latencyReadEnvironment struct {
+	env.REnvironment
+	latency time.Duration
+	failID  int64
+}
+
+func (e *latencyReadEnvironment) GetFrameByIndex(index env.FrameOffsetEntry) ([]byte, error) {
+	time.Sleep(e.latency)
+	if index.ID == e.failID {
+		return nil, fmt.Errorf("test error")
+	}
+	return e.REnvironment.GetFrameByIndex(index)
+}
+
+func TestParallelReadAt(t *testing.T) {
+	t.Parallel()
+
+	const numFrames, latency = 10, 50 * time.Millisecond
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = NewReader(nil, dec, WithParallelReadAt(0))
+	require.ErrorContains(t, err, "parallel ReadAt frames must be positive")
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	var expected []byte
+	for i := 0; i < numFrames; i++ {
+		frame := makeTestFrame(t, i)
+		expected = append(expected, frame...)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	newReader := func(failID int64, opts ...rOption) Reader {
+		e := &latencyReadEnvironment{
+			REnvironment: NewReadSeekerEnv(bytes.NewReader(b.Bytes())),
+			latency:      latency,
+			failID:       failID,
+		}
+		r, err := NewReader(nil, dec, append(opts, WithREnvironment(e))...)
+		require.NoError(t, err)
+		return r
+	}
+
+	r := newReader(-1, WithParallelReadAt(numFrames))
+	defer func() { require.NoError(t, r.Close()) }()
+
+	// Frames are fetched concurrently.
+	actual := make([]byte, len(expected))
+	start := time.Now()
+	n, err := r.ReadAt(actual, 0)
+	elapsed := time.Since(start)
+	require.NoError(t, err)
+	assert.Equal(t, len(expected), n)
+	assert.Equal(t, expected, actual)
+	assert.Less(t, elapsed, 5*latency)
+
+	// Partial frames at both ends.
+	actual = make([]byte, len(expected)-20)
+	n, err = r.ReadAt(actual, 10)
+	require.NoError(t, err)
+	assert.Equal(t, len(actual), n)
+	assert.Equal(t, expected[10:len(expected)-10], actual)
+
+	// Reads past the end.
+	n, err = r.ReadAt(actual, int64(len(expected)-5))
+	require.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, expected[len(expected)-5:], actual[:n])
+
+	// Sequential ReadAt waits for every frame.
+	seq := newReader(-1)
+	defer func() { require.NoError(t, seq.Close()) }()
+	start = time.Now()
+	_, err = seq.ReadAt(make([]byte, len(expected)), 0)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), numFrames*latency)
+
+	// Data of frames preceding the failed one is returned.
+	failing := newReader(5, WithParallelReadAt(3))
+	defer func() { require.NoError(t, failing.Close()) }()
+	n, err = failing.ReadAt(actual, 0)
+	require.ErrorContains(t, err, "test error")
+	off := failing.(Decoder).GetIndexByID(5).DecompOffset
+	assert.Equal(t, int(off), n)
+	assert.Equal(t, expected[:off], actual[:n])
+}
@@ -0,0 +1,53 @@
+package seekable
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// ReencodeStream recompresses each frame of the seekable stream src with dstEnc
+// (e.g. with a higher compression level) and writes the resulting seekable stream to dst.
+// Frame boundaries, and hence decompressed offsets and checksums, are preserved,
+// only the compressed sizes in the seek table change.
+//
+// Checksums of source frames are verified during decompression.
+func ReencodeStream(src io.ReadSeeker, dst io.Writer, srcDec ZSTDDecoder, dstEnc ZSTDEncoder) error {
+	sr, err := NewReader(src, srcDec)
+	if err != nil {
+		return fmt.Errorf("failed to open source stream: %w", err)
+	}
+	defer sr.Close()
+
+	r := sr.(*readerImpl)
+	if r.hierarchical {
+		return fmt.Errorf("hierarchical index is not supported")
+	}
+
+	w, err := NewWriter(dst, dstEnc)
+	if err != nil {
+		return err
+	}
+
+	r.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		frame := []byte{}
+		if index.DecompSize > 0 {
+			frame, err = r.decompressFrame(r.env, srcDec, index)
+			if err != nil {
+				return false
+			}
+		}
+
+		if _, err = w.Write(frame); err != nil {
+			err = fmt.Errorf("failed to write frame %d: %w", index.ID, err)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.Close()
+}
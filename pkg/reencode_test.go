@@ -0,0 +1,80 @@
+package seekable
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReencodeStream(t *testing.T) {
+	t.Parallel()
+
+	fast, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer fast.Close()
+	best, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	require.NoError(t, err)
+	defer best.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var src bytes.Buffer
+	w, err := NewWriter(&src, fast)
+	require.NoError(t, err)
+	var concat []byte
+	for i := 0; i < 10; i++ {
+		frame := makeTestFrame(t, i)
+		if i == 3 {
+			frame = []byte{}
+		}
+		concat = append(concat, frame...)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	var dst bytes.Buffer
+	require.NoError(t, ReencodeStream(bytes.NewReader(src.Bytes()), &dst, dec, best))
+	assert.NotEqual(t, src.Bytes(), dst.Bytes())
+
+	srcReader, err := NewReader(bytes.NewReader(src.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, srcReader.Close()) }()
+	dstReader, err := NewReader(bytes.NewReader(dst.Bytes()), dec, WithSizeValidation())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dstReader.Close()) }()
+
+	// Frames are preserved.
+	srcDecoder, dstDecoder := srcReader.(Decoder), dstReader.(Decoder)
+	require.Equal(t, srcDecoder.NumFrames(), dstDecoder.NumFrames())
+	for id := int64(0); id < srcDecoder.NumFrames(); id++ {
+		srcIndex, dstIndex := srcDecoder.GetIndexByID(id), dstDecoder.GetIndexByID(id)
+		assert.Equal(t, srcIndex.DecompOffset, dstIndex.DecompOffset)
+		assert.Equal(t, srcIndex.DecompSize, dstIndex.DecompSize)
+		assert.Equal(t, srcIndex.Checksum, dstIndex.Checksum)
+	}
+
+	// Data is identical.
+	for off := 0; off < len(concat); off += 131 {
+		srcBuf, dstBuf := make([]byte, 257), make([]byte, 257)
+		n, srcErr := srcReader.ReadAt(srcBuf, int64(off))
+		m, dstErr := dstReader.ReadAt(dstBuf, int64(off))
+		assert.Equal(t, srcErr, dstErr)
+		require.Equal(t, n, m)
+		assert.Equal(t, srcBuf[:n], dstBuf[:m])
+		assert.Equal(t, concat[off:off+n], dstBuf[:m])
+	}
+
+	// Corrupted source.
+	corrupted := bytes.Clone(src.Bytes())
+	corrupted[20] ^= 0xff
+	err = ReencodeStream(bytes.NewReader(corrupted), &bytes.Buffer{}, dec, best)
+	require.Error(t, err)
+
+	err = ReencodeStream(bytes.NewReader(src.Bytes()[:10]), &bytes.Buffer{}, dec, best)
+	require.ErrorContains(t, err, "failed to open source stream")
+}
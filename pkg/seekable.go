@@ -1,9 +1,11 @@
 package seekable
 
 import (
+	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
 	"math"
+	"math/bits"
 
 	"go.uber.org/zap/zapcore"
 )
@@ -40,15 +42,52 @@ const (
 
 	seekableMagicNumber uint32 = 0x8F92EAB1
 
+	// zstdFrameMagic is the magic number of a regular ZSTD frame.
+	zstdFrameMagic uint32 = 0xFD2FB528
+
 	seekTableFooterOffset = 9
 
 	frameSizeFieldSize            = 4
 	skippableMagicNumberFieldSize = 4
 
+	// chunkEntriesFieldSize is the size of `Chunk_Entries` in the last chunk of a chunked seek table.
+	chunkEntriesFieldSize = 4
+	// chunkedSeekTableTrailerSize is the size of the data following the entries in the last chunk.
+	chunkedSeekTableTrailerSize = chunkEntriesFieldSize + seekTableFooterOffset
+
 	// maxFrameSize is the maximum framesize supported by decoder.  This is to prevent OOMs due to untrusted input.
 	maxDecoderFrameSize = 128 << 20
 
 	seekableTag = 0xE
+	// payloadSizeFieldSize is the size of `Compressed_Size` of the compressed seek table
+	// and `Entries_Size` of the varint seek table.
+	payloadSizeFieldSize = 4
+	// payloadSeekTableTrailerSize is the size of the data following the entries
+	// of the compressed and varint seek tables.
+	payloadSeekTableTrailerSize = payloadSizeFieldSize + seekTableFooterOffset
+
+	// fineIndexTag is the skippable frame tag of the fine index of the hierarchical seek table.
+	fineIndexTag = 0xD
+
+	// metadataTag is the skippable frame tag of the frame metadata, see WithFrameMetadata.
+	metadataTag = 0xC
+	// metadataSizeFieldSize is the size of `Key_Size` and `Value_Size` of the frame metadata.
+	metadataSizeFieldSize = 4
+
+	// hmacTag is the skippable frame tag of the HMAC of the seek table, see WithWHMAC.
+	hmacTag = 0xB
+	// hmacFrameSize is the size of the HMAC frame: magic, `Frame_Size` and HMAC-SHA256.
+	hmacFrameSize = skippableMagicNumberFieldSize + frameSizeFieldSize + sha256.Size
+
+	// referenceTag is the skippable frame tag of the reference to a duplicate frame, see WithDeduplication.
+	referenceTag = 0xA
+	// referenceFrameSize is the size of the reference frame: magic, `Frame_Size`, `Frame_ID`, `Comp_Offset` and `Comp_Size`.
+	referenceFrameSize = skippableMagicNumberFieldSize + frameSizeFieldSize + 8 + 8 + 4
+
+	// firstFrameIDFieldSize is the size of `First_Frame_ID` of the fine index.
+	firstFrameIDFieldSize = 8
+	// fineIndexTrailerSize is the size of the data following the entries in the fine index.
+	fineIndexTrailerSize = firstFrameIDFieldSize + seekTableFooterOffset
 
 	// maximum size of a single frame
 	maxChunkSize int64 = math.MaxUint32
@@ -76,15 +115,63 @@ so a compliant decoder should ensure they are set to 0.
 
 `Unused_Bits` may be used in the future for non-breaking changes,
 so a compliant decoder should not interpret these bits.
+
+This implementation uses some of the `Reserved_Bits` for its own (breaking) extensions of the format:
+
+	| Bit number | Field name                |
+	| ---------- | ----------                |
+	| 6          | `Encrypted_Flag`          |
+	| 5          | `Chunked_Flag`            |
+	| 4          | `Hierarchical_Flag`       |
+	| 3          | `Compressed_Flag`         |
+	| 2          | `Varint_Flag`             |
 */
 type seekTableDescriptor struct {
 	// If the checksum flag is set, each of the seek table entries contains a 4 byte checksum
 	// of the uncompressed data contained in its frame.
 	ChecksumFlag bool
+
+	// If the chunked flag is set, the seek table is split across multiple skippable frames,
+	// see marshalChunkedSeekTable for the layout.
+	ChunkedFlag bool
+
+	// If the hierarchical flag is set, each seek table entry describes a span of frames
+	// followed by its own fine index, see marshalFineIndex for the layout.
+	HierarchicalFlag bool
+
+	// If the compressed flag is set, seek table entries are compressed with ZSTD,
+	// see marshalCompressedSeekTable for the layout.
+	CompressedFlag bool
+
+	// If the varint flag is set, seek table entries are delta and varint encoded,
+	// see marshalVarintSeekTable for the layout.
+	VarintFlag bool
+
+	// If the encrypted flag is set, seek table entries and frames are encrypted with AES-256-GCM,
+	// see marshalEncryptedSeekTable for the layout.
+	EncryptedFlag bool
 }
 
+const (
+	checksumFlagBit     uint8 = 1 << 7
+	chunkedFlagBit      uint8 = 1 << 5
+	hierarchicalFlagBit uint8 = 1 << 4
+	compressedFlagBit   uint8 = 1 << 3
+	varintFlagBit       uint8 = 1 << 2
+	encryptedFlagBit    uint8 = 1 << 6
+
+	// layoutFlagBits covers extensions of `Reserved_Bits` that change the seek table layout,
+	// all of them are taken, and at most one can be set.
+	layoutFlagBits uint8 = chunkedFlagBit | hierarchicalFlagBit | compressedFlagBit | varintFlagBit | encryptedFlagBit
+)
+
 func (d *seekTableDescriptor) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddBool("ChecksumFlag", d.ChecksumFlag)
+	enc.AddBool("ChunkedFlag", d.ChunkedFlag)
+	enc.AddBool("HierarchicalFlag", d.HierarchicalFlag)
+	enc.AddBool("CompressedFlag", d.CompressedFlag)
+	enc.AddBool("VarintFlag", d.VarintFlag)
+	enc.AddBool("EncryptedFlag", d.EncryptedFlag)
 	return nil
 }
 
@@ -111,7 +198,22 @@ type seekTableFooter struct {
 func (f *seekTableFooter) marshalBinaryInline(dst []byte) {
 	binary.LittleEndian.PutUint32(dst[0:], f.NumberOfFrames)
 	if f.SeekTableDescriptor.ChecksumFlag {
-		dst[4] |= 1 << 7
+		dst[4] |= checksumFlagBit
+	}
+	if f.SeekTableDescriptor.ChunkedFlag {
+		dst[4] |= chunkedFlagBit
+	}
+	if f.SeekTableDescriptor.HierarchicalFlag {
+		dst[4] |= hierarchicalFlagBit
+	}
+	if f.SeekTableDescriptor.CompressedFlag {
+		dst[4] |= compressedFlagBit
+	}
+	if f.SeekTableDescriptor.VarintFlag {
+		dst[4] |= varintFlagBit
+	}
+	if f.SeekTableDescriptor.EncryptedFlag {
+		dst[4] |= encryptedFlagBit
 	}
 	binary.LittleEndian.PutUint32(dst[5:], seekableMagicNumber)
 }
@@ -135,13 +237,17 @@ func (f *seekTableFooter) UnmarshalBinary(p []byte) error {
 	if len(p) != seekTableFooterOffset {
 		return fmt.Errorf("footer length mismatch %d vs %d", len(p), seekTableFooterOffset)
 	}
-	// Check that reserved bits are set to 0.
-	var reservedBits uint8 = (p[4] << 1) >> 3
-	if reservedBits != 0 {
-		return fmt.Errorf("footer reserved bits %d != 0", reservedBits)
+	// Writer never combines seek table layouts, so neither does the reader.
+	if layoutFlags := p[4] & layoutFlagBits; bits.OnesCount8(layoutFlags) > 1 {
+		return fmt.Errorf("footer sets conflicting seek table flags: %#02x", layoutFlags)
 	}
 	f.NumberOfFrames = binary.LittleEndian.Uint32(p[0:])
-	f.SeekTableDescriptor.ChecksumFlag = (p[4] & (1 << 7)) > 0
+	f.SeekTableDescriptor.ChecksumFlag = (p[4] & checksumFlagBit) > 0
+	f.SeekTableDescriptor.ChunkedFlag = (p[4] & chunkedFlagBit) > 0
+	f.SeekTableDescriptor.HierarchicalFlag = (p[4] & hierarchicalFlagBit) > 0
+	f.SeekTableDescriptor.CompressedFlag = (p[4] & compressedFlagBit) > 0
+	f.SeekTableDescriptor.VarintFlag = (p[4] & varintFlagBit) > 0
+	f.SeekTableDescriptor.EncryptedFlag = (p[4] & encryptedFlagBit) > 0
 	f.SeekableMagicNumber = binary.LittleEndian.Uint32(p[5:])
 	if f.SeekableMagicNumber != seekableMagicNumber {
 		return fmt.Errorf("footer magic mismatch %d vs %d", f.SeekableMagicNumber, seekableMagicNumber)
@@ -170,10 +276,14 @@ type seekTableEntry struct {
 	Checksum uint32
 }
 
+// marshalBinaryInline writes the entry into dst, the checksum is only written
+// if dst has room for it.
 func (e *seekTableEntry) marshalBinaryInline(dst []byte) {
 	binary.LittleEndian.PutUint32(dst[0:], e.CompressedSize)
 	binary.LittleEndian.PutUint32(dst[4:], e.DecompressedSize)
-	binary.LittleEndian.PutUint32(dst[8:], e.Checksum)
+	if len(dst) >= 12 {
+		binary.LittleEndian.PutUint32(dst[8:], e.Checksum)
+	}
 }
 
 func (e *seekTableEntry) MarshalBinary() ([]byte, error) {
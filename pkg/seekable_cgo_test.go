@@ -0,0 +1,111 @@
+//go:build cgo
+
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildCSeekableDecompressor compiles testdata/cseekable/decompress.c against zstd's reference
+// seekable format implementation.  Path to the zstd source tree is taken from ZSTD_SRC,
+// additional compiler/linker flags (e.g. location of libzstd) from CGO_CFLAGS/CGO_LDFLAGS.
+func buildCSeekableDecompressor(t *testing.T) string {
+	t.Helper()
+
+	zstdSrc := os.Getenv("ZSTD_SRC")
+	if zstdSrc == "" {
+		t.Skip("ZSTD_SRC is not set, skipping C reference implementation test")
+	}
+
+	cc := os.Getenv("CC")
+	if cc == "" {
+		cc = "cc"
+	}
+	if _, err := exec.LookPath(cc); err != nil {
+		t.Skipf("C compiler not found: %s", cc)
+	}
+
+	bin := filepath.Join(t.TempDir(), "decompress")
+	args := []string{
+		"-O2",
+		"-I" + filepath.Join(zstdSrc, "lib"),
+		"-I" + filepath.Join(zstdSrc, "lib", "common"),
+		"-I" + filepath.Join(zstdSrc, "contrib", "seekable_format"),
+		"-o", bin,
+		filepath.Join("testdata", "cseekable", "decompress.c"),
+		filepath.Join(zstdSrc, "contrib", "seekable_format", "zstdseek_decompress.c"),
+		filepath.Join(zstdSrc, "lib", "common", "xxhash.c"),
+	}
+	args = append(args, strings.Fields(os.Getenv("CGO_CFLAGS"))...)
+	args = append(args, strings.Fields(os.Getenv("CGO_LDFLAGS"))...)
+	args = append(args, "-lzstd")
+
+	out, err := exec.Command(cc, args...).CombinedOutput()
+	require.NoError(t, err, "failed to build C decompressor: %s", out)
+	return bin
+}
+
+func TestCIntercompat(t *testing.T) {
+	t.Parallel()
+
+	bin := buildCSeekableDecompressor(t)
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+
+	var frames [][]byte
+	var expected []byte
+	for i := 0; i < 100; i++ {
+		frame := makeTestFrame(t, i)
+		frames = append(frames, frame)
+		expected = append(expected, frame...)
+	}
+
+	write := func(opts ...wOption) string {
+		var b bytes.Buffer
+		w, err := NewWriter(&b, enc, opts...)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteMany(context.Background(), makeTestFrameSource(frames)))
+		require.NoError(t, w.Close())
+
+		fn := filepath.Join(t.TempDir(), "test.zst")
+		require.NoError(t, os.WriteFile(fn, b.Bytes(), 0o600))
+		return fn
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, write())
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	require.NoError(t, err, "C decompressor failed: %s", stderr.String())
+	assert.Equal(t, expected, out)
+
+	// Metadata frames are just frames without decompressed data.
+	stderr.Reset()
+	cmd = exec.Command(bin, write(WithFrameMetadata("name", []byte("test.txt"))))
+	cmd.Stderr = &stderr
+	out, err = cmd.Output()
+	require.NoError(t, err, "C decompressor failed: %s", stderr.String())
+	assert.Equal(t, expected, out)
+
+	// Extensions of the format are rejected.
+	for _, opt := range []wOption{WithChunkedSeekTable(7), WithHierarchicalIndex(7), WithCompressSeekTable(), WithVarintSeekTable()} {
+		stderr.Reset()
+		cmd = exec.Command(bin, write(opt))
+		cmd.Stderr = &stderr
+		_, err = cmd.Output()
+		require.Error(t, err)
+		assert.Contains(t, stderr.String(), "ZSTD_seekable_initFile")
+	}
+}
@@ -10,8 +10,14 @@ import (
 	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
 )
 
+func TestMain(m *testing.M) {
+	// Goroutines started before the tests (e.g. by the runtime) are not leaks.
+	goleak.VerifyTestMain(m, goleak.IgnoreCurrent())
+}
+
 type bytesErr struct {
 	tag           uint32
 	input         []byte
@@ -0,0 +1,33 @@
+// Package seekablepb contains protobuf representation of the seek table,
+// e.g. for storing it in a metadata service.
+package seekablepb
+
+import (
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative seekable.proto
+
+// ToProto converts a frame offset entry into its protobuf representation.
+func ToProto(e env.FrameOffsetEntry) *SeekTableEntry {
+	return &SeekTableEntry{
+		Id:           e.ID,
+		CompOffset:   e.CompOffset,
+		DecompOffset: e.DecompOffset,
+		CompSize:     e.CompSize,
+		DecompSize:   e.DecompSize,
+		Checksum:     e.Checksum,
+	}
+}
+
+// FromProto converts protobuf representation back into a frame offset entry.
+func FromProto(p *SeekTableEntry) env.FrameOffsetEntry {
+	return env.FrameOffsetEntry{
+		ID:           p.GetId(),
+		CompOffset:   p.GetCompOffset(),
+		DecompOffset: p.GetDecompOffset(),
+		CompSize:     p.GetCompSize(),
+		DecompSize:   p.GetDecompSize(),
+		Checksum:     p.GetChecksum(),
+	}
+}
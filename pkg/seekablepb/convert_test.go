@@ -0,0 +1,42 @@
+package seekablepb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	entries := []env.FrameOffsetEntry{
+		{},
+		{ID: 1, CompOffset: 17, DecompOffset: 4, CompSize: 18, DecompSize: 5, Checksum: 0x7111eb87},
+		{ID: 1<<40 + 1, CompOffset: 1 << 50, DecompOffset: 1 << 60, CompSize: 1<<32 - 1, DecompSize: 1<<32 - 1, Checksum: 1<<32 - 1},
+	}
+
+	st := &SeekTable{
+		Footer: &SeekTableFooter{NumberOfFrames: uint32(len(entries)), ChecksumFlag: true},
+	}
+	for _, e := range entries {
+		st.Entries = append(st.Entries, ToProto(e))
+	}
+
+	buf, err := proto.Marshal(st)
+	require.NoError(t, err)
+
+	var decoded SeekTable
+	require.NoError(t, proto.Unmarshal(buf, &decoded))
+	assert.True(t, proto.Equal(st, &decoded))
+
+	require.Len(t, decoded.GetEntries(), len(entries))
+	for i, p := range decoded.GetEntries() {
+		assert.Equal(t, entries[i], FromProto(p))
+	}
+	assert.Equal(t, uint32(len(entries)), decoded.GetFooter().GetNumberOfFrames())
+	assert.True(t, decoded.GetFooter().GetChecksumFlag())
+}
@@ -0,0 +1,17 @@
+module github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/seekablepb
+
+go 1.22
+
+require (
+	github.com/SaveTheRbtz/zstd-seekable-format-go/pkg v0.7.3
+	github.com/stretchr/testify v1.9.0
+	google.golang.org/protobuf v1.36.7
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
+	go.uber.org/zap v1.27.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,295 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: seekable.proto
+
+package seekablepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SeekTableEntry is the post-processed view of the Seek_Table_Entries suitable for indexing.
+// It mirrors env.FrameOffsetEntry.
+type SeekTableEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID is the sequence number of the frame in the index.
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// CompOffset is the offset within compressed stream.
+	CompOffset uint64 `protobuf:"varint,2,opt,name=comp_offset,json=compOffset,proto3" json:"comp_offset,omitempty"`
+	// DecompOffset is the offset within decompressed stream.
+	DecompOffset uint64 `protobuf:"varint,3,opt,name=decomp_offset,json=decompOffset,proto3" json:"decomp_offset,omitempty"`
+	// CompSize is the size of the compressed frame.
+	CompSize uint32 `protobuf:"varint,4,opt,name=comp_size,json=compSize,proto3" json:"comp_size,omitempty"`
+	// DecompSize is the size of the original data.
+	DecompSize uint32 `protobuf:"varint,5,opt,name=decomp_size,json=decompSize,proto3" json:"decomp_size,omitempty"`
+	// Checksum is the lower 32 bits of the XXH64 hash of the uncompressed data.
+	Checksum      uint32 `protobuf:"varint,6,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SeekTableEntry) Reset() {
+	*x = SeekTableEntry{}
+	mi := &file_seekable_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeekTableEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeekTableEntry) ProtoMessage() {}
+
+func (x *SeekTableEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_seekable_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeekTableEntry.ProtoReflect.Descriptor instead.
+func (*SeekTableEntry) Descriptor() ([]byte, []int) {
+	return file_seekable_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SeekTableEntry) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SeekTableEntry) GetCompOffset() uint64 {
+	if x != nil {
+		return x.CompOffset
+	}
+	return 0
+}
+
+func (x *SeekTableEntry) GetDecompOffset() uint64 {
+	if x != nil {
+		return x.DecompOffset
+	}
+	return 0
+}
+
+func (x *SeekTableEntry) GetCompSize() uint32 {
+	if x != nil {
+		return x.CompSize
+	}
+	return 0
+}
+
+func (x *SeekTableEntry) GetDecompSize() uint32 {
+	if x != nil {
+		return x.DecompSize
+	}
+	return 0
+}
+
+func (x *SeekTableEntry) GetChecksum() uint32 {
+	if x != nil {
+		return x.Checksum
+	}
+	return 0
+}
+
+// SeekTableFooter is the footer of a seekable ZSTD stream.
+type SeekTableFooter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The number of stored frames in the data.
+	NumberOfFrames uint32 `protobuf:"varint,1,opt,name=number_of_frames,json=numberOfFrames,proto3" json:"number_of_frames,omitempty"`
+	// If set, each of the seek table entries contains a checksum.
+	ChecksumFlag  bool `protobuf:"varint,2,opt,name=checksum_flag,json=checksumFlag,proto3" json:"checksum_flag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SeekTableFooter) Reset() {
+	*x = SeekTableFooter{}
+	mi := &file_seekable_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeekTableFooter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeekTableFooter) ProtoMessage() {}
+
+func (x *SeekTableFooter) ProtoReflect() protoreflect.Message {
+	mi := &file_seekable_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeekTableFooter.ProtoReflect.Descriptor instead.
+func (*SeekTableFooter) Descriptor() ([]byte, []int) {
+	return file_seekable_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SeekTableFooter) GetNumberOfFrames() uint32 {
+	if x != nil {
+		return x.NumberOfFrames
+	}
+	return 0
+}
+
+func (x *SeekTableFooter) GetChecksumFlag() bool {
+	if x != nil {
+		return x.ChecksumFlag
+	}
+	return false
+}
+
+// SeekTable is the whole seek table of a seekable ZSTD stream.
+type SeekTable struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*SeekTableEntry      `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	Footer        *SeekTableFooter       `protobuf:"bytes,2,opt,name=footer,proto3" json:"footer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SeekTable) Reset() {
+	*x = SeekTable{}
+	mi := &file_seekable_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeekTable) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeekTable) ProtoMessage() {}
+
+func (x *SeekTable) ProtoReflect() protoreflect.Message {
+	mi := &file_seekable_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeekTable.ProtoReflect.Descriptor instead.
+func (*SeekTable) Descriptor() ([]byte, []int) {
+	return file_seekable_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SeekTable) GetEntries() []*SeekTableEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *SeekTable) GetFooter() *SeekTableFooter {
+	if x != nil {
+		return x.Footer
+	}
+	return nil
+}
+
+var File_seekable_proto protoreflect.FileDescriptor
+
+const file_seekable_proto_rawDesc = "" +
+	"\n" +
+	"\x0eseekable.proto\x12\bseekable\"\xc0\x01\n" +
+	"\x0eSeekTableEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1f\n" +
+	"\vcomp_offset\x18\x02 \x01(\x04R\n" +
+	"compOffset\x12#\n" +
+	"\rdecomp_offset\x18\x03 \x01(\x04R\fdecompOffset\x12\x1b\n" +
+	"\tcomp_size\x18\x04 \x01(\rR\bcompSize\x12\x1f\n" +
+	"\vdecomp_size\x18\x05 \x01(\rR\n" +
+	"decompSize\x12\x1a\n" +
+	"\bchecksum\x18\x06 \x01(\rR\bchecksum\"`\n" +
+	"\x0fSeekTableFooter\x12(\n" +
+	"\x10number_of_frames\x18\x01 \x01(\rR\x0enumberOfFrames\x12#\n" +
+	"\rchecksum_flag\x18\x02 \x01(\bR\fchecksumFlag\"r\n" +
+	"\tSeekTable\x122\n" +
+	"\aentries\x18\x01 \x03(\v2\x18.seekable.SeekTableEntryR\aentries\x121\n" +
+	"\x06footer\x18\x02 \x01(\v2\x19.seekable.SeekTableFooterR\x06footerB?Z=github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/seekablepbb\x06proto3"
+
+var (
+	file_seekable_proto_rawDescOnce sync.Once
+	file_seekable_proto_rawDescData []byte
+)
+
+func file_seekable_proto_rawDescGZIP() []byte {
+	file_seekable_proto_rawDescOnce.Do(func() {
+		file_seekable_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_seekable_proto_rawDesc), len(file_seekable_proto_rawDesc)))
+	})
+	return file_seekable_proto_rawDescData
+}
+
+var file_seekable_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_seekable_proto_goTypes = []any{
+	(*SeekTableEntry)(nil),  // 0: seekable.SeekTableEntry
+	(*SeekTableFooter)(nil), // 1: seekable.SeekTableFooter
+	(*SeekTable)(nil),       // 2: seekable.SeekTable
+}
+var file_seekable_proto_depIdxs = []int32{
+	0, // 0: seekable.SeekTable.entries:type_name -> seekable.SeekTableEntry
+	1, // 1: seekable.SeekTable.footer:type_name -> seekable.SeekTableFooter
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_seekable_proto_init() }
+func file_seekable_proto_init() {
+	if File_seekable_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_seekable_proto_rawDesc), len(file_seekable_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_seekable_proto_goTypes,
+		DependencyIndexes: file_seekable_proto_depIdxs,
+		MessageInfos:      file_seekable_proto_msgTypes,
+	}.Build()
+	File_seekable_proto = out.File
+	file_seekable_proto_goTypes = nil
+	file_seekable_proto_depIdxs = nil
+}
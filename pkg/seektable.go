@@ -0,0 +1,160 @@
+package seekable
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// SeekTableEntry describes a single frame of the seek table.
+type SeekTableEntry struct {
+	// CompOffset is the offset within compressed stream.
+	CompOffset uint64
+	// DecompOffset is the offset within decompressed stream.
+	DecompOffset uint64
+	// CompSize is the size of the compressed frame.
+	CompSize uint32
+	// DecompSize is the size of the original data.
+	DecompSize uint32
+	// Checksum is the lower 32 bits of the XXH64 hash of the uncompressed data.
+	// It is only meaningful if SeekTable's ChecksumFlag is set.
+	Checksum uint32
+}
+
+// SeekTable is the parsed seek table of a seekable stream.  It is useful for exporting
+// frame metadata to external tools, e.g. via JSON, and importing it back.
+type SeekTable struct {
+	Entries      []SeekTableEntry
+	ChecksumFlag bool
+}
+
+var (
+	_ json.Marshaler   = (*SeekTable)(nil)
+	_ json.Unmarshaler = (*SeekTable)(nil)
+)
+
+// ExtractSeekTable parses the seek table of the stream.
+// Chunked, compressed and varint seek tables are supported, hierarchical index is not.
+func ExtractSeekTable(rs io.ReadSeeker) (*SeekTable, error) {
+	r, err := NewReader(rs, nil, WithDefaultDecoder())
+	if err != nil {
+		return nil, err
+	}
+	sr := r.(*readerImpl)
+	defer sr.Close()
+
+	if sr.hierarchical {
+		return nil, fmt.Errorf("hierarchical index is not supported")
+	}
+
+	st := &SeekTable{
+		Entries:      make([]SeekTableEntry, 0, sr.numFrames),
+		ChecksumFlag: sr.checksums,
+	}
+	sr.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		st.Entries = append(st.Entries, SeekTableEntry{
+			CompOffset:   index.CompOffset,
+			DecompOffset: index.DecompOffset,
+			CompSize:     index.CompSize,
+			DecompSize:   index.DecompSize,
+			Checksum:     index.Checksum,
+		})
+		return true
+	})
+	return st, nil
+}
+
+// MarshalBinary serializes the seek table into a seek table skippable frame
+// that can be appended to the frames or passed to NewDecoder.
+// Entries' offsets are not stored in the seek table and are ignored.
+func (st *SeekTable) MarshalBinary() ([]byte, error) {
+	entries := make([]seekTableEntry, 0, len(st.Entries))
+	for _, e := range st.Entries {
+		entry := seekTableEntry{
+			CompressedSize:   e.CompSize,
+			DecompressedSize: e.DecompSize,
+		}
+		if st.ChecksumFlag {
+			entry.Checksum = e.Checksum
+		}
+		entries = append(entries, entry)
+	}
+	return marshalSeekTable(entries, st.ChecksumFlag)
+}
+
+type jsonSeekTableEntry struct {
+	CompOffset   uint64  `json:"compOffset"`
+	DecompOffset uint64  `json:"decompOffset"`
+	CompSize     uint32  `json:"compSize"`
+	DecompSize   uint32  `json:"decompSize"`
+	Checksum     *uint32 `json:"checksum,omitempty"`
+}
+
+type jsonSeekTable struct {
+	ChecksumFlag bool                 `json:"checksumFlag"`
+	Entries      []jsonSeekTableEntry `json:"entries"`
+}
+
+// MarshalJSON encodes the seek table as a JSON object with `checksumFlag` and `entries` fields.
+// Each entry has `compOffset`, `decompOffset`, `compSize`, `decompSize`
+// and, if ChecksumFlag is set, `checksum` fields.
+func (st *SeekTable) MarshalJSON() ([]byte, error) {
+	js := jsonSeekTable{
+		ChecksumFlag: st.ChecksumFlag,
+		Entries:      make([]jsonSeekTableEntry, 0, len(st.Entries)),
+	}
+	for _, e := range st.Entries {
+		entry := jsonSeekTableEntry{
+			CompOffset:   e.CompOffset,
+			DecompOffset: e.DecompOffset,
+			CompSize:     e.CompSize,
+			DecompSize:   e.DecompSize,
+		}
+		if st.ChecksumFlag {
+			checksum := e.Checksum
+			entry.Checksum = &checksum
+		}
+		js.Entries = append(js.Entries, entry)
+	}
+	return json.Marshal(js)
+}
+
+// UnmarshalJSON decodes the seek table encoded by MarshalJSON.
+// Offsets must be consistent with sizes of the preceding entries.
+func (st *SeekTable) UnmarshalJSON(data []byte) error {
+	var js jsonSeekTable
+	if err := json.Unmarshal(data, &js); err != nil {
+		return err
+	}
+
+	entries := make([]SeekTableEntry, 0, len(js.Entries))
+	var compOffset, decompOffset uint64
+	for i, e := range js.Entries {
+		if e.CompOffset != compOffset || e.DecompOffset != decompOffset {
+			return fmt.Errorf("entry %d: offset mismatch: expected: %d/%d, actual: %d/%d",
+				i, compOffset, decompOffset, e.CompOffset, e.DecompOffset)
+		}
+		entry := SeekTableEntry{
+			CompOffset:   e.CompOffset,
+			DecompOffset: e.DecompOffset,
+			CompSize:     e.CompSize,
+			DecompSize:   e.DecompSize,
+		}
+		if js.ChecksumFlag {
+			if e.Checksum == nil {
+				return fmt.Errorf("entry %d: checksum is missing", i)
+			}
+			entry.Checksum = *e.Checksum
+		}
+		entries = append(entries, entry)
+
+		compOffset += uint64(e.CompSize)
+		decompOffset += uint64(e.DecompSize)
+	}
+
+	st.Entries = entries
+	st.ChecksumFlag = js.ChecksumFlag
+	return nil
+}
@@ -0,0 +1,82 @@
+package seekable
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeekTableJSON(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		_, err = w.Write(makeTestFrame(t, i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	for _, stream := range [][]byte{checksum, noChecksum, b.Bytes()} {
+		st, err := ExtractSeekTable(bytes.NewReader(stream))
+		require.NoError(t, err)
+
+		data, err := json.Marshal(st)
+		require.NoError(t, err)
+
+		var parsed SeekTable
+		require.NoError(t, json.Unmarshal(data, &parsed))
+		assert.Equal(t, st, &parsed)
+
+		seekTable, err := parsed.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, stream[len(stream)-len(seekTable):], seekTable)
+
+		// Frames followed by the seek table are the original stream.
+		last := parsed.Entries[len(parsed.Entries)-1]
+		assert.Equal(t, int(last.CompOffset)+int(last.CompSize)+len(seekTable), len(stream))
+	}
+
+	st, err := ExtractSeekTable(bytes.NewReader(checksum))
+	require.NoError(t, err)
+	data, err := json.Marshal(st)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"checksumFlag":true,"entries":[
+		{"compOffset":0,"decompOffset":0,"compSize":17,"decompSize":4,"checksum":3680993593},
+		{"compOffset":17,"decompOffset":4,"compSize":18,"decompSize":5,"checksum":1896999815}]}`, string(data))
+
+	st, err = ExtractSeekTable(bytes.NewReader(noChecksum))
+	require.NoError(t, err)
+	data, err = json.Marshal(st)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "checksum\":")
+
+	// Errors.
+	var parsed SeekTable
+	require.ErrorContains(t, json.Unmarshal([]byte(`{"checksumFlag":true,"entries":[
+		{"compOffset":0,"decompOffset":0,"compSize":17,"decompSize":4}]}`), &parsed),
+		"entry 0: checksum is missing")
+	require.ErrorContains(t, json.Unmarshal([]byte(`{"entries":[
+		{"compOffset":0,"decompOffset":0,"compSize":17,"decompSize":4},
+		{"compOffset":18,"decompOffset":4,"compSize":17,"decompSize":4}]}`), &parsed),
+		"entry 1: offset mismatch: expected: 17/4, actual: 18/4")
+
+	_, err = ExtractSeekTable(bytes.NewReader([]byte("not a seekable stream")))
+	require.Error(t, err)
+
+	b.Reset()
+	w, err = NewWriter(&b, enc, WithHierarchicalIndex(2))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, err = ExtractSeekTable(bytes.NewReader(b.Bytes()))
+	require.ErrorContains(t, err, "hierarchical index is not supported")
+}
@@ -0,0 +1,69 @@
+package seekable
+
+import (
+	"fmt"
+	"io"
+)
+
+// sidecarWriter writes the seek table to index instead of the data stream on Close.
+type sidecarWriter struct {
+	Writer
+	index  io.Writer
+	closed bool
+}
+
+// NewSidecarWriter is like NewWriter, but data only receives ZSTD frames (and skippable frames
+// of metadata, if any), while the seek table is written to index on Close, e.g. for object stores
+// where appending to a large object is impractical.  Streams written this way can be read with
+// NewSidecarReader.
+//
+// Hierarchical index and HMAC are not supported, see EndStreamTo.
+func NewSidecarWriter(data io.Writer, index io.Writer, encoder ZSTDEncoder, opts ...wOption) (Writer, error) {
+	w, err := NewWriter(data, encoder, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s := w.(*writerImpl)
+	if s.spanFrames > 0 {
+		return nil, fmt.Errorf("sidecar seek table is not supported with hierarchical index")
+	}
+	if s.hmacKey != nil {
+		return nil, fmt.Errorf("sidecar seek table is not supported with HMAC")
+	}
+
+	return &sidecarWriter{Writer: w, index: index}, nil
+}
+
+func (s *sidecarWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if _, err := s.Writer.EndStreamTo(s.index); err != nil {
+		return err
+	}
+	return s.Writer.Close()
+}
+
+// ResetTo starts a new stream written to w, its seek table is written to the same index writer.
+func (s *sidecarWriter) ResetTo(w io.Writer) {
+	s.Writer.ResetTo(w)
+	s.closed = false
+}
+
+// NewSidecarReader returns the Reader of the stream written by NewSidecarWriter:
+// the seek table is read from index, while frames are read from data.
+// opts are the same as for NewReader, see also WithSeekTableBytes.
+func NewSidecarReader(data io.ReadSeeker, index io.ReadSeeker, decoder ZSTDDecoder, opts ...rOption) (Reader, error) {
+	if _, err := index.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to the start of the index: %w", err)
+	}
+	seekTable, err := io.ReadAll(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	return NewReader(data, decoder, append(opts, WithSeekTableBytes(seekTable))...)
+}
@@ -0,0 +1,72 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSidecar(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var data, index bytes.Buffer
+	w, err := NewSidecarWriter(&data, &index, enc)
+	require.NoError(t, err)
+	var original []byte
+	for i := 0; i < 5; i++ {
+		frame := makeTestFrame(t, i)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		original = append(original, frame...)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+
+	// Data is a regular multi-frame ZSTD stream without the seek table.
+	isSeekable, err := DetectSeekable(bytes.NewReader(data.Bytes()))
+	require.NoError(t, err)
+	assert.False(t, isSeekable)
+	plain, err := dec.DecodeAll(data.Bytes(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, original, plain)
+
+	r, err := NewSidecarReader(bytes.NewReader(data.Bytes()), bytes.NewReader(index.Bytes()), dec,
+		WithSizeValidation())
+	require.NoError(t, err)
+	d := r.(Decoder)
+	assert.Equal(t, int64(5), d.NumFrames())
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, original, all)
+	require.NoError(t, r.Close())
+
+	// Reused writer writes the seek table of the new stream to the same index.
+	var data2 bytes.Buffer
+	index.Reset()
+	w.ResetTo(&data2)
+	_, err = w.Write([]byte(sourceString))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	r, err = NewSidecarReader(bytes.NewReader(data2.Bytes()), bytes.NewReader(index.Bytes()), dec)
+	require.NoError(t, err)
+	all, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, sourceString, string(all))
+	require.NoError(t, r.Close())
+
+	_, err = NewSidecarReader(bytes.NewReader(data.Bytes()), bytes.NewReader(nil), dec)
+	assert.ErrorContains(t, err, "seek table is empty")
+
+	_, err = NewSidecarWriter(&data, &index, enc, WithHierarchicalIndex(2))
+	assert.ErrorContains(t, err, "not supported with hierarchical index")
+}
@@ -0,0 +1,75 @@
+package seekable
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Slice writes the standalone seekable stream to dst that decompresses to the [decompStart, decompEnd)
+// range of src.  Frames fully contained in the range are copied as is with WriteRaw, while frames
+// crossing its boundaries are decompressed with decoder and only their needed parts are recompressed
+// with encoder.  Frames without data, e.g. metadata, are dropped.
+//
+// Streams with hierarchical index are not supported.
+func Slice(dst io.Writer, src io.ReadSeeker, decoder ZSTDDecoder, encoder ZSTDEncoder, decompStart, decompEnd uint64) error {
+	sr, err := NewReader(src, decoder, WithDefaultDecoder())
+	if err != nil {
+		return fmt.Errorf("failed to open source stream: %w", err)
+	}
+	defer sr.Close()
+
+	r := sr.(*readerImpl)
+	if r.hierarchical {
+		return fmt.Errorf("hierarchical index is not supported")
+	}
+	if decompStart > decompEnd || decompEnd > uint64(r.endOffset) {
+		return fmt.Errorf("invalid range: [%d, %d) for stream of size %d", decompStart, decompEnd, r.endOffset)
+	}
+
+	w, err := NewWriter(dst, encoder)
+	if err != nil {
+		return err
+	}
+	if err = sliceFrames(w, r, decompStart, decompEnd); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// sliceFrames writes the [decompStart, decompEnd) range of r to w, see Slice.
+func sliceFrames(w Writer, r *readerImpl, decompStart, decompEnd uint64) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for index := range r.IterFrames(ctx) {
+		frameStart, frameEnd := index.DecompOffset, index.DecompOffset+uint64(index.DecompSize)
+		if frameStart >= decompEnd {
+			break
+		}
+		if frameEnd <= decompStart || index.DecompSize == 0 {
+			continue
+		}
+
+		if frameStart >= decompStart && frameEnd <= decompEnd {
+			frame, checksum, err := r.rawFrame(index)
+			if err != nil {
+				return err
+			}
+			if err = w.WriteRaw(frame, index.DecompSize, checksum); err != nil {
+				return fmt.Errorf("failed to write frame %d: %w", index.ID, err)
+			}
+			continue
+		}
+
+		decompressed, err := r.decompressFrame(r.env, r.dec, index)
+		if err != nil {
+			return fmt.Errorf("failed to decompress frame %d: %w", index.ID, err)
+		}
+		part := decompressed[max(frameStart, decompStart)-frameStart : min(frameEnd, decompEnd)-frameStart]
+		if _, err = w.Write(part); err != nil {
+			return fmt.Errorf("failed to write part of frame %d: %w", index.ID, err)
+		}
+	}
+	return nil
+}
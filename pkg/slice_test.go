@@ -0,0 +1,79 @@
+package seekable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlice(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	const numFrames, frameSize = 10, 1000
+	rng := rand.New(rand.NewSource(1))
+	var src bytes.Buffer
+	w, err := NewWriter(&src, enc)
+	require.NoError(t, err)
+	var original []byte
+	for i := 0; i < numFrames; i++ {
+		frame := make([]byte, frameSize)
+		_, _ = rng.Read(frame[:frameSize/2])
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		original = append(original, frame...)
+	}
+	require.NoError(t, w.Close())
+
+	for _, tc := range []struct {
+		start, end uint64
+		numFrames  int64
+	}{
+		{0, numFrames * frameSize, numFrames},
+		{2000, 5000, 3},
+		{1500, 1700, 1},
+		{1500, 7300, 7},
+		{999, 1001, 2},
+		{3000, 3000, 0},
+		{numFrames * frameSize, numFrames * frameSize, 0},
+	} {
+		t.Run(fmt.Sprintf("%d-%d", tc.start, tc.end), func(t *testing.T) {
+			var dst bytes.Buffer
+			require.NoError(t, Slice(&dst, bytes.NewReader(src.Bytes()), dec, enc, tc.start, tc.end))
+
+			r, err := NewReader(bytes.NewReader(dst.Bytes()), dec, WithSizeValidation())
+			require.NoError(t, err)
+			defer func() { require.NoError(t, r.Close()) }()
+			assert.Equal(t, tc.numFrames, r.(Decoder).NumFrames())
+
+			actual, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, original[tc.start:tc.end], actual)
+		})
+	}
+
+	t.Run("aligned frames are copied", func(t *testing.T) {
+		var dst bytes.Buffer
+		require.NoError(t, Slice(&dst, bytes.NewReader(src.Bytes()), dec, enc, 0, numFrames*frameSize))
+		assert.Equal(t, src.Bytes(), dst.Bytes())
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		var dst bytes.Buffer
+		err := Slice(&dst, bytes.NewReader(src.Bytes()), dec, enc, 2, 1)
+		require.ErrorContains(t, err, "invalid range: [2, 1)")
+		err = Slice(&dst, bytes.NewReader(src.Bytes()), dec, enc, 0, numFrames*frameSize+1)
+		require.ErrorContains(t, err, "invalid range")
+	})
+}
@@ -0,0 +1,48 @@
+package seekable
+
+import (
+	"fmt"
+	"io"
+)
+
+// Split divides src into standalone seekable streams, e.g. for parallel processing of shards.
+// Segment i decompresses to the [i*segmentDecompSize, (i+1)*segmentDecompSize) range of src
+// (the last one may be shorter) and is written to the writer returned by outputFn(i).
+// As with Slice, frames fully contained in a segment are copied as is, while frames crossing
+// segment boundaries are decompressed with decoder and their parts are recompressed with encoder,
+// so streams with frames aligned to segmentDecompSize are split without recompression.
+//
+// Streams with hierarchical index are not supported.
+func Split(src io.ReadSeeker, decoder ZSTDDecoder, encoder ZSTDEncoder, segmentDecompSize uint64,
+	outputFn func(segIndex int) io.Writer,
+) error {
+	if segmentDecompSize == 0 {
+		return fmt.Errorf("segment size must be positive")
+	}
+
+	sr, err := NewReader(src, decoder, WithDefaultDecoder())
+	if err != nil {
+		return fmt.Errorf("failed to open source stream: %w", err)
+	}
+	defer sr.Close()
+
+	r := sr.(*readerImpl)
+	if r.hierarchical {
+		return fmt.Errorf("hierarchical index is not supported")
+	}
+
+	size := uint64(r.endOffset)
+	for i, start := 0, uint64(0); start < size; i, start = i+1, start+segmentDecompSize {
+		w, err := NewWriter(outputFn(i), encoder)
+		if err != nil {
+			return err
+		}
+		if err = sliceFrames(w, r, start, min(start+segmentDecompSize, size)); err != nil {
+			return fmt.Errorf("segment %d: %w", i, err)
+		}
+		if err = w.Close(); err != nil {
+			return fmt.Errorf("segment %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package seekable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	const numFrames, frameSize = 10, 1000
+	rng := rand.New(rand.NewSource(1))
+	var src bytes.Buffer
+	w, err := NewWriter(&src, enc)
+	require.NoError(t, err)
+	var original []byte
+	for i := 0; i < numFrames; i++ {
+		frame := make([]byte, frameSize)
+		_, _ = rng.Read(frame[:frameSize/2])
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		original = append(original, frame...)
+	}
+	require.NoError(t, w.Close())
+
+	for _, tc := range []struct {
+		segmentSize uint64
+		numFrames   []int64
+	}{
+		{3 * frameSize, []int64{3, 3, 3, 1}},
+		{2500, []int64{3, 3, 3, 3}},
+		{numFrames * frameSize, []int64{numFrames}},
+		{100 * frameSize, []int64{numFrames}},
+	} {
+		t.Run(fmt.Sprint(tc.segmentSize), func(t *testing.T) {
+			var segments []*bytes.Buffer
+			err := Split(bytes.NewReader(src.Bytes()), dec, enc, tc.segmentSize, func(i int) io.Writer {
+				require.Equal(t, len(segments), i)
+				segments = append(segments, &bytes.Buffer{})
+				return segments[i]
+			})
+			require.NoError(t, err)
+			require.Len(t, segments, len(tc.numFrames))
+
+			for i, segment := range segments {
+				r, err := NewReader(bytes.NewReader(segment.Bytes()), dec, WithSizeValidation())
+				require.NoError(t, err)
+				assert.Equal(t, tc.numFrames[i], r.(Decoder).NumFrames(), "segment %d", i)
+
+				actual, err := io.ReadAll(r)
+				require.NoError(t, err)
+				start := uint64(i) * tc.segmentSize
+				assert.Equal(t, original[start:min(start+tc.segmentSize, uint64(len(original)))], actual, "segment %d", i)
+				require.NoError(t, r.Close())
+			}
+		})
+	}
+
+	t.Run("aligned frames are copied", func(t *testing.T) {
+		sr, err := NewReader(bytes.NewReader(src.Bytes()), dec)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, sr.Close()) }()
+		d := sr.(Decoder)
+
+		var segments []*bytes.Buffer
+		err = Split(bytes.NewReader(src.Bytes()), dec, enc, 3*frameSize, func(i int) io.Writer {
+			segments = append(segments, &bytes.Buffer{})
+			return segments[i]
+		})
+		require.NoError(t, err)
+
+		for i, segment := range segments {
+			first, last := d.GetIndexByID(int64(3*i)), d.GetIndexByID(min(int64(3*i+2), numFrames-1))
+			frames := src.Bytes()[first.CompOffset : last.CompOffset+uint64(last.CompSize)]
+			assert.True(t, bytes.HasPrefix(segment.Bytes(), frames), "segment %d", i)
+		}
+	})
+
+	t.Run("invalid segment size", func(t *testing.T) {
+		err := Split(bytes.NewReader(src.Bytes()), dec, enc, 0, func(int) io.Writer { return io.Discard })
+		require.ErrorContains(t, err, "segment size must be positive")
+	})
+}
@@ -0,0 +1,43 @@
+package seekable
+
+import (
+	"go.uber.org/atomic"
+)
+
+// Stats are cumulative statistics of the reader, see Reader.Stats.
+type Stats struct {
+	// FramesRead is the number of frame accesses by reads, i.e. CacheHits + CacheMisses.
+	FramesRead uint64
+	// CacheHits is the number of frame accesses served from the frame cache.
+	CacheHits uint64
+	// CacheMisses is the number of frame accesses that had to wait for decompression.
+	CacheMisses uint64
+	// BytesDecompressed is the size of decompressed frames, including prefetched ones.
+	BytesDecompressed uint64
+	// BytesReadCompressed is the size of compressed frames read from the environment.
+	BytesReadCompressed uint64
+	// SeeksIssued is the number of Seek calls.
+	SeeksIssued uint64
+}
+
+// readerStats are atomically updated counters backing Stats.
+type readerStats struct {
+	framesRead          atomic.Uint64
+	cacheHits           atomic.Uint64
+	cacheMisses         atomic.Uint64
+	bytesDecompressed   atomic.Uint64
+	bytesReadCompressed atomic.Uint64
+	seeksIssued         atomic.Uint64
+}
+
+// Stats returns a snapshot of the reader statistics.  This method is goroutine-safe.
+func (r *readerImpl) Stats() Stats {
+	return Stats{
+		FramesRead:          r.stats.framesRead.Load(),
+		CacheHits:           r.stats.cacheHits.Load(),
+		CacheMisses:         r.stats.cacheMisses.Load(),
+		BytesDecompressed:   r.stats.bytesDecompressed.Load(),
+		BytesReadCompressed: r.stats.bytesReadCompressed.Load(),
+		SeeksIssued:         r.stats.seeksIssued.Load(),
+	}
+}
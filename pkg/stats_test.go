@@ -0,0 +1,77 @@
+package seekable
+
+import (
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderStats(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	assert.Equal(t, Stats{}, r.Stats())
+
+	tmp := make([]byte, 3)
+	for i := 0; i < 3; i++ {
+		_, err = r.Read(tmp)
+		require.NoError(t, err)
+	}
+	_, err = r.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	// Frame 0 was evicted from the single-frame cache by frame 1.
+	_, err = r.ReadAt(tmp, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, Stats{
+		FramesRead:          4,
+		CacheHits:           1,
+		CacheMisses:         3,
+		BytesDecompressed:   4 + 5 + 4,
+		BytesReadCompressed: 17 + 18 + 17,
+		SeeksIssued:         1,
+	}, r.Stats())
+}
+
+func TestReaderStatsConcurrent(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, dec, WithCacheSize(2))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	const readers, reads = 4, 100
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tmp := make([]byte, 1)
+			for j := 0; j < reads; j++ {
+				_, err := r.ReadAt(tmp, int64(j%len(sourceString)))
+				assert.NoError(t, err)
+				_ = r.Stats()
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := r.Stats()
+	assert.Equal(t, uint64(readers*reads), stats.FramesRead)
+	assert.Equal(t, stats.FramesRead, stats.CacheHits+stats.CacheMisses)
+	assert.GreaterOrEqual(t, stats.CacheMisses, uint64(2))
+}
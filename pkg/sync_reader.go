@@ -0,0 +1,79 @@
+package seekable
+
+import (
+	"io"
+	"sync"
+)
+
+// syncReader serializes Read, WriteTo and Seek calls of the underlying reader,
+// so that they can be called concurrently.
+type syncReader struct {
+	*readerImpl
+
+	mu sync.RWMutex
+}
+
+var _ Reader = (*syncReader)(nil)
+
+// NewSyncReader is like NewReader, but returned Reader's Read and Seek methods are goroutine-safe,
+// i.e. it can be shared by multiple goroutines without external synchronization.
+// Note that concurrent Read calls still share the offset, so each goroutine reads
+// some part of the stream that is not read by others.
+//
+// ReadAt does not depend on the offset and only waits for in-flight Seek and Read calls,
+// so it can be called concurrently under the same conditions as for NewReader.
+func NewSyncReader(rs io.ReadSeeker, decoder ZSTDDecoder, opts ...rOption) (Reader, error) {
+	r, err := NewReader(rs, decoder, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &syncReader{readerImpl: r.(*readerImpl)}, nil
+}
+
+func (s *syncReader) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readerImpl.Read(p)
+}
+
+func (s *syncReader) Seek(offset int64, whence int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readerImpl.Seek(offset, whence)
+}
+
+func (s *syncReader) SeekToFrame(id int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readerImpl.SeekToFrame(id)
+}
+
+func (s *syncReader) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readerImpl.WriteTo(w)
+}
+
+func (s *syncReader) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.readerImpl.ReadAt(p, off)
+}
+
+func (s *syncReader) BulkReadAt(ranges []ReadRange) ([][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.readerImpl.BulkReadAt(ranges)
+}
+
+func (s *syncReader) ReadAtMissing(p []byte, off int64, fill byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.readerImpl.ReadAtMissing(p, off, fill)
+}
+
+func (s *syncReader) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readerImpl.Close()
+}
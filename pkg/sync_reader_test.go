@@ -0,0 +1,127 @@
+package seekable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncReader(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = NewSyncReader(nil, dec, WithCacheSize(0))
+	require.ErrorContains(t, err, "cache size must be positive")
+
+	// Stream of sequential uint32 values, frames are aligned to values.
+	const (
+		frames         = 10
+		valuesPerFrame = 100
+	)
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	frame := make([]byte, 4*valuesPerFrame)
+	for i := 0; i < frames; i++ {
+		for j := 0; j < valuesPerFrame; j++ {
+			binary.BigEndian.PutUint32(frame[4*j:], uint32(i*valuesPerFrame+j))
+		}
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewSyncReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	// Concurrent reads must return every value exactly once.
+	var seen [frames * valuesPerFrame]atomic.Int32
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tmp := make([]byte, 4)
+			for {
+				n, err := r.Read(tmp)
+				if errors.Is(err, io.EOF) {
+					return
+				}
+				if !assert.NoError(t, err) || !assert.Equal(t, 4, n) {
+					return
+				}
+				v := binary.BigEndian.Uint32(tmp)
+				if !assert.Less(t, v, uint32(len(seen))) {
+					return
+				}
+				seen[v].Add(1)
+
+				// Offset never points into the middle of a value.
+				off, err := r.Seek(0, io.SeekCurrent)
+				if !assert.NoError(t, err) || !assert.Zero(t, off%4) {
+					return
+				}
+
+				// ReadAt is not affected by the offset.
+				_, err = r.ReadAt(tmp, 4*int64(v))
+				if !assert.NoError(t, err) || !assert.Equal(t, v, binary.BigEndian.Uint32(tmp)) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for v := range seen {
+		assert.Equal(t, int32(1), seen[v].Load(), "value %d", v)
+	}
+
+	// Rewinding concurrently with reads.
+	_, err = r.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	var rewinds atomic.Int32
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			tmp := make([]byte, 4)
+			for i := 0; i < 200; i++ {
+				if g == 0 && i%50 == 0 {
+					_, err := r.Seek(0, io.SeekStart)
+					assert.NoError(t, err)
+					rewinds.Add(1)
+					continue
+				}
+				n, err := r.Read(tmp)
+				if errors.Is(err, io.EOF) {
+					continue
+				}
+				if !assert.NoError(t, err) || !assert.Equal(t, 4, n) {
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(4), rewinds.Load())
+
+	off, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	assert.Zero(t, off%4)
+	assert.LessOrEqual(t, off, int64(4*frames*valuesPerFrame))
+}
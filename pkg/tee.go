@@ -0,0 +1,50 @@
+package seekable
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.uber.org/multierr"
+)
+
+// teeWriter writes uncompressed data to plain in addition to the seekable Writer.
+type teeWriter struct {
+	Writer
+	plain io.Writer
+}
+
+// NewTeeWriter returns the Writer that writes data passed to Write, WriteCtx and WriteFrom
+// to plain before writing it to w, e.g. to hash uncompressed data of the stream.
+// Write and WriteCtx write data to both of them even if one fails and return both errors.
+// WriteRaw is not supported, since the uncompressed data is not available.
+//
+// Close only closes w, the caller is responsible for closing plain.
+func NewTeeWriter(w Writer, plain io.Writer) Writer {
+	return &teeWriter{Writer: w, plain: plain}
+}
+
+func (t *teeWriter) Write(src []byte) (int, error) {
+	return t.WriteCtx(context.Background(), src)
+}
+
+func (t *teeWriter) WriteCtx(ctx context.Context, src []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var err error
+	if _, plainErr := t.plain.Write(src); plainErr != nil {
+		err = fmt.Errorf("failed to write to plain writer: %w", plainErr)
+	}
+	n, writeErr := t.Writer.WriteCtx(ctx, src)
+	return n, multierr.Append(err, writeErr)
+}
+
+func (t *teeWriter) WriteFrom(r io.Reader, chunkSize int) error {
+	return t.Writer.WriteFrom(io.TeeReader(r, t.plain), chunkSize)
+}
+
+func (t *teeWriter) WriteRaw([]byte, uint32, uint32) error {
+	return fmt.Errorf("raw frames can not be written to tee writer")
+}
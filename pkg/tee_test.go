@@ -0,0 +1,65 @@
+package seekable
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+)
+
+func TestTeeWriter(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b, plain bytes.Buffer
+	sw, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	hash := sha256.New()
+	w := NewTeeWriter(sw, io.MultiWriter(&plain, hash))
+
+	for _, s := range []string{"test", "test2"} {
+		n, err := w.Write([]byte(s))
+		require.NoError(t, err)
+		assert.Equal(t, len(s), n)
+	}
+	require.NoError(t, w.WriteFrom(strings.NewReader("abcdefg"), 3))
+	require.ErrorContains(t, w.WriteRaw(checksum[:17], 4, 0), "tee writer")
+	require.NoError(t, w.Close())
+
+	expected := "testtest2abcdefg"
+	assert.Equal(t, expected, plain.String())
+	assert.Equal(t, sha256.Sum256([]byte(expected)), [sha256.Size]byte(hash.Sum(nil)))
+
+	r, err := NewReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(all))
+	assert.Equal(t, int64(5), r.(Decoder).NumFrames())
+
+	// Errors of both writers are returned.
+	sw, err = NewWriter(failingWriter{}, enc)
+	require.NoError(t, err)
+	_, err = NewTeeWriter(sw, failingWriter{}).Write([]byte("test"))
+	require.ErrorContains(t, err, "failed to write to plain writer: failed")
+	assert.Len(t, multierr.Errors(err), 2)
+
+	// Data is written to plain even if the seekable writer fails.
+	plain.Reset()
+	_, err = NewTeeWriter(sw, &plain).Write([]byte("test"))
+	require.Error(t, err)
+	assert.Equal(t, "test", plain.String())
+}
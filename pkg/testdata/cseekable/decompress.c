@@ -0,0 +1,70 @@
+/*
+ * Decompresses a seekable ZSTD stream to stdout using the reference
+ * implementation from zstd's contrib/seekable_format.
+ *
+ * Usage: decompress FILE
+ */
+#include <stdio.h>
+#include <stdlib.h>
+
+#include "zstd.h"
+#include "zstd_seekable.h"
+
+/* Deliberately small and odd to make reads straddle frame boundaries. */
+#define BUF_SIZE 1000
+
+int main(int argc, char **argv) {
+    if (argc != 2) {
+        fprintf(stderr, "usage: %s FILE\n", argv[0]);
+        return 2;
+    }
+
+    FILE *f = fopen(argv[1], "rb");
+    if (f == NULL) {
+        perror("fopen");
+        return 1;
+    }
+
+    ZSTD_seekable *zs = ZSTD_seekable_create();
+    if (zs == NULL) {
+        fprintf(stderr, "ZSTD_seekable_create failed\n");
+        return 1;
+    }
+
+    size_t ret = ZSTD_seekable_initFile(zs, f);
+    if (ZSTD_isError(ret)) {
+        fprintf(stderr, "ZSTD_seekable_initFile: %s\n", ZSTD_getErrorName(ret));
+        return 1;
+    }
+
+    unsigned numFrames = ZSTD_seekable_getNumFrames(zs);
+    unsigned long long size = 0;
+    if (numFrames > 0) {
+        size = ZSTD_seekable_getFrameDecompressedOffset(zs, numFrames - 1) +
+               ZSTD_seekable_getFrameDecompressedSize(zs, numFrames - 1);
+    }
+
+    char buf[BUF_SIZE];
+    unsigned long long offset = 0;
+    while (offset < size) {
+        size_t n = size - offset < BUF_SIZE ? (size_t)(size - offset) : BUF_SIZE;
+        ret = ZSTD_seekable_decompress(zs, buf, n, offset);
+        if (ZSTD_isError(ret)) {
+            fprintf(stderr, "ZSTD_seekable_decompress: %s\n", ZSTD_getErrorName(ret));
+            return 1;
+        }
+        if (ret == 0) {
+            fprintf(stderr, "ZSTD_seekable_decompress: unexpected end of stream at %llu\n", offset);
+            return 1;
+        }
+        if (fwrite(buf, 1, ret, stdout) != ret) {
+            perror("fwrite");
+            return 1;
+        }
+        offset += ret;
+    }
+
+    ZSTD_seekable_free(zs);
+    fclose(f);
+    return 0;
+}
@@ -0,0 +1,31 @@
+package seekable
+
+import (
+	"context"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// FrameTracer traces frame-level operations, e.g. as spans of a distributed tracing system,
+// see WithRTracer and WithWTracer.  OpenTelemetry implementation is in the pkg/obs/otel module.
+//
+// Each Start method returns the function that is called exactly once when the operation is finished.
+// Methods are called synchronously, possibly from multiple goroutines, so implementations must be goroutine-safe.
+type FrameTracer interface {
+	// StartFrameRead is called when the reader accesses the frame, either in the frame cache or in the environment.
+	// cacheHit is set if the frame was served from the frame cache (see WithCacheSize).
+	StartFrameRead(ctx context.Context, index env.FrameOffsetEntry) (end func(cacheHit bool, err error))
+	// StartFrameWrite is called before the writer compresses the frame.
+	StartFrameWrite(ctx context.Context, frameID int64, decompressedBytes uint64) (end func(compressedBytes uint64, err error))
+}
+
+// nopTracer is the FrameTracer that traces nothing.
+type nopTracer struct{}
+
+func (nopTracer) StartFrameRead(context.Context, env.FrameOffsetEntry) func(bool, error) {
+	return func(bool, error) {}
+}
+
+func (nopTracer) StartFrameWrite(context.Context, int64, uint64) func(uint64, error) {
+	return func(uint64, error) {}
+}
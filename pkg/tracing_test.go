@@ -0,0 +1,111 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// recordingTracer records finished operations of FrameTracer as strings.
+type recordingTracer struct {
+	mu     sync.Mutex
+	reads  []string
+	writes []string
+}
+
+func (t *recordingTracer) StartFrameRead(_ context.Context, index env.FrameOffsetEntry) func(bool, error) {
+	return func(cacheHit bool, err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.reads = append(t.reads, fmt.Sprintf("%d/%d/%d/%t/%v", index.ID, index.CompSize, index.DecompSize, cacheHit, err))
+	}
+}
+
+func (t *recordingTracer) StartFrameWrite(_ context.Context, frameID int64, decompressedBytes uint64) func(uint64, error) {
+	return func(compressedBytes uint64, err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.writes = append(t.writes, fmt.Sprintf("%d/%d/%d/%v", frameID, compressedBytes, decompressedBytes, err))
+	}
+}
+
+func TestReaderTracer(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	tr := &recordingTracer{}
+	r, err := NewReader(&seekableBufferReaderAt{buf: checksum}, dec, WithRTracer(tr), WithCacheSize(2))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	tmp := make([]byte, len(sourceString))
+	_, err = r.ReadAt(tmp, 0)
+	require.NoError(t, err)
+	_, err = r.ReadAt(tmp[:2], 5)
+	require.NoError(t, err)
+	require.NoError(t, r.(Decoder).Prefetch(context.Background(), []int64{0, 1}, nil, dec))
+
+	assert.Equal(t, []string{
+		"0/17/4/false/<nil>",
+		"1/18/5/false/<nil>",
+		"1/18/5/true/<nil>",
+	}, tr.reads)
+	assert.Empty(t, tr.writes)
+}
+
+func TestWriterTracer(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	tr := &recordingTracer{}
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc, WithWTracer(tr))
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test2"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, checksum, b.Bytes())
+	assert.Equal(t, []string{"0/17/4/<nil>", "1/18/5/<nil>"}, tr.writes)
+
+	// Frame ids of concurrently compressed frames follow their order in the stream.
+	tr = &recordingTracer{}
+	e, err := NewEncoder(enc, WithWTracer(tr))
+	require.NoError(t, err)
+	_, err = e.Encode([]byte("test"))
+	require.NoError(t, err)
+	_, err = e.EncodeBatch(context.Background(), [][]byte{[]byte("test2"), []byte("test")}, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"0/17/4/<nil>", "1/18/5/<nil>", "2/17/4/<nil>"}, tr.writes)
+
+	tr = &recordingTracer{}
+	b.Reset()
+	w, err = NewWriter(&b, enc, WithWTracer(tr))
+	require.NoError(t, err)
+	frames := [][]byte{[]byte("test"), []byte("test2"), []byte("test")}
+	require.NoError(t, w.WriteMany(context.Background(), func() ([]byte, error) {
+		if len(frames) == 0 {
+			return nil, nil
+		}
+		frame := frames[0]
+		frames = frames[1:]
+		return frame, nil
+	}))
+	require.NoError(t, w.Close())
+	assert.ElementsMatch(t, []string{"0/17/4/<nil>", "1/18/5/<nil>", "2/17/4/<nil>"}, tr.writes)
+}
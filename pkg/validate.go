@@ -0,0 +1,418 @@
+package seekable
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cespare/xxhash/v2"
+	"go.uber.org/multierr"
+
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+)
+
+// ValidationErrorKind classifies problems found by Validate.
+type ValidationErrorKind int
+
+const (
+	// ValidationStructural means that the seek table or frames can not be parsed,
+	// or that their sizes do not match.
+	ValidationStructural ValidationErrorKind = iota
+	// ValidationOffset means that the seek table entries do not match the boundaries of frames
+	// in the stream, so the frame and, in turn, offsets of the following frames are wrong.
+	ValidationOffset
+	// ValidationChecksum means that the checksum of the decompressed frame does not match the seek table.
+	ValidationChecksum
+)
+
+func (k ValidationErrorKind) String() string {
+	switch k {
+	case ValidationStructural:
+		return "structural"
+	case ValidationOffset:
+		return "offset"
+	case ValidationChecksum:
+		return "checksum"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// ValidationError is a single problem found by Validate.
+type ValidationError struct {
+	Kind ValidationErrorKind
+	// FrameID is the ID of the affected frame, -1 if the problem is not specific to a frame.
+	FrameID int64
+	Err     error
+}
+
+func (e ValidationError) Error() string {
+	if e.FrameID < 0 {
+		return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%s: frame %d: %v", e.Kind, e.FrameID, e.Err)
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks that the seekable stream is internally consistent: the seek table can be parsed,
+// frames together with the seek table cover the whole stream, and each frame ends where the next
+// frame found by parsing frame headers starts.
+// If decoder is not nil, every frame is also decompressed and its size and checksum are verified.
+//
+// Problems with the stream are returned as ValidationError slice, while error is only returned
+// when validation itself fails, e.g. on I/O errors.  Streams with hierarchical index are not supported.
+func Validate(rs io.ReadSeeker, decoder ZSTDDecoder) ([]ValidationError, error) {
+	r, err := NewReader(rs, decoder, WithDefaultDecoder())
+	if err != nil {
+		return []ValidationError{{Kind: ValidationStructural, FrameID: -1, Err: err}}, nil
+	}
+	sr := r.(*readerImpl)
+	defer sr.Close()
+
+	if sr.hierarchical {
+		return nil, fmt.Errorf("hierarchical index is not supported")
+	}
+
+	var problems []ValidationError
+	report := func(kind ValidationErrorKind, id int64, format string, args ...any) {
+		problems = append(problems, ValidationError{Kind: kind, FrameID: id, Err: fmt.Errorf(format, args...)})
+	}
+
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream size: %w", err)
+	}
+
+	var start, compSize uint64
+	if first, ok := sr.index.Min(); ok {
+		start = first.CompOffset
+	}
+	sr.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		compSize += uint64(index.CompSize)
+
+		if index.CompSize == 0 && index.DecompSize != 0 {
+			report(ValidationStructural, index.ID, "compressed size is 0 for decompressed size %d", index.DecompSize)
+		}
+		return true
+	})
+
+	// Offsets in the index are sums of the sizes in the seek table, so they are checked
+	// against the frames actually found in the stream.
+	boundaries, parsedEnd, err := frameBoundaries(rs, start, compSize)
+	if err != nil {
+		return nil, err
+	}
+	// Frames that do not start or end at the frame boundary are not decompressed.
+	misaligned := make(map[int64]bool)
+	sr.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		end := index.CompOffset + uint64(index.CompSize)
+		if end > parsedEnd {
+			return false
+		}
+		if !boundaries[index.CompOffset] {
+			misaligned[index.ID] = true
+		}
+		if !boundaries[end] {
+			misaligned[index.ID] = true
+			report(ValidationOffset, index.ID, "frame ends at %d, which is not a frame boundary", end)
+		}
+		return true
+	})
+
+	if expected := int64(compSize) + sr.seekTableSize; expected != size {
+		report(ValidationStructural, -1, "compressed size mismatch: expected: %d (frames: %d, seek table: %d), actual: %d",
+			expected, compSize, sr.seekTableSize, size)
+	}
+
+	if decoder == nil {
+		return problems, nil
+	}
+
+	sr.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		// Frames without data, e.g. skippable ones, are never read.
+		if index.DecompSize == 0 || misaligned[index.ID] {
+			return true
+		}
+
+		src, err := sr.env.GetFrameByIndex(*index)
+		if err != nil {
+			report(ValidationStructural, index.ID, "failed to read frame: %w", err)
+			return true
+		}
+		if len(src) != int(index.CompSize) {
+			report(ValidationStructural, index.ID, "compressed size mismatch: expected: %d, actual: %d",
+				index.CompSize, len(src))
+			return true
+		}
+		if src, err = sr.resolveReference(sr.env, index, src); err != nil {
+			report(ValidationStructural, index.ID, "%w", err)
+			return true
+		}
+		if err = checkFrameMagic(index, src); err != nil {
+			report(ValidationStructural, index.ID, "%w", err)
+			return true
+		}
+
+		decompressed, err := decoder.DecodeAll(src, nil)
+		if err != nil {
+			report(ValidationStructural, index.ID, "failed to decompress frame: %w", err)
+			return true
+		}
+		if len(decompressed) != int(index.DecompSize) {
+			report(ValidationStructural, index.ID, "decompressed size mismatch: expected: %d, actual: %d",
+				index.DecompSize, len(decompressed))
+			return true
+		}
+		if sr.checksums {
+			if checksum := uint32((xxhash.Sum64(decompressed) << 32) >> 32); checksum != index.Checksum {
+				report(ValidationChecksum, index.ID, "checksum mismatch: expected: %d, actual: %d",
+					index.Checksum, checksum)
+			}
+		}
+		return true
+	})
+
+	return problems, nil
+}
+
+// frameBoundaries parses frame headers in the [start, start+size) range of rs and returns offsets
+// at which frames start, along with the end of the last parsed frame.
+// Parsing stops at the first frame that can not be parsed, e.g. a corrupted one.
+func frameBoundaries(rs io.ReadSeeker, start, size uint64) (map[uint64]bool, uint64, error) {
+	if _, err := rs.Seek(int64(start), io.SeekStart); err != nil {
+		return nil, 0, fmt.Errorf("failed to seek to the first frame: %w", err)
+	}
+	br := bufio.NewReader(io.LimitReader(rs, int64(size)))
+
+	off := start
+	boundaries := map[uint64]bool{off: true}
+	for {
+		f, err := parseFrame(br)
+		if err != nil {
+			return boundaries, off, nil
+		}
+		off += uint64(f.entry.CompressedSize)
+		boundaries[off] = true
+	}
+}
+
+// FullValidate is like Validate with a decoder, i.e. every frame is fetched, decompressed
+// and its sizes and checksum are verified, but problems are returned as a single error
+// combined with multierr.  Use multierr.Errors to get the individual ValidationError values.
+func FullValidate(rs io.ReadSeeker, decoder ZSTDDecoder) error {
+	if decoder == nil {
+		return fmt.Errorf("decoder is not set")
+	}
+
+	problems, err := Validate(rs, decoder)
+	if err != nil {
+		return err
+	}
+	errs := make([]error, 0, len(problems))
+	for _, p := range problems {
+		errs = append(errs, p)
+	}
+	return multierr.Combine(errs...)
+}
+
+// Repair rebuilds the seek table of the stream by parsing ZSTD frames from its beginning
+// and writes it at the end of the last complete frame, dropping the old seek table (if any)
+// and the incomplete trailing frame, e.g. left by an interrupted writer.
+//
+// Frame sizes are taken from ZSTD frame headers, so every frame must have `Frame_Content_Size`.
+// Checksums are taken from ZSTD content checksums if all frames have them,
+// otherwise the seek table is written without checksums.  Skippable frames in the middle of the stream
+// are kept as frames with no decompressed data.
+//
+// rws is truncated before writing if it has a Truncate method, e.g. *os.File.
+func Repair(rws io.ReadWriteSeeker) error {
+	if _, err := rws.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to the start: %w", err)
+	}
+	br := bufio.NewReader(rws)
+
+	var entries []seekTableEntry
+	checksums := true
+	// dataEnd and dataEntries are the offset and the number of entries after the last ZSTD frame,
+	// skippable frames following it are considered to be a part of the old seek table.
+	var off, dataEnd int64
+	var dataEntries int
+	for {
+		f, err := parseFrame(br)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse frame at offset %d: %w", off, err)
+		}
+		if !f.skippable && !f.contentSize {
+			return fmt.Errorf("failed to parse frame at offset %d: frame has no content size", off)
+		}
+
+		entries = append(entries, f.entry)
+		off += int64(f.entry.CompressedSize)
+		if !f.skippable {
+			checksums = checksums && f.checksum
+			dataEnd, dataEntries = off, len(entries)
+		}
+	}
+	entries = entries[:dataEntries]
+	if !checksums {
+		for i := range entries {
+			entries[i].Checksum = 0
+		}
+	}
+
+	seekTable, err := marshalSeekTable(entries, checksums)
+	if err != nil {
+		return err
+	}
+
+	if t, ok := rws.(interface{ Truncate(size int64) error }); ok {
+		if err = t.Truncate(dataEnd); err != nil {
+			return fmt.Errorf("failed to truncate stream: %w", err)
+		}
+	}
+	if _, err = rws.Seek(dataEnd, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to the end of frames: %w", err)
+	}
+	if _, err = rws.Write(seekTable); err != nil {
+		return fmt.Errorf("failed to write seek table: %w", err)
+	}
+	return nil
+}
+
+// parsedFrame is a frame found by parseFrame.
+type parsedFrame struct {
+	entry     seekTableEntry
+	skippable bool
+	// checksum is set if the ZSTD frame has the content checksum.
+	checksum bool
+	// contentSize is set if the ZSTD frame has `Frame_Content_Size`,
+	// otherwise DecompressedSize of the entry is 0.
+	contentSize bool
+}
+
+// parseFrame reads a single ZSTD or skippable frame from r without decompressing it.
+// io.EOF is returned if there are no more frames, io.ErrUnexpectedEOF if the frame is truncated.
+func parseFrame(r *bufio.Reader) (f parsedFrame, err error) {
+	var buf [8]byte
+	if _, err = io.ReadFull(r, buf[:4]); err != nil {
+		return f, err
+	}
+	size := int64(4)
+
+	read := func(n int) ([]byte, error) {
+		size += int64(n)
+		_, err := io.ReadFull(r, buf[:n])
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return buf[:n], err
+	}
+	skip := func(n int64) error {
+		size += n
+		_, err := r.Discard(int(n))
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	magic := binary.LittleEndian.Uint32(buf[:4])
+	if magic&0xFFFFFFF0 == skippableFrameMagic {
+		p, err := read(frameSizeFieldSize)
+		if err != nil {
+			return f, err
+		}
+		if err = skip(int64(binary.LittleEndian.Uint32(p))); err != nil {
+			return f, err
+		}
+		f.skippable = true
+		f.entry.CompressedSize = uint32(size)
+		return f, nil
+	}
+	if magic != zstdFrameMagic {
+		return f, fmt.Errorf("unexpected magic: 0x%08X", magic)
+	}
+
+	p, err := read(1)
+	if err != nil {
+		return f, err
+	}
+	descriptor := p[0]
+	fcsFlag := descriptor >> 6
+	singleSegment := descriptor&(1<<5) != 0
+	f.checksum = descriptor&(1<<2) != 0
+
+	if !singleSegment {
+		// Window_Descriptor
+		if err = skip(1); err != nil {
+			return f, err
+		}
+	}
+	// Dictionary_ID
+	if err = skip([]int64{0, 1, 2, 4}[descriptor&3]); err != nil {
+		return f, err
+	}
+
+	fcsSize := []int{0, 2, 4, 8}[fcsFlag]
+	if fcsFlag == 0 && singleSegment {
+		fcsSize = 1
+	}
+	f.contentSize = fcsSize != 0
+	if p, err = read(fcsSize); err != nil {
+		return f, err
+	}
+	var contentSize uint64
+	switch fcsSize {
+	case 1:
+		contentSize = uint64(p[0])
+	case 2:
+		contentSize = uint64(binary.LittleEndian.Uint16(p)) + 256
+	case 4:
+		contentSize = uint64(binary.LittleEndian.Uint32(p))
+	case 8:
+		contentSize = binary.LittleEndian.Uint64(p)
+	}
+	if contentSize > uint64(maxChunkSize) {
+		return f, fmt.Errorf("content size is too big: %d", contentSize)
+	}
+
+	for last := false; !last; {
+		if p, err = read(3); err != nil {
+			return f, err
+		}
+		header := uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16
+		last = header&1 != 0
+		blockSize := int64(header >> 3)
+		switch blockType := (header >> 1) & 3; blockType {
+		case 1:
+			// RLE_Block
+			blockSize = 1
+		case 3:
+			return f, fmt.Errorf("reserved block type")
+		}
+		if err = skip(blockSize); err != nil {
+			return f, err
+		}
+	}
+
+	if f.checksum {
+		if p, err = read(4); err != nil {
+			return f, err
+		}
+		f.entry.Checksum = binary.LittleEndian.Uint32(p)
+	}
+	if size > maxChunkSize {
+		return f, fmt.Errorf("frame is too big: %d", size)
+	}
+	f.entry.CompressedSize = uint32(size)
+	f.entry.DecompressedSize = uint32(contentSize)
+	return f, nil
+}
@@ -0,0 +1,218 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	for _, b := range [][]byte{checksum, noChecksum} {
+		for _, d := range []ZSTDDecoder{nil, dec} {
+			problems, err := Validate(bytes.NewReader(b), d)
+			require.NoError(t, err)
+			assert.Empty(t, problems)
+		}
+	}
+
+	kinds := func(problems []ValidationError) (k []ValidationErrorKind) {
+		for _, p := range problems {
+			k = append(k, p.Kind)
+		}
+		return
+	}
+
+	// Checksum of the first frame.
+	corrupted := bytes.Clone(checksum)
+	corrupted[17+18+8+8] ^= 0xff
+	problems, err := Validate(bytes.NewReader(corrupted), nil)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+	problems, err = Validate(bytes.NewReader(corrupted), dec)
+	require.NoError(t, err)
+	require.Equal(t, []ValidationErrorKind{ValidationChecksum}, kinds(problems))
+	assert.Equal(t, int64(0), problems[0].FrameID)
+	assert.ErrorContains(t, problems[0], "checksum: frame 0: checksum mismatch")
+
+	// Decompressed size of the second frame.
+	corrupted = bytes.Clone(checksum)
+	corrupted[17+18+8+12+4]++
+	problems, err = Validate(bytes.NewReader(corrupted), dec)
+	require.NoError(t, err)
+	require.Equal(t, []ValidationErrorKind{ValidationStructural}, kinds(problems))
+	assert.Equal(t, int64(1), problems[0].FrameID)
+	assert.ErrorContains(t, problems[0], "decompressed size mismatch")
+
+	// Compressed size of the first frame is moved to the second one.
+	corrupted = bytes.Clone(checksum)
+	corrupted[17+18+8]++
+	corrupted[17+18+8+12]--
+	for _, d := range []ZSTDDecoder{nil, dec} {
+		problems, err = Validate(bytes.NewReader(corrupted), d)
+		require.NoError(t, err)
+		require.Equal(t, []ValidationErrorKind{ValidationOffset}, kinds(problems))
+		assert.Equal(t, int64(0), problems[0].FrameID)
+		assert.ErrorContains(t, problems[0], "offset: frame 0: frame ends at 18, which is not a frame boundary")
+	}
+
+	// Extra data in front of the stream.
+	problems, err = Validate(bytes.NewReader(append([]byte{0}, checksum...)), dec)
+	require.NoError(t, err)
+	require.Equal(t, []ValidationErrorKind{ValidationStructural, ValidationStructural, ValidationStructural}, kinds(problems))
+	assert.Equal(t, int64(-1), problems[0].FrameID)
+	assert.ErrorContains(t, problems[0], "compressed size mismatch")
+	assert.ErrorContains(t, problems[1], "expected ZSTD magic")
+
+	// Not a seekable stream.
+	problems, err = Validate(bytes.NewReader([]byte("not a seekable stream")), dec)
+	require.NoError(t, err)
+	require.Equal(t, []ValidationErrorKind{ValidationStructural}, kinds(problems))
+	assert.Equal(t, int64(-1), problems[0].FrameID)
+	assert.Equal(t, "structural", problems[0].Kind.String())
+}
+
+func TestFullValidate(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = w.Write(makeTestFrame(t, i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, FullValidate(bytes.NewReader(b.Bytes()), dec))
+
+	r, err := NewReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	index := r.(Decoder).GetIndexByID(2)
+	require.NoError(t, r.Close())
+
+	// Flip a byte in the middle of the compressed data of frame 2.
+	corrupted := bytes.Clone(b.Bytes())
+	corrupted[index.CompOffset+uint64(index.CompSize)/2] ^= 0xff
+	err = FullValidate(bytes.NewReader(corrupted), dec)
+	require.Error(t, err)
+	errs := multierr.Errors(err)
+	require.Len(t, errs, 1)
+	var problem ValidationError
+	require.ErrorAs(t, errs[0], &problem)
+	assert.Equal(t, int64(2), problem.FrameID)
+
+	err = FullValidate(bytes.NewReader([]byte("not a seekable stream")), dec)
+	require.ErrorAs(t, err, &problem)
+	assert.Equal(t, ValidationStructural, problem.Kind)
+
+	require.ErrorContains(t, FullValidate(bytes.NewReader(b.Bytes()), nil), "decoder is not set")
+}
+
+func TestRepair(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var expected []byte
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		frame := makeTestFrame(t, i)
+		expected = append(expected, frame...)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	d, err := NewDecoder(b.Bytes()[lastFrameEnd(t, b.Bytes()):], nil)
+	require.NoError(t, err)
+	frame2 := *d.GetIndexByID(2)
+	require.NoError(t, d.Close())
+
+	repair := func(data []byte) []byte {
+		fn := filepath.Join(t.TempDir(), "test.zst")
+		require.NoError(t, os.WriteFile(fn, data, 0o600))
+		f, err := os.OpenFile(fn, os.O_RDWR, 0)
+		require.NoError(t, err)
+		defer f.Close()
+
+		require.NoError(t, Repair(f))
+		repaired, err := os.ReadFile(fn)
+		require.NoError(t, err)
+
+		problems, err := Validate(bytes.NewReader(repaired), dec)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+		return repaired
+	}
+	readAll := func(data []byte) []byte {
+		r, err := NewReader(bytes.NewReader(data), dec)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, r.Close()) }()
+		all, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return all
+	}
+
+	// Intact stream is not changed.
+	assert.Equal(t, b.Bytes(), repair(b.Bytes()))
+
+	// Missing seek table.
+	frames := b.Bytes()[:frame2.CompOffset+uint64(frame2.CompSize)]
+	assert.Equal(t, b.Bytes(), repair(frames))
+
+	// Truncated last frame and seek table.
+	repaired := repair(b.Bytes()[:frame2.CompOffset+uint64(frame2.CompSize)/2])
+	assert.Equal(t, expected[:frame2.DecompOffset], readAll(repaired))
+
+	// Skippable frames in the middle are kept.
+	var hb bytes.Buffer
+	w, err = NewWriter(&hb, enc, WithHierarchicalIndex(2))
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = w.Write(makeTestFrame(t, i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	assert.Equal(t, expected, readAll(repair(hb.Bytes())))
+
+	// Errors.
+	fn := filepath.Join(t.TempDir(), "test.zst")
+	require.NoError(t, os.WriteFile(fn, append([]byte("garbage"), b.Bytes()...), 0o600))
+	f, err := os.OpenFile(fn, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer f.Close()
+	require.ErrorContains(t, Repair(f), "failed to parse frame at offset 0: unexpected magic")
+}
+
+// lastFrameEnd returns the offset of the seek table of the stream.
+func lastFrameEnd(t *testing.T, stream []byte) int64 {
+	r, err := NewReader(bytes.NewReader(stream), nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	return int64(len(stream)) - r.(*readerImpl).seekTableSize
+}
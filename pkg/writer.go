@@ -2,13 +2,16 @@ package seekable
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"runtime"
 	"sync"
 
+	"github.com/klauspost/compress/zstd"
 	"golang.org/x/sync/errgroup"
 
+	"go.uber.org/atomic"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 
@@ -32,8 +35,60 @@ type writerImpl struct {
 	enc          ZSTDEncoder
 	frameEntries []seekTableEntry
 
-	logger *zap.Logger
-	env    env.WEnvironment
+	// chunkEntries is the maximum number of entries per seek table frame, 0 means no chunking.
+	chunkEntries int
+
+	// compressSeekTable compresses seek table entries with enc.
+	compressSeekTable bool
+	// varintSeekTable delta and varint encodes seek table entries.
+	varintSeekTable bool
+
+	// spanFrames is the number of frames per span of the hierarchical index, 0 means no hierarchical index.
+	// In that case frameEntries only holds the frames of the current span.
+	spanFrames     int
+	spanEntries    []seekTableEntry
+	spanFirstID    uint64
+	spanCompSize   uint64
+	spanDecompSize uint64
+
+	// pipeMode keeps the seek table in memory instead of writing it on Close.
+	pipeMode  bool
+	seekTable []byte
+
+	// metadata is written in skippable frames before the seek table.
+	metadata []frameMetadata
+
+	// hmacKey signs the seek table with the HMAC frame written before it.
+	hmacKey []byte
+
+	// dict requests creation of ownEnc with the dictionary.
+	dict []byte
+	// ownEnc is the encoder created by the writer itself and released on Close.
+	// Close only finishes its streaming state, so EncodeAll can still be used after ResetTo.
+	ownEnc *zstd.Encoder
+	// encryptionKey requests creation of cipher, see WithWEncryption.
+	encryptionKey []byte
+	// cipher encrypts frames and the seek table.
+	cipher *frameCipher
+
+	// magicPrefix is written before the first frame.
+	magicPrefix        []byte
+	magicPrefixWritten bool
+
+	// dedup maps the content of written frames to their location, see WithDeduplication.
+	// compOffset is the offset of the next frame in the stream.
+	dedup      map[dedupKey]dedupRef
+	compOffset uint64
+
+	// numFrames and bytesWritten count frames recorded in the seek table and their decompressed size,
+	// they are atomic, so that NumFrames and TotalBytesWritten can be called during WriteMany.
+	numFrames    atomic.Int64
+	bytesWritten atomic.Uint64
+
+	logger  *zap.Logger
+	env     env.WEnvironment
+	metrics MetricsObserver
+	tracer  FrameTracer
 
 	once *sync.Once
 }
@@ -50,11 +105,68 @@ type Writer interface {
 	// so each write will map to a separate ZSTD Frame.
 	Write(src []byte) (int, error)
 
+	// WriteCtx is like Write, but returns ctx.Err() without writing anything if ctx is done.
+	WriteCtx(ctx context.Context, src []byte) (int, error)
+
+	// WriteFrom reads r until EOF and writes its data as frames of chunkSize bytes each,
+	// except for the last one, which may be shorter.
+	WriteFrom(r io.Reader, chunkSize int) error
+
+	// WriteRaw writes an already compressed frame as is, e.g. one copied from another seekable stream.
+	// decompSize and checksum (lower 32 bits of the XXH64 of the decompressed data) are recorded
+	// in the seek table without decompressing the frame, so the caller is responsible for their correctness.
+	WriteRaw(compressedFrame []byte, decompSize uint32, checksum uint32) error
+
 	// Close implement io.Closer interface.  It writes the seek table footer
 	// and releases occupied memory.
 	//
 	// Caller is still responsible to Close the underlying writer.
 	Close() (err error)
+
+	// Checkpoint writes the seek table for the frames written so far to w as a valid
+	// seek table skippable frame.  Neither the main output stream nor the in-memory
+	// seek table are affected, Close still writes the complete seek table.
+	//
+	// Appending the checkpoint to the compressed data written before it yields
+	// a valid seekable stream, which is useful for recovering interrupted jobs.
+	//
+	// Checkpoint must not be called concurrently with Write or WriteMany.
+	Checkpoint(w io.Writer) (int64, error)
+
+	// EndStreamTo writes the seek table to w instead of the underlying writer, e.g. to keep
+	// the index in a separate file.  Seek table has the same format as the one returned by EndStream.
+	// Subsequent Close does not write the seek table again.
+	//
+	// Not supported with WithHierarchicalIndex, since its last fine index belongs to the frames.
+	EndStreamTo(w io.Writer) (int64, error)
+
+	// ResetTo discards the state of the stream, so that the Writer can be reused for a new stream
+	// written to w without reallocating it.  Options passed to NewWriter are kept, except for
+	// the environment, which is replaced by the one writing to w.
+	//
+	// ResetTo must not be called concurrently with other methods.
+	ResetTo(w io.Writer)
+
+	// SeekTable returns the seek table kept in memory by Close in pipe mode (see WithPipeMode).
+	// Returns nil until the writer is closed or if pipe mode is not enabled.
+	// Use SnapshotSeekTable to get the seek table of a writer that is still open.
+	SeekTable() []byte
+
+	// SnapshotSeekTable returns the seek table for the frames written so far, e.g. to store it
+	// separately while the stream is still being written.  Returns the empty seek table if
+	// nothing was written yet.  The writer is not closed and nothing is written to its environment.
+	//
+	// SnapshotSeekTable must not be called concurrently with Write or WriteMany.
+	SnapshotSeekTable() ([]byte, error)
+
+	// NumFrames returns the number of frames written so far, excluding skippable frames
+	// of metadata and HMAC written on Close.  Writers created with NewAppendWriter count
+	// all frames of the existing seek table.  It is safe to call concurrently with WriteMany.
+	NumFrames() int64
+
+	// TotalBytesWritten returns the total decompressed size of frames written so far.
+	// It is safe to call concurrently with WriteMany.
+	TotalBytesWritten() uint64
 }
 
 // FrameSource returns one frame of data at a time.
@@ -67,6 +179,13 @@ type ConcurrentWriter interface {
 
 	// WriteMany writes many frames concurrently
 	WriteMany(ctx context.Context, frameSource FrameSource, options ...WriteManyOption) error
+
+	// WriteManyChunked concurrently writes frames read from r by the chunker,
+	// e.g. a content-defined or a fixed-size one.  Iteration stops when the chunker returns nil.
+	//
+	// Chunks are compressed concurrently, so the chunker must not reuse memory of returned chunks.
+	WriteManyChunked(ctx context.Context, r io.Reader, chunker func(r io.Reader) ([]byte, error),
+		options ...WriteManyOption) error
 }
 
 // ZSTDEncoder is the compressor.  Tested with github.com/klauspost/compress/zstd.
@@ -83,6 +202,8 @@ func NewWriter(w io.Writer, encoder ZSTDEncoder, opts ...wOption) (ConcurrentWri
 	}
 
 	sw.logger = zap.NewNop()
+	sw.metrics = nopMetrics{}
+	sw.tracer = nopTracer{}
 	for _, o := range opts {
 		err := o(&sw)
 		if err != nil {
@@ -90,6 +211,54 @@ func NewWriter(w io.Writer, encoder ZSTDEncoder, opts ...wOption) (ConcurrentWri
 		}
 	}
 
+	if sw.dict != nil && encoder != nil {
+		return nil, fmt.Errorf("dictionary can not be used with the passed encoder, use zstd.WithEncoderDict instead")
+	}
+	if sw.chunkEntries > 0 && sw.spanFrames > 0 {
+		return nil, fmt.Errorf("chunked seek table and hierarchical index are mutually exclusive")
+	}
+	if sw.compressSeekTable && (sw.chunkEntries > 0 || sw.spanFrames > 0) {
+		return nil, fmt.Errorf("compressed seek table can not be chunked or hierarchical")
+	}
+	if sw.varintSeekTable && (sw.chunkEntries > 0 || sw.spanFrames > 0 || sw.compressSeekTable) {
+		return nil, fmt.Errorf("varint seek table can not be chunked, hierarchical or compressed")
+	}
+	if len(sw.metadata) > 0 && sw.spanFrames > 0 {
+		return nil, fmt.Errorf("frame metadata can not be used with hierarchical index")
+	}
+	if sw.pipeMode && sw.spanFrames > 0 {
+		return nil, fmt.Errorf("pipe mode can not be used with hierarchical index")
+	}
+	if sw.hmacKey != nil && (sw.spanFrames > 0 || sw.pipeMode) {
+		return nil, fmt.Errorf("HMAC can not be used with hierarchical index or pipe mode")
+	}
+	if sw.dedup != nil && (sw.spanFrames > 0 || sw.encryptionKey != nil) {
+		return nil, fmt.Errorf("deduplication can not be used with hierarchical index or encryption")
+	}
+	if sw.encryptionKey != nil {
+		if sw.spanFrames > 0 || sw.chunkEntries > 0 || sw.compressSeekTable || sw.varintSeekTable {
+			return nil, fmt.Errorf("encrypted seek table can not be chunked, hierarchical, compressed or varint")
+		}
+		if len(sw.metadata) > 0 || sw.hmacKey != nil {
+			return nil, fmt.Errorf("encryption can not be used with frame metadata or HMAC")
+		}
+		var err error
+		if sw.cipher, err = newFrameCipher(sw.encryptionKey, newEncryptionSalt()); err != nil {
+			return nil, err
+		}
+	}
+
+	if sw.dict != nil {
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderDict(sw.dict))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dictionary encoder: %w", err)
+		}
+		sw.enc = enc
+		sw.ownEnc = enc
+	}
+
+	sw.compOffset = uint64(len(sw.magicPrefix))
+
 	if sw.env == nil {
 		sw.env = &writerEnvImpl{
 			w: w,
@@ -99,12 +268,91 @@ func NewWriter(w io.Writer, encoder ZSTDEncoder, opts ...wOption) (ConcurrentWri
 	return &sw, nil
 }
 
+// NewAppendWriter opens an existing seekable stream for appending: the seek table is parsed
+// and then overwritten by subsequently written frames, Close writes the seek table
+// for both old and new frames.  Chunked, compressed and varint seek tables are supported.
+//
+// rw is truncated before writing if it has a Truncate method, e.g. *os.File.  Otherwise the
+// new seek table must not be shorter than the old one, which is the case if they have the same format.
+// Streams with hierarchical index or without checksums can not be appended to, nor can WithWEncryption be used.
+func NewAppendWriter(rw io.ReadWriteSeeker, encoder ZSTDEncoder, opts ...wOption) (ConcurrentWriter, error) {
+	r, err := NewReader(rw, nil, WithDefaultDecoder())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse existing stream: %w", err)
+	}
+	existing := r.(*readerImpl)
+	defer existing.Close()
+
+	if existing.hierarchical {
+		return nil, fmt.Errorf("appending to streams with hierarchical index is not supported")
+	}
+	if !existing.checksums && existing.numFrames > 0 {
+		return nil, fmt.Errorf("appending to streams without checksums is not supported")
+	}
+
+	sw, err := NewWriter(rw, encoder, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s := sw.(*writerImpl)
+	if s.spanFrames > 0 {
+		return nil, fmt.Errorf("append writer can not be used with hierarchical index")
+	}
+	// Existing frames would stay in plaintext, while the seek table is encrypted with a new salt.
+	if s.cipher != nil {
+		return nil, fmt.Errorf("append writer can not be used with encryption")
+	}
+
+	s.frameEntries = make([]seekTableEntry, 0, existing.numFrames)
+	existing.index.Ascend(func(index *env.FrameOffsetEntry) bool {
+		s.frameEntries = append(s.frameEntries, seekTableEntry{
+			CompressedSize:   index.CompSize,
+			DecompressedSize: index.DecompSize,
+			Checksum:         index.Checksum,
+		})
+		return true
+	})
+	s.numFrames.Store(existing.numFrames)
+	s.bytesWritten.Store(uint64(existing.endOffset))
+	// Prefix, if any, is already a part of the stream.
+	s.magicPrefixWritten = true
+
+	size, err := rw.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream size: %w", err)
+	}
+	seekTableOffset := size - existing.seekTableSize
+	s.compOffset = uint64(seekTableOffset)
+	if t, ok := rw.(interface{ Truncate(size int64) error }); ok {
+		if err = t.Truncate(seekTableOffset); err != nil {
+			return nil, fmt.Errorf("failed to truncate seek table: %w", err)
+		}
+	}
+	if _, err = rw.Seek(seekTableOffset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to the seek table: %w", err)
+	}
+
+	return s, nil
+}
+
 func (s *writerImpl) Write(src []byte) (int, error) {
-	dst, err := s.Encode(src)
+	return s.WriteCtx(context.Background(), src)
+}
+
+func (s *writerImpl) WriteCtx(ctx context.Context, src []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	dst, err := s.encode(ctx, src)
 	if err != nil {
 		return 0, err
 	}
 
+	if err = s.writeMagicPrefix(); err != nil {
+		return 0, err
+	}
+
 	n, err := s.env.WriteFrame(dst)
 	if err != nil {
 		return 0, err
@@ -116,27 +364,169 @@ func (s *writerImpl) Write(src []byte) (int, error) {
 	return len(src), nil
 }
 
+func (s *writerImpl) WriteFrom(r io.Reader, chunkSize int) error {
+	if chunkSize < 1 || int64(chunkSize) > maxChunkSize {
+		return fmt.Errorf("chunk size must be in [1, %d]: %d", maxChunkSize, chunkSize)
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if _, werr := s.Write(buf[:n]); werr != nil {
+				return fmt.Errorf("failed to write frame: %w", werr)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read chunk: %w", err)
+		}
+	}
+}
+
+func (s *writerImpl) WriteRaw(compressedFrame []byte, decompSize uint32, checksum uint32) error {
+	if s.cipher != nil {
+		compressedFrame = s.cipher.sealFrame(s.nextFrameID(), compressedFrame)
+	}
+	if int64(len(compressedFrame)) > maxChunkSize {
+		return fmt.Errorf("frame size too big for seekable format: %d > %d",
+			len(compressedFrame), maxChunkSize)
+	}
+	if len(compressedFrame) < skippableMagicNumberFieldSize {
+		return fmt.Errorf("frame is too small: %d", len(compressedFrame))
+	}
+
+	if err := s.writeMagicPrefix(); err != nil {
+		return err
+	}
+
+	compressedFrame, entry, err := s.dedupFrame(compressedFrame, seekTableEntry{
+		CompressedSize:   uint32(len(compressedFrame)),
+		DecompressedSize: decompSize,
+		Checksum:         checksum,
+	})
+	if err != nil {
+		return err
+	}
+	fine, err := s.appendEntry(entry)
+	if err != nil {
+		return err
+	}
+	for _, buf := range [][]byte{compressedFrame, fine} {
+		if len(buf) == 0 {
+			continue
+		}
+		n, err := s.env.WriteFrame(buf)
+		if err != nil {
+			return fmt.Errorf("failed to write compressed data: %w", err)
+		}
+		if n != len(buf) {
+			return fmt.Errorf("partial write: %d out of %d", n, len(buf))
+		}
+	}
+	return nil
+}
+
 func (s *writerImpl) Close() (err error) {
 	s.once.Do(func() {
 		err = multierr.Append(err, s.writeSeekTable())
+		if s.ownEnc != nil {
+			err = multierr.Append(err, s.ownEnc.Close())
+		}
 	})
 	return
 }
 
+func (s *writerImpl) SnapshotSeekTable() ([]byte, error) {
+	if s.spanFrames > 0 {
+		return nil, fmt.Errorf("seek table snapshot is not supported with hierarchical index")
+	}
+	return s.EndStream()
+}
+
+func (s *writerImpl) Checkpoint(w io.Writer) (int64, error) {
+	if s.spanFrames > 0 {
+		return 0, fmt.Errorf("checkpoint is not supported with hierarchical index")
+	}
+
+	seekTableBytes, err := s.SnapshotSeekTable()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(seekTableBytes)
+	if err != nil {
+		return int64(n), fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if n != len(seekTableBytes) {
+		return int64(n), fmt.Errorf("partial write: %d out of %d", n, len(seekTableBytes))
+	}
+	return int64(n), nil
+}
+
+func (s *writerImpl) EndStreamTo(w io.Writer) (n int64, err error) {
+	if s.spanFrames > 0 {
+		return 0, fmt.Errorf("writing seek table separately is not supported with hierarchical index")
+	}
+	if s.hmacKey != nil {
+		return 0, fmt.Errorf("writing seek table separately is not supported with HMAC")
+	}
+
+	ended := false
+	s.once.Do(func() {
+		ended = true
+
+		if err = s.writeMetadata(); err != nil {
+			return
+		}
+
+		var seekTableBytes []byte
+		seekTableBytes, err = s.EndStream()
+		if err != nil {
+			return
+		}
+		if err = s.writeMagicPrefix(); err != nil {
+			return
+		}
+
+		var m int
+		m, err = w.Write(seekTableBytes)
+		n = int64(m)
+		if err != nil {
+			err = fmt.Errorf("failed to write seek table: %w", err)
+			return
+		}
+		if m != len(seekTableBytes) {
+			err = fmt.Errorf("partial write: %d out of %d", m, len(seekTableBytes))
+		}
+	})
+	if !ended {
+		return 0, fmt.Errorf("seek table was already written")
+	}
+	return n, err
+}
+
 type encodeResult struct {
 	buf   []byte
 	entry seekTableEntry
 }
 
-func (s *writerImpl) writeManyEncoder(ctx context.Context, ch chan<- encodeResult, frame []byte) func() error {
+func (s *writerImpl) writeManyEncoder(ctx context.Context, ch chan<- encodeResult, id int64, frame []byte) func() error {
 	return func() error {
-		dst, entry, err := s.encodeOne(frame)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		dst, entry, err := s.encodeOne(ctx, id, frame)
 		if err != nil {
 			return fmt.Errorf("failed to encode frame: %w", err)
 		}
 
 		select {
 		case <-ctx.Done():
+			return ctx.Err()
 		// Fulfill our promise
 		case ch <- encodeResult{dst, entry}:
 			close(ch)
@@ -146,9 +536,19 @@ func (s *writerImpl) writeManyEncoder(ctx context.Context, ch chan<- encodeResul
 	}
 }
 
-func (s *writerImpl) writeManyProducer(ctx context.Context, frameSource FrameSource, g *errgroup.Group, queue chan<- chan encodeResult) func() error {
+func (s *writerImpl) writeManyProducer(ctx context.Context, frameSource FrameSource, g *errgroup.Group, queue chan<- chan encodeResult, stop func() bool) func() error {
+	// Frames are appended in the order they are produced.
+	id := s.nextFrameID()
 	return func() error {
-		for {
+		for ; ; id++ {
+			if stop != nil && stop() {
+				close(queue)
+				return nil
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
 			frame, err := frameSource()
 			if err != nil {
 				return fmt.Errorf("frame source failed: %w", err)
@@ -164,22 +564,24 @@ func (s *writerImpl) writeManyProducer(ctx context.Context, frameSource FrameSou
 			ch := make(chan encodeResult, 1)
 			select {
 			case <-ctx.Done():
-				return nil
+				return ctx.Err()
 			case queue <- ch:
 			}
 
-			g.Go(s.writeManyEncoder(ctx, ch, frame))
+			g.Go(s.writeManyEncoder(ctx, ch, id, frame))
 		}
 	}
 }
 
-func (s *writerImpl) writeManyConsumer(ctx context.Context, callback func(uint32), queue <-chan chan encodeResult) func() error {
+func (s *writerImpl) writeManyConsumer(ctx context.Context, callback func(decompSize, compSize uint32),
+	queue <-chan chan encodeResult,
+) func() error {
 	return func() error {
 		for {
 			var ch <-chan encodeResult
 			select {
 			case <-ctx.Done():
-				return nil
+				return ctx.Err()
 			case ch = <-queue:
 			}
 			if ch == nil {
@@ -190,21 +592,38 @@ func (s *writerImpl) writeManyConsumer(ctx context.Context, callback func(uint32
 			var result encodeResult
 			select {
 			case <-ctx.Done():
-				return nil
+				return ctx.Err()
 			case result = <-ch:
 			}
 
-			n, err := s.env.WriteFrame(result.buf)
+			if err := s.writeMagicPrefix(); err != nil {
+				return err
+			}
+
+			var err error
+			result.buf, result.entry, err = s.dedupFrame(result.buf, result.entry)
+			if err != nil {
+				return err
+			}
+			fine, err := s.appendEntry(result.entry)
 			if err != nil {
-				return fmt.Errorf("failed to write compressed data: %w", err)
+				return err
 			}
-			if n != len(result.buf) {
-				return fmt.Errorf("partial write: %d out of %d", n, len(result.buf))
+			for _, buf := range [][]byte{result.buf, fine} {
+				if len(buf) == 0 {
+					continue
+				}
+				n, err := s.env.WriteFrame(buf)
+				if err != nil {
+					return fmt.Errorf("failed to write compressed data: %w", err)
+				}
+				if n != len(buf) {
+					return fmt.Errorf("partial write: %d out of %d", n, len(buf))
+				}
 			}
-			s.frameEntries = append(s.frameEntries, result.entry)
 
 			if callback != nil {
-				callback(result.entry.DecompressedSize)
+				callback(result.entry.DecompressedSize, result.entry.CompressedSize)
 			}
 		}
 	}
@@ -218,21 +637,119 @@ func (s *writerImpl) WriteMany(ctx context.Context, frameSource FrameSource, opt
 		}
 	}
 
+	callback := opts.writeCallback
+	var stop func() bool
+	var written atomic.Int64
+	if opts.maxDecompressedBytes > 0 {
+		callback = func(decompSize, compSize uint32) {
+			written.Add(int64(decompSize))
+			if opts.writeCallback != nil {
+				opts.writeCallback(decompSize, compSize)
+			}
+		}
+		stop = func() bool { return written.Load() > opts.maxDecompressedBytes }
+	}
+
 	g, gCtx := errgroup.WithContext(ctx)
 	g.SetLimit(opts.concurrency + 2) // reader and writer
 	// Add extra room in the queue, so we can keep throughput high even if blocks finish out of order
 	queue := make(chan chan encodeResult, opts.concurrency*2)
-	g.Go(s.writeManyProducer(gCtx, frameSource, g, queue))
-	g.Go(s.writeManyConsumer(gCtx, opts.writeCallback, queue))
-	return g.Wait()
+	g.Go(s.writeManyProducer(gCtx, frameSource, g, queue, stop))
+	g.Go(s.writeManyConsumer(gCtx, callback, queue))
+	if err := g.Wait(); err != nil {
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return fmt.Errorf("write many canceled: %w", err)
+		}
+		return err
+	}
+
+	if stop != nil && stop() {
+		return &QuotaExceededError{Limit: opts.maxDecompressedBytes, Actual: written.Load()}
+	}
+	return nil
+}
+
+func (s *writerImpl) WriteManyChunked(ctx context.Context, r io.Reader, chunker func(r io.Reader) ([]byte, error),
+	options ...WriteManyOption,
+) error {
+	return s.WriteMany(ctx, func() ([]byte, error) { return chunker(r) }, options...)
+}
+
+// QuotaExceededError is returned by WriteMany when more data than allowed by
+// WithMaxDecompressedBytes was written.  Frames written so far are recorded
+// in the seek table, so the stream can still be closed.
+type QuotaExceededError struct {
+	Limit  int64
+	Actual int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("decompressed bytes quota exceeded: %d > %d", e.Actual, e.Limit)
+}
+
+// writeMagicPrefix writes the magic prefix (if any) unless it was already written.
+func (s *writerImpl) writeMagicPrefix() error {
+	if len(s.magicPrefix) == 0 || s.magicPrefixWritten {
+		return nil
+	}
+
+	n, err := s.env.WriteFrame(s.magicPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to write magic prefix: %w", err)
+	}
+	if n != len(s.magicPrefix) {
+		return fmt.Errorf("partial write: %d out of %d", n, len(s.magicPrefix))
+	}
+	s.magicPrefixWritten = true
+	return nil
 }
 
 func (s *writerImpl) writeSeekTable() error {
-	seekTableBytes, err := s.EndStream()
+	if err := s.writeMetadata(); err != nil {
+		return err
+	}
+
+	var seekTableBytes []byte
+	var err error
+	if s.hmacKey != nil {
+		seekTableBytes, err = s.writeHMAC()
+	} else {
+		seekTableBytes, err = s.EndStream()
+	}
 	if err != nil {
 		return err
 	}
 
+	if err = s.writeMagicPrefix(); err != nil {
+		return err
+	}
+
+	if s.pipeMode {
+		s.seekTable = seekTableBytes
+		return nil
+	}
+
 	_, err = s.env.WriteSeekTable(seekTableBytes)
 	return err
 }
+
+func (s *writerImpl) ResetTo(w io.Writer) {
+	s.Reset()
+	if e, ok := s.env.(*writerEnvImpl); ok {
+		e.w = w
+	} else {
+		s.env = &writerEnvImpl{w: w}
+	}
+}
+
+func (s *writerImpl) NumFrames() int64 {
+	return s.numFrames.Load()
+}
+
+func (s *writerImpl) TotalBytesWritten() uint64 {
+	return s.bytesWritten.Load()
+}
+
+func (s *writerImpl) SeekTable() []byte {
+	return s.seekTable
+}
@@ -2,10 +2,12 @@ package seekable
 
 import (
 	"fmt"
+	"log/slog"
 
 	"go.uber.org/zap"
 
 	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/env"
+	"github.com/SaveTheRbtz/zstd-seekable-format-go/pkg/logadapter"
 )
 
 type wOption func(*writerImpl) error
@@ -14,13 +16,207 @@ func WithWLogger(l *zap.Logger) wOption {
 	return func(w *writerImpl) error { w.logger = l; return nil }
 }
 
+// WithWSlogLogger is like WithWLogger, but logs to the standard library logger, see logadapter.
+func WithWSlogLogger(l *slog.Logger) wOption {
+	return func(w *writerImpl) error { w.logger = logadapter.NewZapLogger(l); return nil }
+}
+
 func WithWEnvironment(e env.WEnvironment) wOption {
 	return func(w *writerImpl) error { w.env = e; return nil }
 }
 
+// WithWMetrics sets the observer of written frames, see MetricsObserver.
+func WithWMetrics(m MetricsObserver) wOption {
+	return func(w *writerImpl) error { w.metrics = m; return nil }
+}
+
+// WithWTracer sets the tracer of frame compression, see FrameTracer.
+func WithWTracer(t FrameTracer) wOption {
+	return func(w *writerImpl) error { w.tracer = t; return nil }
+}
+
+// WithWDictionary makes the writer compress frames with its own ZSTD encoder that uses dict,
+// e.g. trained with `zstd --train` or zstd.BuildDict.  Since frames are compressed independently,
+// a dictionary considerably improves the compression ratio of small similar frames, e.g. JSON records.
+// The encoder passed to NewWriter must be nil; to use other encoder settings,
+// pass an encoder created with zstd.WithEncoderDict instead of this option.
+//
+// Such streams need to be opened with WithRDictionary and the same dictionary.
+func WithWDictionary(dict []byte) wOption {
+	return func(w *writerImpl) error {
+		if len(dict) == 0 {
+			return fmt.Errorf("dictionary is empty")
+		}
+		w.dict = dict
+		return nil
+	}
+}
+
+// WithFrameMetadata stores a key-value pair in a skippable frame that Close writes right before
+// the seek table, so that it can be retrieved with GetFrameMetadata.  Can be passed multiple times
+// with different keys.  Metadata frames are listed in the seek table as frames with no decompressed data,
+// so they are transparently skipped both by ZSTD decoders and seekable readers.
+//
+// Cannot be combined with WithHierarchicalIndex.
+func WithFrameMetadata(key string, value []byte) wOption {
+	return func(w *writerImpl) error {
+		for _, m := range w.metadata {
+			if m.key == key {
+				return fmt.Errorf("duplicate metadata key: %q", key)
+			}
+		}
+		w.metadata = append(w.metadata, frameMetadata{key: key, value: value})
+		return nil
+	}
+}
+
+// WithWHMAC makes Close sign the seek table with HMAC-SHA256 keyed by key, so that streams
+// stored in untrusted locations can be checked for tampering with the seek table by readers
+// opened WithRHMAC.  The HMAC is stored in a skippable frame right before the seek table,
+// which is listed in the seek table as a frame with no decompressed data.
+// Note that only the seek table is signed, frames themselves are protected by checksums.
+//
+// Cannot be combined with WithHierarchicalIndex, WithPipeMode or EndStreamTo.
+func WithWHMAC(key []byte) wOption {
+	return func(w *writerImpl) error {
+		if len(key) == 0 {
+			return fmt.Errorf("HMAC key is empty")
+		}
+		w.hmacKey = key
+		return nil
+	}
+}
+
+// WithWEncryption makes the writer encrypt each compressed frame and the seek table with AES-256-GCM
+// using the 32-byte key, so that streams can be stored in untrusted locations.  Such streams need
+// to be opened WithREncryption and the same key.  Frame nonces are derived from the key,
+// a random salt of the stream and the frame id, so frames can not be reordered undetected;
+// the number and sizes of frames are not hidden.
+//
+// Cannot be combined with WithHierarchicalIndex, WithWHMAC, WithFrameMetadata or seek table
+// options that change its format, e.g. WithCompressSeekTable.
+func WithWEncryption(key []byte) wOption {
+	return func(w *writerImpl) error {
+		if len(key) != encryptionKeySize {
+			return fmt.Errorf("encryption key must be %d bytes: %d", encryptionKeySize, len(key))
+		}
+		w.encryptionKey = key
+		return nil
+	}
+}
+
+// WithPipeMode makes Close keep the seek table in memory instead of appending it
+// to the output, which is useful for non-seekable outputs like pipes or sockets.
+// The seek table is then available via SeekTable, so it can be sent out-of-band and
+// passed to NewReader with WithSeekTableBytes.
+//
+// Cannot be combined with WithHierarchicalIndex.
+func WithPipeMode() wOption {
+	return func(w *writerImpl) error { w.pipeMode = true; return nil }
+}
+
+// WithChunkedSeekTable splits the seek table across multiple skippable frames
+// each holding at most chunkEntries entries.  This allows streams whose
+// seek table exceeds the maximum size of a single skippable frame.
+//
+// NB! This is an extension of the seekable format: such streams can only be read
+// by this implementation.  Decoders compliant with the spec will reject them.
+func WithChunkedSeekTable(chunkEntries int) wOption {
+	return func(w *writerImpl) error {
+		if chunkEntries < 1 {
+			return fmt.Errorf("chunk entries must be positive: %d", chunkEntries)
+		}
+		if int64(chunkEntries)*12+chunkedSeekTableTrailerSize > maxDecoderFrameSize {
+			return fmt.Errorf("seek table chunk is too big: %d entries", chunkEntries)
+		}
+		w.chunkEntries = chunkEntries
+		return nil
+	}
+}
+
+// WithCompressSeekTable compresses seek table entries with the writer's encoder.
+// Entries of streams with regularly sized frames compress very well,
+// which considerably reduces the size of seek tables for streams with many frames.
+// The reader needs a decoder to parse such seek tables, even for the Decoder.
+// Cannot be combined with WithChunkedSeekTable or WithHierarchicalIndex.
+//
+// NB! This is an extension of the seekable format: such streams can only be read
+// by this implementation.  Decoders compliant with the spec will reject them.
+func WithCompressSeekTable() wOption {
+	return func(w *writerImpl) error { w.compressSeekTable = true; return nil }
+}
+
+// WithVarintSeekTable encodes seek table entries as varint deltas of sizes of consecutive frames,
+// which considerably reduces the size of seek tables for streams with similarly sized frames.
+// Unlike WithCompressSeekTable, it does not need a decoder to parse the seek table.
+// Cannot be combined with WithChunkedSeekTable, WithHierarchicalIndex or WithCompressSeekTable.
+//
+// NB! This is an extension of the seekable format: such streams can only be read
+// by this implementation.  Decoders compliant with the spec will reject them.
+func WithVarintSeekTable() wOption {
+	return func(w *writerImpl) error { w.varintSeekTable = true; return nil }
+}
+
+// WithHierarchicalIndex makes the writer produce a two-level seek table: each span of
+// coarseGranularity frames is followed by its own fine index, while the seek table at the end
+// of the stream only has one entry per span.  This keeps the memory footprint of both
+// the writer and the reader proportional to the number of spans, which makes streams with
+// billions of frames practical.  See marshalFineIndex for the layout.
+//
+// Uncompressed and compressed size of a span is limited to 4GiB each.
+// Cannot be combined with WithChunkedSeekTable.
+//
+// NB! This is an extension of the seekable format: such streams can only be read
+// by this implementation.  Decoders compliant with the spec will reject them.
+func WithHierarchicalIndex(coarseGranularity int) wOption {
+	return func(w *writerImpl) error {
+		if coarseGranularity < 1 {
+			return fmt.Errorf("coarse granularity must be positive: %d", coarseGranularity)
+		}
+		if fineIndexSize(int64(coarseGranularity), 12) > maxDecoderFrameSize {
+			return fmt.Errorf("fine index is too big: %d entries", coarseGranularity)
+		}
+		w.spanFrames = coarseGranularity
+		return nil
+	}
+}
+
+// WithWMagicPrefix prepends magic to the output before the first compressed frame,
+// so that the stream can be detected by its head.  The prefix is written through
+// the environment's WriteFrame.
+//
+// Such streams need to be opened with WithRMagicPrefix.  Encoder does not
+// write the prefix, the caller is responsible for prepending it.
+func WithWMagicPrefix(magic []byte) wOption {
+	return func(w *writerImpl) error {
+		if int64(len(magic)) > maxDecoderFrameSize {
+			return fmt.Errorf("magic prefix is too big: %d > %d", len(magic), maxDecoderFrameSize)
+		}
+		w.magicPrefix = magic
+		return nil
+	}
+}
+
+// WithDeduplication makes the writer replace frames identical to the already written ones with
+// small reference skippable frames pointing to the first occurrence, which is useful for data with
+// repeated blocks, e.g. disk images.  Frames are still compressed, since their content is identified
+// by the checksum along with the hash of the compressed data, and the map of written frames is kept
+// in memory until the writer is reset.
+//
+// Readers of this package resolve the references transparently.  Other readers, including
+// plain ZSTD decoders, skip reference frames, so such streams are not compliant with the spec.
+// Deduplication can not be used with hierarchical index or encryption.
+func WithDeduplication() wOption {
+	return func(w *writerImpl) error {
+		w.dedup = make(map[dedupKey]dedupRef)
+		return nil
+	}
+}
+
 type writeManyOptions struct {
-	concurrency   int
-	writeCallback func(uint32)
+	concurrency          int
+	writeCallback        func(decompSize, compSize uint32)
+	maxDecompressedBytes int64
 }
 
 type WriteManyOption func(options *writeManyOptions) error
@@ -35,9 +231,27 @@ func WithConcurrency(concurrency int) WriteManyOption {
 	}
 }
 
-func WithWriteCallback(cb func(size uint32)) WriteManyOption {
+// WithWriteCallback makes WriteMany call cb after each frame is written with its decompressed
+// and compressed sizes, e.g. to report progress.  The compressed size does not include fine indexes
+// of the hierarchical index.  cb is called sequentially from a single goroutine.
+func WithWriteCallback(cb func(decompSize, compSize uint32)) WriteManyOption {
 	return func(options *writeManyOptions) error {
 		options.writeCallback = cb
 		return nil
 	}
 }
+
+// WithMaxDecompressedBytes makes WriteMany stop reading from the frame source once
+// more than n bytes of uncompressed data were written and return *QuotaExceededError.
+//
+// Frames that are already being compressed are still written, so the limit
+// can be exceeded by up to a few frames per unit of concurrency.
+func WithMaxDecompressedBytes(n int64) WriteManyOption {
+	return func(options *writeManyOptions) error {
+		if n < 1 {
+			return fmt.Errorf("max decompressed bytes must be positive: %d", n)
+		}
+		options.maxDecompressedBytes = n
+		return nil
+	}
+}
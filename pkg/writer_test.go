@@ -8,11 +8,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
 )
 
 func TestWriter(t *testing.T) {
@@ -72,6 +79,339 @@ func TestWriter(t *testing.T) {
 	assert.Equal(t, concat, readBuf[:n])
 }
 
+func TestCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b, checkpoint bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+
+	// The checkpoint of a writer without frames is the empty seek table.
+	_, err = w.Checkpoint(&checkpoint)
+	require.NoError(t, err)
+	d, err := NewDecoder(checkpoint.Bytes(), dec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), d.NumFrames())
+	assert.Zero(t, b.Len())
+	checkpoint.Reset()
+
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test2"))
+	require.NoError(t, err)
+
+	n, err := w.Checkpoint(&checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, int64(checkpoint.Len()), n)
+	compressedSoFar := b.Len()
+
+	_, err = w.Write([]byte("test3"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Len(t, w.(*writerImpl).frameEntries, 3)
+
+	// The checkpoint describes the first two frames only.
+	d, err = NewDecoder(checkpoint.Bytes(), dec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), d.NumFrames())
+	assert.Equal(t, int64(len("testtest2")), d.Size())
+
+	// Compressed data written so far plus the checkpoint is a valid stream.
+	recovered := append(b.Bytes()[:compressedSoFar:compressedSoFar], checkpoint.Bytes()...)
+	r, err := NewReader(bytes.NewReader(recovered), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testtest2"), all)
+
+	// The main stream still gets the complete seek table.
+	r, err = NewReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	all, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testtest2test3"), all)
+
+	_, err = w.Checkpoint(failingWriter{})
+	require.ErrorContains(t, err, "failed to write checkpoint")
+}
+
+func TestSnapshotSeekTable(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc, WithPipeMode())
+	require.NoError(t, err)
+
+	empty, err := w.SnapshotSeekTable()
+	require.NoError(t, err)
+	d, err := NewDecoder(empty, dec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), d.NumFrames())
+
+	for i := 0; i < 3; i++ {
+		_, err = w.Write(makeTestFrame(t, i))
+		require.NoError(t, err)
+	}
+	compressedSoFar := b.Len()
+	snapshot, err := w.SnapshotSeekTable()
+	require.NoError(t, err)
+	assert.Equal(t, compressedSoFar, b.Len())
+	assert.Nil(t, w.SeekTable())
+
+	for i := 3; i < 5; i++ {
+		_, err = w.Write(makeTestFrame(t, i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	d, err = NewDecoder(snapshot, dec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), d.NumFrames())
+	d, err = NewDecoder(w.SeekTable(), dec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), d.NumFrames())
+
+	// Snapshot describes the prefix of the stream.
+	r, err := NewReader(bytes.NewReader(append(b.Bytes()[:compressedSoFar:compressedSoFar], snapshot...)), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Join([][]byte{makeTestFrame(t, 0), makeTestFrame(t, 1), makeTestFrame(t, 2)}, nil), all)
+
+	w, err = NewWriter(io.Discard, enc, WithHierarchicalIndex(2))
+	require.NoError(t, err)
+	_, err = w.SnapshotSeekTable()
+	require.ErrorContains(t, err, "seek table snapshot is not supported with hierarchical index")
+	require.NoError(t, w.Close())
+}
+
+func TestEndStreamTo(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	var data, index bytes.Buffer
+	w, err := NewWriter(&data, enc)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test2"))
+	require.NoError(t, err)
+
+	n, err := w.EndStreamTo(&index)
+	require.NoError(t, err)
+	assert.Equal(t, int64(index.Len()), n)
+
+	// Seek table is written only once.
+	dataLen := data.Len()
+	require.NoError(t, w.Close())
+	assert.Equal(t, dataLen, data.Len())
+	_, err = w.EndStreamTo(&index)
+	require.ErrorContains(t, err, "seek table was already written")
+
+	// Index and data files together are a valid stream.
+	d, err := NewDecoder(index.Bytes(), dec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), d.NumFrames())
+
+	r, err := NewReader(bytes.NewReader(append(data.Bytes(), index.Bytes()...)), dec, WithSizeValidation())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(sourceString), all)
+
+	// Errors.
+	w, err = NewWriter(io.Discard, enc)
+	require.NoError(t, err)
+	_, err = w.EndStreamTo(failingWriter{})
+	require.ErrorContains(t, err, "failed to write seek table")
+
+	w, err = NewWriter(io.Discard, enc, WithHierarchicalIndex(2))
+	require.NoError(t, err)
+	_, err = w.EndStreamTo(&index)
+	require.ErrorContains(t, err, "not supported with hierarchical index")
+}
+
+func TestPipeMode(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	pr, pw := io.Pipe()
+	w, err := NewWriter(pw, enc, WithPipeMode())
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() { errCh <- pw.CloseWithError(err) }()
+		for i := 0; i < 10; i++ {
+			if _, err = w.Write(makeTestFrame(t, i)); err != nil {
+				return
+			}
+		}
+		err = w.Close()
+	}()
+
+	data, err := io.ReadAll(pr)
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+
+	// Seek table is not a part of the stream.
+	seekTable := w.SeekTable()
+	require.NotEmpty(t, seekTable)
+	ok, err := DetectSeekable(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	r, err := NewReader(bytes.NewReader(data), dec, WithSeekTableBytes(seekTable), WithSizeValidation())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	assert.Equal(t, int64(10), r.(Decoder).NumFrames())
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	var expected []byte
+	for i := 0; i < 10; i++ {
+		expected = append(expected, makeTestFrame(t, i)...)
+	}
+	assert.Equal(t, expected, all)
+
+	// Without pipe mode there is no seek table to return.
+	w, err = NewWriter(io.Discard, enc)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Nil(t, w.SeekTable())
+
+	// Errors.
+	_, err = NewWriter(io.Discard, enc, WithPipeMode(), WithHierarchicalIndex(2))
+	require.ErrorContains(t, err, "pipe mode can not be used with hierarchical index")
+	_, err = NewReader(bytes.NewReader(data), dec, WithSeekTableBytes(nil))
+	require.ErrorContains(t, err, "seek table is empty")
+}
+
+func TestAppendWriter(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	appendFrames := func(fn string, from, to int, opts ...wOption) error {
+		f, err := os.OpenFile(fn, os.O_RDWR, 0)
+		require.NoError(t, err)
+		defer f.Close()
+
+		w, err := NewAppendWriter(f, enc, opts...)
+		if err != nil {
+			return err
+		}
+		for i := from; i < to; i++ {
+			_, err = w.Write(makeTestFrame(t, i))
+			require.NoError(t, err)
+		}
+		return w.Close()
+	}
+	checkFrames := func(fn string, n int) {
+		f, err := os.Open(fn)
+		require.NoError(t, err)
+		defer f.Close()
+
+		r, err := NewReader(f, dec, WithSizeValidation())
+		require.NoError(t, err)
+		defer func() { require.NoError(t, r.Close()) }()
+		require.Equal(t, int64(n), r.(Decoder).NumFrames())
+
+		var expected []byte
+		for i := 0; i < n; i++ {
+			expected = append(expected, makeTestFrame(t, i)...)
+		}
+		all, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, expected, all)
+	}
+
+	fn := filepath.Join(t.TempDir(), "test.zst")
+	f, err := os.Create(fn)
+	require.NoError(t, err)
+	w, err := NewWriter(f, enc)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = w.Write(makeTestFrame(t, i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+	checkFrames(fn, 3)
+
+	require.NoError(t, appendFrames(fn, 3, 5))
+	checkFrames(fn, 5)
+
+	// Seek table format can be changed, the file is truncated if the new one is shorter.
+	require.NoError(t, appendFrames(fn, 5, 6, WithChunkedSeekTable(2)))
+	checkFrames(fn, 6)
+	require.NoError(t, appendFrames(fn, 6, 6, WithVarintSeekTable()))
+	checkFrames(fn, 6)
+	require.NoError(t, appendFrames(fn, 6, 7, WithCompressSeekTable()))
+	checkFrames(fn, 7)
+	require.NoError(t, appendFrames(fn, 7, 8))
+	checkFrames(fn, 8)
+
+	// Errors.
+	require.ErrorContains(t, appendFrames(fn, 8, 8, WithHierarchicalIndex(2)),
+		"append writer can not be used with hierarchical index")
+	require.ErrorContains(t, appendFrames(fn, 8, 9, WithWEncryption(bytes.Repeat([]byte{0x42}, encryptionKeySize))),
+		"append writer can not be used with encryption")
+	checkFrames(fn, 8)
+
+	require.NoError(t, os.WriteFile(fn, []byte("not a seekable stream"), 0o600))
+	require.ErrorContains(t, appendFrames(fn, 0, 1), "failed to parse existing stream")
+
+	require.NoError(t, os.WriteFile(fn, noChecksum, 0o600))
+	require.ErrorContains(t, appendFrames(fn, 0, 1), "appending to streams without checksums is not supported")
+
+	var b bytes.Buffer
+	w, err = NewWriter(&b, enc, WithHierarchicalIndex(2))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, os.WriteFile(fn, b.Bytes(), 0o600))
+	require.ErrorContains(t, appendFrames(fn, 0, 1), "appending to streams with hierarchical index is not supported")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (n int, err error) {
+	return 0, errors.New("failed")
+}
+
 func makeTestFrame(t *testing.T, idx int) []byte {
 	var b bytes.Buffer
 	for i := 0; i < 100; i++ {
@@ -94,6 +434,174 @@ func makeTestFrameSource(frames [][]byte) FrameSource {
 	}
 }
 
+func TestWriteRaw(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRaw(checksum[:17], 4, binary.LittleEndian.Uint32(checksum[51:])))
+	require.NoError(t, w.WriteRaw(checksum[17:35], 5, binary.LittleEndian.Uint32(checksum[63:])))
+	require.ErrorContains(t, w.WriteRaw(nil, 0, 0), "frame is too small")
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, checksum, b.Bytes())
+}
+
+func TestWriteFrom(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	const chunkSize = 64 << 10
+	src := make([]byte, 1<<20+1)
+	_, err = rand.Read(src)
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1 << 20, 1<<20 + 1} {
+		var b bytes.Buffer
+		w, err := NewWriter(&b, enc)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteFrom(bytes.NewReader(src[:size]), chunkSize))
+		require.NoError(t, w.Close())
+
+		r, err := NewReader(bytes.NewReader(b.Bytes()), dec)
+		require.NoError(t, err)
+		assert.Equal(t, int64((size+chunkSize-1)/chunkSize), r.(Decoder).NumFrames(), "size %d", size)
+		actual, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, src[:size], actual, "size %d", size)
+		require.NoError(t, r.Close())
+	}
+
+	w, err := NewWriter(io.Discard, enc)
+	require.NoError(t, err)
+	require.ErrorContains(t, w.WriteFrom(bytes.NewReader(src), 0), "chunk size must be in")
+	err = w.WriteFrom(io.MultiReader(bytes.NewReader(src[:chunkSize]), iotest.ErrReader(errors.New("read failed"))), chunkSize)
+	require.ErrorContains(t, err, "failed to read chunk: read failed")
+
+	w, err = NewWriter(failingWriter{}, enc)
+	require.NoError(t, err)
+	require.ErrorContains(t, w.WriteFrom(bytes.NewReader(src), chunkSize), "failed to write frame: failed")
+}
+
+func TestDictionary(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	record := func(i int) []byte {
+		return []byte(fmt.Sprintf(`{"id":%d,"name":"user%d","email":"user%d@example.com",`+
+			`"active":true,"roles":["reader","writer"],"settings":{"theme":"dark","language":"en"}}`, i, i, i))
+	}
+	var samples [][]byte
+	var history []byte
+	for i := 1000; i < 1100; i++ {
+		samples = append(samples, record(i*7919))
+		history = append(history, record(i)...)
+	}
+	dict, err := zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       1,
+		Contents: samples,
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+	})
+	require.NoError(t, err)
+
+	write := func(enc ZSTDEncoder, opts ...wOption) []byte {
+		var b bytes.Buffer
+		w, err := NewWriter(&b, enc, opts...)
+		require.NoError(t, err)
+		for i := 0; i < 100; i++ {
+			_, err = w.Write(record(i))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		return b.Bytes()
+	}
+	plain := write(enc)
+	withDict := write(nil, WithWDictionary(dict))
+	assert.LessOrEqual(t, float64(len(withDict)), 0.8*float64(len(plain)),
+		"with dictionary: %d, without: %d", len(withDict), len(plain))
+
+	r, err := NewReader(bytes.NewReader(withDict), nil, WithRDictionary(dict))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	for i := 0; i < 100; i++ {
+		expected := record(i)
+		actual := make([]byte, len(expected))
+		_, err = io.ReadFull(r, actual)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	}
+
+	r, err = NewReader(bytes.NewReader(withDict), nil, WithDefaultDecoder())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	_, err = io.ReadAll(r)
+	require.ErrorContains(t, err, "failed to decompress")
+
+	_, err = NewWriter(io.Discard, enc, WithWDictionary(nil))
+	require.ErrorContains(t, err, "dictionary is empty")
+	_, err = NewWriter(io.Discard, enc, WithWDictionary(dict))
+	require.ErrorContains(t, err, "dictionary can not be used with the passed encoder")
+	_, err = NewWriter(io.Discard, nil, WithWDictionary(dict), WithChunkedSeekTable(2), WithHierarchicalIndex(2))
+	require.ErrorContains(t, err, "mutually exclusive")
+
+	// Owned encoder is closed, but still works for the next stream.
+	w, err := NewWriter(io.Discard, nil, WithWDictionary(dict))
+	require.NoError(t, err)
+	require.NotNil(t, w.(*writerImpl).ownEnc)
+	require.NoError(t, w.Close())
+	w.ResetTo(io.Discard)
+	_, err = w.Write(record(0))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, err = NewReader(bytes.NewReader(withDict), nil, WithRDictionary(nil))
+	require.ErrorContains(t, err, "dictionary is empty")
+}
+
+func TestWriterResetTo(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	opts := []wOption{WithFrameMetadata("key", []byte("value")), WithWMagicPrefix([]byte("magic"))}
+	write := func(w Writer, frames ...string) {
+		for _, f := range frames {
+			_, err := w.Write([]byte(f))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+	}
+
+	var expected bytes.Buffer
+	fresh, err := NewWriter(&expected, enc, opts...)
+	require.NoError(t, err)
+	write(fresh, "test3", "test4")
+
+	var first, second bytes.Buffer
+	w, err := NewWriter(&first, enc, opts...)
+	require.NoError(t, err)
+	write(w, "test", "test2")
+	firstBytes := bytes.Clone(first.Bytes())
+
+	w.ResetTo(&second)
+	write(w, "test3", "test4")
+	assert.Equal(t, expected.Bytes(), second.Bytes())
+	assert.Equal(t, firstBytes, first.Bytes())
+}
+
 func TestConcurrentWriter(t *testing.T) {
 	t.Parallel()
 
@@ -118,13 +626,16 @@ func TestConcurrentWriter(t *testing.T) {
 	concurrentWriter, err := NewWriter(bw, enc)
 	require.NoError(t, err)
 
-	var totalWritten int
+	var totalWritten, totalCompressed int
 	err = concurrentWriter.WriteMany(ctx, makeTestFrameSource(frames), WithConcurrency(5),
-		WithWriteCallback(func(size uint32) {
-			totalWritten += int(size)
+		WithWriteCallback(func(decompSize, compSize uint32) {
+			totalWritten += int(decompSize)
+			totalCompressed += int(compSize)
 		}))
 	require.NoError(t, err)
 	require.Equal(t, len(concat), totalWritten)
+	// Only frames are written before Close.
+	require.Equal(t, b.Len(), totalCompressed)
 
 	// Write one at a time
 	var nb bytes.Buffer
@@ -151,6 +662,272 @@ func TestConcurrentWriter(t *testing.T) {
 	decoded, err := dec.DecodeAll(b.Bytes(), nil)
 	require.NoError(t, err)
 	assert.Equal(t, concat, decoded)
+
+	// Compressed sizes passed to the callback match the frames of the seek table.
+	require.NoError(t, concurrentWriter.Close())
+	r, err := NewReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	var seekTableCompressed int
+	for index := range r.(Decoder).IterFrames(ctx) {
+		seekTableCompressed += int(index.CompSize)
+	}
+	assert.Equal(t, totalCompressed, seekTableCompressed)
+}
+
+func TestWriterCounters(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc, WithFrameMetadata("key", []byte("value")))
+	require.NoError(t, err)
+	assert.Zero(t, w.NumFrames())
+	assert.Zero(t, w.TotalBytesWritten())
+
+	var total uint64
+	for i := 0; i < 3; i++ {
+		frame := makeTestFrame(t, i)
+		_, err = w.Write(frame)
+		require.NoError(t, err)
+		total += uint64(len(frame))
+	}
+	assert.Equal(t, int64(3), w.NumFrames())
+	assert.Equal(t, total, w.TotalBytesWritten())
+
+	var frames [][]byte
+	for i := 0; i < 20; i++ {
+		frame := makeTestFrame(t, i)
+		frames = append(frames, frame)
+		total += uint64(len(frame))
+	}
+	source := makeTestFrameSource(frames)
+	produced := int64(3)
+	err = w.WriteMany(context.Background(), func() ([]byte, error) {
+		// Counters are updated concurrently by WriteMany, frames are only counted once written.
+		assert.LessOrEqual(t, w.NumFrames(), produced)
+		assert.GreaterOrEqual(t, w.NumFrames(), int64(3))
+		produced++
+		return source()
+	}, WithConcurrency(5))
+	require.NoError(t, err)
+	assert.Equal(t, int64(23), w.NumFrames())
+	assert.Equal(t, total, w.TotalBytesWritten())
+
+	// Metadata frames written on Close are not counted.
+	require.NoError(t, w.Close())
+	assert.Equal(t, int64(23), w.NumFrames())
+	assert.Equal(t, total, w.TotalBytesWritten())
+
+	w.ResetTo(io.Discard)
+	assert.Zero(t, w.NumFrames())
+	assert.Zero(t, w.TotalBytesWritten())
+
+	// Appended streams count all frames of the existing seek table, including the metadata one.
+	fn := filepath.Join(t.TempDir(), "append.zst")
+	require.NoError(t, os.WriteFile(fn, b.Bytes(), 0o600))
+	f, err := os.OpenFile(fn, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer f.Close()
+	aw, err := NewAppendWriter(f, enc)
+	require.NoError(t, err)
+	assert.Equal(t, int64(24), aw.NumFrames())
+	assert.Equal(t, total, aw.TotalBytesWritten())
+}
+
+func TestWriteManyChunked(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+
+	src := make([]byte, 1000)
+	_, err = rand.Read(src)
+	require.NoError(t, err)
+
+	fixedSize := func(r io.Reader) ([]byte, error) {
+		chunk := make([]byte, 64)
+		n, err := io.ReadFull(r, chunk)
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+		return chunk[:n], nil
+	}
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteManyChunked(ctx, bytes.NewReader(src), fixedSize, WithConcurrency(3)))
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	assert.Equal(t, int64(16), r.(Decoder).NumFrames())
+	assert.Equal(t, uint32(1000%64), r.(Decoder).GetIndexByID(15).DecompSize)
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, src, all)
+
+	// Chunker errors.
+	w, err = NewWriter(io.Discard, enc)
+	require.NoError(t, err)
+	err = w.WriteManyChunked(ctx, failingReader{}, fixedSize)
+	require.ErrorIs(t, err, errFailingReader)
+	require.ErrorContains(t, err, "frame source failed")
+}
+
+var errFailingReader = errors.New("failing reader")
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) { return 0, errFailingReader }
+
+func TestChunkedSeekTable(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+
+	_, err = NewWriter(nil, enc, WithChunkedSeekTable(0))
+	require.ErrorContains(t, err, "chunk entries must be positive")
+	_, err = NewWriter(nil, enc, WithChunkedSeekTable(maxDecoderFrameSize))
+	require.ErrorContains(t, err, "seek table chunk is too big")
+
+	for _, frameCount := range []int{0, 1, 3, 4, 7} {
+		frameCount := frameCount
+		t.Run(fmt.Sprint(frameCount), func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			w, err := NewWriter(&b, enc, WithChunkedSeekTable(3))
+			require.NoError(t, err)
+
+			concat := []byte{}
+			for i := 0; i < frameCount; i++ {
+				frame := makeTestFrame(t, i)
+				concat = append(concat, frame...)
+				_, err = w.Write(frame)
+				require.NoError(t, err)
+			}
+			require.NoError(t, w.Close())
+
+			// Chunked flag is set.
+			buf := b.Bytes()
+			assert.Equal(t, chunkedFlagBit|checksumFlagBit, buf[len(buf)-5])
+
+			// Seekable decompression.
+			r, err := NewReader(bytes.NewReader(buf), dec, WithSizeValidation())
+			require.NoError(t, err)
+			defer func() { require.NoError(t, r.Close()) }()
+			assert.Equal(t, int64(frameCount), r.(*readerImpl).NumFrames())
+
+			all, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, len(concat), len(all))
+			assert.Equal(t, concat, all)
+
+			// Decoder.
+			seekTable, err := w.(*writerImpl).EndStream()
+			require.NoError(t, err)
+			d, err := NewDecoder(seekTable, dec)
+			require.NoError(t, err)
+			assert.Equal(t, int64(frameCount), d.NumFrames())
+			assert.Equal(t, int64(len(concat)), d.Size())
+
+			// Native decompression.
+			decoded, err := dec.DecodeAll(buf, nil)
+			require.NoError(t, err)
+			assert.Equal(t, len(concat), len(decoded))
+		})
+	}
+}
+
+func TestCompressedSeekTable(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+
+	_, err = NewWriter(nil, enc, WithCompressSeekTable(), WithChunkedSeekTable(3))
+	require.ErrorContains(t, err, "compressed seek table can not be chunked or hierarchical")
+
+	// Regularly sized frames.
+	frame := bytes.Repeat([]byte("test"), 1024)
+	concat := bytes.Repeat(frame, 1000)
+
+	var plain, compressed bytes.Buffer
+	pw, err := NewWriter(&plain, enc)
+	require.NoError(t, err)
+	cw, err := NewWriter(&compressed, enc, WithCompressSeekTable())
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		_, err = pw.Write(frame)
+		require.NoError(t, err)
+		_, err = cw.Write(frame)
+		require.NoError(t, err)
+	}
+	require.NoError(t, pw.Close())
+	require.NoError(t, cw.Close())
+
+	plainTable, err := pw.(*writerImpl).EndStream()
+	require.NoError(t, err)
+	compressedTable, err := cw.(*writerImpl).EndStream()
+	require.NoError(t, err)
+	assert.Less(t, len(compressedTable), len(plainTable)/10)
+	assert.Equal(t, plain.Len()-len(plainTable), compressed.Len()-len(compressedTable))
+
+	// Compressed flag is set.
+	buf := compressed.Bytes()
+	assert.Equal(t, compressedFlagBit|checksumFlagBit, buf[len(buf)-5])
+
+	// Seekable decompression.
+	r, err := NewReader(bytes.NewReader(buf), dec, WithSizeValidation())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	assert.Equal(t, int64(1000), r.(*readerImpl).NumFrames())
+
+	all, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, concat, all)
+
+	// Decoder.
+	d, err := NewDecoder(compressedTable, dec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), d.NumFrames())
+	assert.Equal(t, int64(len(concat)), d.Size())
+
+	b, err := d.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, plainTable, b)
+
+	_, err = NewDecoder(compressedTable, nil)
+	require.ErrorContains(t, err, "decoder is required")
+
+	// Corrupted entries.
+	corrupted := bytes.Clone(compressedTable)
+	corrupted[8] ^= 0xff
+	_, err = NewDecoder(corrupted, dec)
+	require.ErrorContains(t, err, "failed to decompress seek table")
+
+	// Native decompression.
+	decoded, err := dec.DecodeAll(buf, nil)
+	require.NoError(t, err)
+	assert.Equal(t, concat, decoded)
 }
 
 type failingWriteEnvironment struct {
@@ -205,6 +982,55 @@ func TestConcurrentWriterErrors(t *testing.T) {
 	assert.ErrorContains(t, err, "partial write")
 }
 
+// slowWriteEnvironment delays each write.
+type slowWriteEnvironment struct {
+	delay time.Duration
+}
+
+func (e slowWriteEnvironment) WriteFrame(p []byte) (n int, err error) {
+	time.Sleep(e.delay)
+	return len(p), nil
+}
+
+func (e slowWriteEnvironment) WriteSeekTable(p []byte) (n int, err error) {
+	return len(p), nil
+}
+
+// TestWriteManyCancel is not parallel, so that goleak only sees its own goroutines.
+func TestWriteManyCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+	require.NoError(t, err)
+
+	for _, we := range []slowWriteEnvironment{{delay: time.Millisecond}, {delay: 20 * time.Millisecond}} {
+		w, err := NewWriter(nil, enc, WithWEnvironment(we))
+		require.NoError(t, err)
+
+		// Endless source.
+		frameSource := func() ([]byte, error) { return []byte(sourceString), nil }
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		start := time.Now()
+		err = w.WriteMany(ctx, frameSource, WithConcurrency(4))
+		cancel()
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, "context deadline exceeded")
+		assert.Less(t, time.Since(start), time.Second)
+	}
+
+	w, err := NewWriter(nil, enc, WithWEnvironment(slowWriteEnvironment{}))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	n, err := w.WriteCtx(ctx, []byte(sourceString))
+	require.NoError(t, err)
+	assert.Equal(t, len(sourceString), n)
+	cancel()
+	n, err = w.WriteCtx(ctx, []byte(sourceString))
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, n)
+}
+
 type fakeWriteEnvironment struct {
 	bw io.Writer
 }
@@ -263,12 +1089,109 @@ func makeRepeatingFrameSource(frame []byte, count int) FrameSource {
 	}
 }
 
+func TestWriteManyMaxDecompressedBytes(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	require.NoError(t, err)
+	defer enc.Close()
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	const (
+		frameSize = 64 << 10
+		quota     = 1 << 20
+	)
+	frame := make([]byte, frameSize)
+	_, err = rand.Read(frame)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, enc)
+	require.NoError(t, err)
+
+	for _, n := range []int64{-1, 0} {
+		err = w.WriteMany(context.Background(), makeRepeatingFrameSource(frame, 160),
+			WithMaxDecompressedBytes(n))
+		require.ErrorContains(t, err, "max decompressed bytes must be positive")
+	}
+
+	var callbackBytes int64
+	// 10MB source with 1MB quota.
+	err = w.WriteMany(context.Background(), makeRepeatingFrameSource(frame, 160),
+		WithConcurrency(2),
+		WithMaxDecompressedBytes(quota),
+		WithWriteCallback(func(size, _ uint32) { callbackBytes += int64(size) }))
+	var quotaErr *QuotaExceededError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, int64(quota), quotaErr.Limit)
+	assert.Greater(t, quotaErr.Actual, int64(quota))
+	assert.Less(t, quotaErr.Actual, int64(2*quota))
+	assert.Equal(t, quotaErr.Actual, callbackBytes)
+
+	// Frames written so far form a valid stream.
+	require.NoError(t, w.Close())
+	r, err := NewReader(bytes.NewReader(b.Bytes()), dec)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+	assert.Equal(t, quotaErr.Actual, r.(*readerImpl).Size())
+
+	// Quota is not exceeded.
+	w, err = NewWriter(nullWriter{}, enc)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteMany(context.Background(), makeRepeatingFrameSource(frame, 16),
+		WithMaxDecompressedBytes(quota)))
+}
+
 type nullWriter struct{}
 
 func (nullWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+func BenchmarkWriterResetTo(b *testing.B) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	if err != nil {
+		b.Fatal(err)
+	}
+	src := []byte(sourceString)
+
+	write := func(w Writer) {
+		for i := 0; i < 16; i++ {
+			if _, err := w.Write(src); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w, err := NewWriter(io.Discard, enc)
+			if err != nil {
+				b.Fatal(err)
+			}
+			write(w)
+		}
+	})
+	b.Run("reset", func(b *testing.B) {
+		w, err := NewWriter(io.Discard, enc)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			w.ResetTo(io.Discard)
+			write(w)
+		}
+	})
+}
+
 func BenchmarkWrite(b *testing.B) {
 	ctx := context.Background()
 
@@ -304,3 +1227,92 @@ func BenchmarkWrite(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+// userCPUSeconds returns the CPU time spent by the process running Go code.
+// Runtime only updates CPU stats during GC, hence it is forced here.
+func userCPUSeconds() float64 {
+	runtime.GC()
+	sample := []metrics.Sample{{Name: "/cpu/classes/user:cpu-seconds"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindFloat64 {
+		return 0
+	}
+	return sample[0].Value.Float64()
+}
+
+// BenchmarkWriteComparison compares Write and WriteMany on identical input.
+// Besides throughput, it reports the average number of cores utilized
+// and the peak number of goroutines.
+func BenchmarkWriteComparison(b *testing.B) {
+	ctx := context.Background()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	require.NoError(b, err)
+	defer enc.Close()
+
+	report := func(b *testing.B, start time.Time, startCPU float64, goroutines int) {
+		b.StopTimer()
+		wall := time.Since(start).Seconds()
+		if wall > 0 {
+			b.ReportMetric((userCPUSeconds()-startCPU)/wall, "cores")
+		}
+		b.ReportMetric(float64(goroutines), "goroutines")
+	}
+
+	sizes := []int64{4 * 1024, 64 * 1024, 1 * 1024 * 1024}
+	for _, sz := range sizes {
+		// Compressible data so that compression is CPU-bound.
+		writeBuf := make([]byte, sz)
+		_, err := rand.Read(writeBuf)
+		require.NoError(b, err)
+		for i := range writeBuf {
+			writeBuf[i] &= 0x0f
+		}
+
+		b.Run(fmt.Sprintf("%d/Write", sz), func(b *testing.B) {
+			w, err := NewWriter(nullWriter{}, enc)
+			require.NoError(b, err)
+
+			b.SetBytes(sz)
+			startCPU := userCPUSeconds()
+			b.ResetTimer()
+			start := time.Now()
+
+			goroutines := runtime.NumGoroutine()
+			for i := 0; i < b.N; i++ {
+				_, err = w.Write(writeBuf)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			report(b, start, startCPU, goroutines)
+			require.NoError(b, w.Close())
+		})
+
+		for _, concurrency := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("%d/WriteMany-%d", sz, concurrency), func(b *testing.B) {
+				w, err := NewWriter(nullWriter{}, enc)
+				require.NoError(b, err)
+
+				goroutines := 0
+				frames := makeRepeatingFrameSource(writeBuf, b.N)
+				source := func() ([]byte, error) {
+					goroutines = max(goroutines, runtime.NumGoroutine())
+					return frames()
+				}
+
+				b.SetBytes(sz)
+				startCPU := userCPUSeconds()
+				b.ResetTimer()
+				start := time.Now()
+
+				err = w.WriteMany(ctx, source, WithConcurrency(concurrency))
+				if err != nil {
+					b.Fatal(err)
+				}
+				report(b, start, startCPU, goroutines)
+				require.NoError(b, w.Close())
+			})
+		}
+	}
+}
@@ -0,0 +1,36 @@
+# Fails if benchstat output contains a statistically significant regression
+# above the threshold (in percent).  Lower is better for */op units,
+# higher is better for throughput (*/s) ones.
+#
+# Usage: benchstat old.txt new.txt | awk -v threshold=10 -f benchcheck.awk
+
+/vs base/ {
+	unit = ""
+	for (i = 1; i <= NF; i++) {
+		if ($i ~ /\/op$/ || $i ~ /\/s$/) {
+			unit = $i
+			break
+		}
+	}
+	next
+}
+
+# Insignificant changes are reported as "~".
+match($0, /[+-][0-9.]+% \(p=/) {
+	delta = substr($0, RSTART, RLENGTH)
+	sub(/% \(p=/, "", delta)
+	delta += 0
+	if (unit ~ /\/s$/) {
+		delta = -delta
+	}
+	if (delta > threshold) {
+		print "regression (" unit "): " $0
+		failed = 1
+	}
+}
+
+END {
+	if (failed) {
+		exit 1
+	}
+}
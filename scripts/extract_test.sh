@@ -0,0 +1,40 @@
+#!/usr/bin/env bash
+# End-to-end test of `zstdseek extract`: compresses a 10MiB file, extracts a 1MiB chunk
+# from its middle and compares it with the same range cut from the original by dd.
+#
+# Usage: scripts/extract_test.sh (from the repository root)
+set -euo pipefail
+
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+(cd cmd/zstdseek && go build -o "$tmp/zstdseek" .)
+
+# Compressible input: random data interleaved with zeroes.
+for i in $(seq 10); do
+	head -c 524288 /dev/urandom
+	head -c 524288 /dev/zero
+done > "$tmp/input"
+
+# Small chunks, so that the extracted range is a small fraction of frames.
+"$tmp/zstdseek" -f "$tmp/input" -o "$tmp/input.zst" -c 16:64:128 -t
+frames=$("$tmp/zstdseek" info "$tmp/input.zst" | awk -F'\t' '$1 == "frames" { print $2 }')
+
+start=$((4 << 20))
+end=$((5 << 20))
+"$tmp/zstdseek" extract -f "$tmp/input.zst" -s "$start" -e "$end" -o "$tmp/chunk" -stats 2> "$tmp/stats"
+dd if="$tmp/input" of="$tmp/expected" bs=1048576 skip=4 count=1 status=none
+cmp "$tmp/expected" "$tmp/chunk"
+
+fetched=$(awk -F'\t' '$1 == "frames_fetched" { print $2 }' "$tmp/stats")
+# 1MiB with frames of at most 128KiB, plus partial frames at both ends.
+if [ "$fetched" -gt 18 ]; then
+	echo "too many frames fetched: $fetched of $frames" >&2
+	exit 1
+fi
+
+# Without -e, extract reads to EOF.
+"$tmp/zstdseek" extract -f "$tmp/input.zst" -s "$start" > "$tmp/tail"
+tail -c +$((start + 1)) "$tmp/input" | cmp - "$tmp/tail"
+
+echo "ok: fetched $fetched of $frames frames"